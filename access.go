@@ -0,0 +1,233 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"unsafe"
+	"weak"
+)
+
+// accessLevel is the access attribute of a composite object, as described
+// in section 3.3.2 of the PostScript Language Reference Manual.
+//
+// The interpreter stores access attributes with the storage of an object,
+// together with the length of arrays and strings.  Thus, restricting the
+// access to a dictionary restricts the access through all references to
+// the same dictionary, and restricting the access to an array or string
+// affects all references to the same elements, but not intervals of
+// different length obtained by `getinterval`.  Since empty arrays and
+// strings have no storage, their access cannot be restricted.
+type accessLevel uint8
+
+// These are the access levels supported by PostScript, in order of
+// increasing restriction.
+const (
+	accessUnlimited accessLevel = iota
+	accessReadOnly
+	accessExecuteOnly
+	accessNone
+)
+
+// accessKey identifies the storage of a composite object, see compositeID.
+// For arrays and strings, n is the length.
+type accessKey struct {
+	addr uintptr
+	n    int
+}
+
+// accessEntry is the access attribute of a composite object.  The weak
+// pointer allows to detect entries for objects which have been garbage
+// collected, since the address may be reused for a different object.
+type accessEntry struct {
+	obj   weak.Pointer[byte]
+	level accessLevel
+}
+
+// minAccessPrune is the minimal size of the access map, before entries for
+// collected objects are removed.
+const minAccessPrune = 64
+
+// accessKeyOf returns the key used to store the access attribute of obj.
+// The pointer is nil for objects which cannot have an access attribute.
+func accessKeyOf(obj Object) (accessKey, unsafe.Pointer) {
+	id := compositeID(obj)
+	if id == nil {
+		return accessKey{}, nil
+	}
+	key := accessKey{addr: uintptr(id)}
+	switch obj := obj.(type) {
+	case Array:
+		key.n = len(obj)
+	case Procedure:
+		key.n = len(obj)
+	case String:
+		key.n = len(obj)
+	}
+	return key, id
+}
+
+// accessOf returns the access attribute of obj.
+func (intp *Interpreter) accessOf(obj Object) accessLevel {
+	key, id := accessKeyOf(obj)
+	if id == nil {
+		return accessUnlimited
+	}
+	e, ok := intp.access[key]
+	if !ok || e.obj.Value() != (*byte)(id) {
+		return accessUnlimited
+	}
+	return e.level
+}
+
+// restrictAccess sets the access attribute of obj.  The access to an object
+// can only be reduced, never increased.
+func (intp *Interpreter) restrictAccess(op string, obj Object, level accessLevel) error {
+	key, id := accessKeyOf(obj)
+	if id == nil {
+		return nil
+	}
+	if intp.accessOf(obj) > level {
+		return intp.e(eInvalidaccess, "%s: cannot increase access", op)
+	}
+	if intp.access == nil {
+		intp.access = make(map[accessKey]accessEntry)
+		intp.accessPrune = minAccessPrune
+	}
+	intp.access[key] = accessEntry{obj: weak.Make((*byte)(id)), level: level}
+
+	if len(intp.access) >= intp.accessPrune {
+		for key, e := range intp.access {
+			if e.obj.Value() == nil {
+				delete(intp.access, key)
+			}
+		}
+		intp.accessPrune = max(2*len(intp.access), minAccessPrune)
+	}
+	return nil
+}
+
+// inheritAccess gives obj the access attribute of from.  This is used for
+// objects which share storage with another object.
+func (intp *Interpreter) inheritAccess(obj, from Object) {
+	if level := intp.accessOf(from); level != accessUnlimited {
+		intp.restrictAccess("", obj, level)
+	}
+}
+
+// checkRead returns an invalidaccess error, if obj cannot be read.
+func (intp *Interpreter) checkRead(op string, obj Object) error {
+	if intp.accessOf(obj) >= accessExecuteOnly {
+		return intp.e(eInvalidaccess, "%s: %T is not readable", op, obj)
+	}
+	return nil
+}
+
+// checkWrite returns an invalidaccess error, if obj cannot be modified.
+func (intp *Interpreter) checkWrite(op string, obj Object) error {
+	if intp.accessOf(obj) != accessUnlimited {
+		return intp.e(eInvalidaccess, "%s: %T is not writable", op, obj)
+	}
+	return nil
+}
+
+func bReadonly(intp *Interpreter) error {
+	return intp.setAccess("readonly", accessReadOnly)
+}
+
+func bExecuteonly(intp *Interpreter) error {
+	return intp.setAccess("executeonly", accessExecuteOnly)
+}
+
+func bNoaccess(intp *Interpreter) error {
+	return intp.setAccess("noaccess", accessNone)
+}
+
+// setAccess implements the `readonly`, `executeonly` and `noaccess`
+// operators.
+func (intp *Interpreter) setAccess(op string, level accessLevel) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	obj := intp.Stack[len(intp.Stack)-1]
+	switch obj.(type) {
+	case Array, Procedure, String:
+		// pass
+	case Dict:
+		if level == accessExecuteOnly {
+			return intp.e(eTypecheck, "%s: not allowed for dictionaries", op)
+		}
+	case nil, *file:
+		// There are no access restrictions for files.
+		return nil
+	default:
+		return intp.e(eTypecheck, "%s: invalid argument type %T", op, obj)
+	}
+	return intp.restrictAccess(op, obj, level)
+}
+
+func bRcheck(intp *Interpreter) error {
+	return intp.checkAccess("rcheck", accessReadOnly)
+}
+
+func bWcheck(intp *Interpreter) error {
+	return intp.checkAccess("wcheck", accessUnlimited)
+}
+
+// checkAccess implements the `rcheck` and `wcheck` operators.  The result is
+// true, if the access attribute of the operand is at most maxLevel.
+func (intp *Interpreter) checkAccess(op string, maxLevel accessLevel) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	obj := intp.Stack[len(intp.Stack)-1]
+	switch obj.(type) {
+	case Array, Procedure, Dict, String, nil, *file:
+		// pass
+	default:
+		return intp.e(eTypecheck, "%s: invalid argument type %T", op, obj)
+	}
+	intp.Stack[len(intp.Stack)-1] = Boolean(intp.accessOf(obj) <= maxLevel)
+	return nil
+}
+
+func bXcheck(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "xcheck: not enough arguments")
+	}
+	var isExec bool
+	switch intp.Stack[len(intp.Stack)-1].(type) {
+	case Procedure, Operator, builtin, *GoOperator:
+		isExec = true
+	}
+	intp.Stack[len(intp.Stack)-1] = Boolean(isExec)
+	return nil
+}
+
+func bCvlit(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "cvlit: not enough arguments")
+	}
+	switch obj := intp.Stack[len(intp.Stack)-1].(type) {
+	case Procedure:
+		// The array shares the storage, and thus the access attribute, of
+		// the procedure.
+		intp.Stack[len(intp.Stack)-1] = Array(obj)
+	case Operator:
+		intp.Stack[len(intp.Stack)-1] = Name(obj)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAccessChecks(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected []Object
+	}{
+		{"[1 2] rcheck", []Object{Boolean(true)}},
+		{"[1 2] wcheck", []Object{Boolean(true)}},
+		{"[1 2] readonly dup rcheck exch wcheck", []Object{Boolean(true), Boolean(false)}},
+		{"(ab) executeonly dup rcheck exch wcheck", []Object{Boolean(false), Boolean(false)}},
+		{"1 dict noaccess rcheck", []Object{Boolean(false)}},
+		{"systemdict wcheck", []Object{Boolean(false)}},
+		{"userdict wcheck", []Object{Boolean(true)}},
+		{"[1 2 3] readonly 1 2 getinterval wcheck", []Object{Boolean(false)}},
+		{"[1 2 3] readonly cvx wcheck", []Object{Boolean(false)}},
+		{"{1} xcheck [1] xcheck /a xcheck", []Object{Boolean(true), Boolean(false), Boolean(false)}},
+		{"{1} cvlit xcheck", []Object{Boolean(false)}},
+		{"{1} executeonly exec", []Object{Integer(1)}},
+		{"/x (abc) noaccess def userdict /x known", []Object{Boolean(true)}},
+
+		// Restricting the access to an interval does not affect the
+		// original object.
+		{"/a 5 array def a 0 2 getinterval readonly pop a 0 5 put a 0 get", []Object{Integer(5)}},
+		{"/s (hello) def s 0 2 getinterval readonly pop s 0 65 put s", []Object{String("Aello")}},
+		{"/a [1 2 3] def a 0 2 getinterval readonly wcheck a wcheck", []Object{Boolean(false), Boolean(true)}},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if d := cmp.Diff(intp.Stack, test.expected); d != "" {
+			t.Errorf("%q: %s", test.code, d)
+		}
+	}
+}
+
+func TestInvalidAccess(t *testing.T) {
+	for _, code := range []string{
+		"systemdict /x 1 put",
+		"systemdict begin /x 1 def",
+		"StandardEncoding 0 /a put",
+		"[1 2] readonly 0 3 put",
+		"(ab) readonly 0 (x) putinterval",
+		"[1 2] executeonly 0 get",
+		"(ab) noaccess { } forall",
+		"1 dict noaccess /a known",
+		"1 dict noaccess length",
+		"1 dict noaccess begin",
+		"1 dict readonly 1 dict copy 1 dict readonly copy",
+		"[1] noaccess [2] copy",
+		"[1 2] readonly readonly noaccess readonly",
+		"{1} noaccess exec",
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eInvalidaccess {
+			t.Errorf("%q: expected invalidaccess, got %v", code, err)
+		}
+	}
+}
+
+func TestAccessTypecheck(t *testing.T) {
+	for _, code := range []string{
+		"1 readonly",
+		"1 dict executeonly",
+		"/a rcheck",
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eTypecheck {
+			t.Errorf("%q: expected typecheck, got %v", code, err)
+		}
+	}
+}
+
+func TestBindReadonly(t *testing.T) {
+	// bind must not modify read-only procedures
+	intp, err := run("{add} readonly bind 0 get", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Operator("add") {
+		t.Errorf("read-only procedure was modified: %v", intp.Stack[0])
+	}
+}
+
+// TestAccessMapPrune checks that the access attributes of objects which
+// have been garbage collected are eventually removed.
+func TestAccessMapPrune(t *testing.T) {
+	intp := NewInterpreter()
+	for range 10 {
+		err := intp.ExecuteString("1 1 1000 { pop [1] readonly pop } for")
+		if err != nil {
+			t.Fatal(err)
+		}
+		runtime.GC()
+	}
+	if n := len(intp.access); n > 2000 {
+		t.Errorf("access map has %d entries", n)
+	}
+}
@@ -0,0 +1,483 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// This file implements the binary encoding of the PostScript language,
+// as described in section 3.14 of the PostScript Language Reference Manual.
+
+// isBinaryToken reports whether b starts a binary token.  Outside of
+// strings, these bytes also terminate names and numbers.
+func isBinaryToken(b byte) bool {
+	return b >= 128 && b <= 159
+}
+
+// binarySequence is the top-level array of a binary object sequence.  In
+// contrast to procedures, which are pushed onto the operand stack, binary
+// object sequences read by the interpreter are executed immediately.
+type binarySequence Procedure
+
+// readBinaryToken reads a binary token, starting with the token type byte.
+func (s *scanner) readBinaryToken() (Object, error) {
+	tp, err := s.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tp {
+	case 128, 129, 130, 131: // binary object sequence
+		return s.readBinarySequence(tp)
+	case 132, 133: // 32-bit integer
+		buf, err := s.readBinaryBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return Integer(int32(byteOrder(tp == 133).Uint32(buf))), nil
+	case 134, 135: // 16-bit integer
+		buf, err := s.readBinaryBytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return Integer(int16(byteOrder(tp == 135).Uint16(buf))), nil
+	case 136: // 8-bit integer
+		buf, err := s.readBinaryBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return Integer(int8(buf[0])), nil
+	case 137: // fixed point number
+		buf, err := s.readBinaryBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		r := numberRepresentation(buf[0])
+		if !r.isValid() || r.isReal() {
+			return nil, &postScriptError{eSyntaxerror, fmt.Sprintf("invalid fixed point representation %d", r)}
+		}
+		buf, err = s.readBinaryBytes(r.size())
+		if err != nil {
+			return nil, err
+		}
+		return r.decode(buf), nil
+	case 138, 139, 140: // real
+		buf, err := s.readBinaryBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		var r numberRepresentation
+		switch tp {
+		case 138: // IEEE, high-order byte first
+			r = 48
+		case 139: // IEEE, low-order byte first
+			r = 128 + 48
+		default: // native
+			r = 49
+		}
+		return r.decode(buf), nil
+	case 141: // boolean
+		buf, err := s.readBinaryBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return Boolean(buf[0] != 0), nil
+	case 142, 143, 144: // string
+		var n int
+		if tp == 142 {
+			buf, err := s.readBinaryBytes(1)
+			if err != nil {
+				return nil, err
+			}
+			n = int(buf[0])
+		} else {
+			buf, err := s.readBinaryBytes(2)
+			if err != nil {
+				return nil, err
+			}
+			n = int(byteOrder(tp == 144).Uint16(buf))
+		}
+		if n > s.maxStringBytes {
+			return nil, &postScriptError{eLimitcheck, "string too long"}
+		}
+		buf, err := s.readBinaryBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return String(buf), nil
+	case 145, 146: // name from the system name table
+		buf, err := s.readBinaryBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		name, err := systemName(int(buf[0]))
+		if err != nil {
+			return nil, err
+		}
+		if tp == 146 {
+			return Operator(name), nil
+		}
+		return name, nil
+	case 147, 148: // name from the user name table
+		if _, err := s.readBinaryBytes(1); err != nil {
+			return nil, err
+		}
+		return nil, &postScriptError{eUndefined, "user name table is not supported"}
+	case 149: // homogeneous number array
+		return s.readNumberArray()
+	default:
+		return nil, &postScriptError{eSyntaxerror, fmt.Sprintf("unassigned binary token type %d", tp)}
+	}
+}
+
+// readNumberArray reads the body of a homogeneous number array token.
+func (s *scanner) readNumberArray() (Object, error) {
+	buf, err := s.readBinaryBytes(3)
+	if err != nil {
+		return nil, err
+	}
+	r := numberRepresentation(buf[0])
+	if !r.isValid() {
+		return nil, &postScriptError{eSyntaxerror, fmt.Sprintf("invalid number representation %d", r)}
+	}
+	n := int(r.order().Uint16(buf[1:]))
+	if n*r.size() > s.maxStringBytes {
+		return nil, &postScriptError{eLimitcheck, "number array too long"}
+	}
+	data, err := s.readBinaryBytes(n * r.size())
+	if err != nil {
+		return nil, err
+	}
+	res := make(Array, n)
+	for i := range res {
+		res[i] = r.decode(data[i*r.size():])
+	}
+	return res, nil
+}
+
+// readBinarySequence reads a binary object sequence.  The token type byte
+// has already been consumed.
+func (s *scanner) readBinarySequence(tp byte) (Object, error) {
+	order := byteOrder(tp == 129 || tp == 131)
+
+	header, err := s.readBinaryBytes(3)
+	if err != nil {
+		return nil, err
+	}
+	headerLen := 4
+	numTop := int(header[0])
+	totalLen := int(order.Uint16(header[1:]))
+	if numTop == 0 {
+		// extended header
+		ext, err := s.readBinaryBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		headerLen = 8
+		numTop = int(order.Uint16(header[1:]))
+		totalLen = int(order.Uint32(ext))
+	}
+	if totalLen < headerLen+8*numTop {
+		return nil, &postScriptError{eSyntaxerror, "binary object sequence too short"}
+	}
+	if totalLen-headerLen > s.maxStringBytes {
+		return nil, &postScriptError{eLimitcheck, "binary object sequence too long"}
+	}
+	data, err := s.readBinaryBytes(totalLen - headerLen)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := &sequenceDecoder{
+		data:      data,
+		order:     order,
+		remaining: len(data) / 8,
+	}
+	top, err := dec.decodeArray(0, numTop, 0)
+	if err != nil {
+		return nil, err
+	}
+	return binarySequence(top), nil
+}
+
+// sequenceDecoder decodes the objects in a binary object sequence.
+type sequenceDecoder struct {
+	data  []byte
+	order binary.ByteOrder
+
+	// remaining is the number of objects which may still be decoded.
+	// Since every object occupies 8 bytes, a well-formed sequence can
+	// never contain more objects than this.  The limit guards against
+	// arrays which refer to themselves.
+	remaining int
+}
+
+// maxSequenceDepth is the maximal nesting depth of arrays inside a binary
+// object sequence.
+const maxSequenceDepth = 100
+
+// decodeArray decodes n consecutive objects, starting at the given offset.
+func (dec *sequenceDecoder) decodeArray(offset, n, depth int) ([]Object, error) {
+	if depth > maxSequenceDepth {
+		return nil, &postScriptError{eLimitcheck, "binary object sequence nested too deeply"}
+	}
+	if offset < 0 || offset+8*n > len(dec.data) {
+		return nil, &postScriptError{eSyntaxerror, "invalid array in binary object sequence"}
+	}
+	dec.remaining -= n
+	if dec.remaining < 0 {
+		return nil, &postScriptError{eSyntaxerror, "too many objects in binary object sequence"}
+	}
+	res := make([]Object, n)
+	for i := range res {
+		obj, err := dec.decodeObject(dec.data[offset+8*i:offset+8*i+8], depth)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = obj
+	}
+	return res, nil
+}
+
+// decodeObject decodes the 8-byte object representation in buf.
+func (dec *sequenceDecoder) decodeObject(buf []byte, depth int) (Object, error) {
+	// Native reals, for sequence types 130 and 131, are assumed to use
+	// the IEEE format.
+	tp := buf[0] & 0x7F
+	executable := buf[0]&0x80 != 0
+	length := int(dec.order.Uint16(buf[2:]))
+	value := dec.order.Uint32(buf[4:])
+
+	switch tp {
+	case 0: // null
+		return nil, nil
+	case 1: // integer
+		return Integer(int32(value)), nil
+	case 2: // real
+		if length == 0 {
+			return Real(math.Float32frombits(value)), nil
+		}
+		r := numberRepresentation(length)
+		if length >= 32 {
+			return nil, &postScriptError{eSyntaxerror, fmt.Sprintf("invalid fixed point scale %d", length)}
+		}
+		if dec.order == binary.LittleEndian {
+			r |= 128
+		}
+		return r.decode(buf[4:]), nil
+	case 3: // name
+		name, err := dec.decodeName(length, value)
+		if err != nil {
+			return nil, err
+		}
+		if executable {
+			return Operator(name), nil
+		}
+		return name, nil
+	case 4: // boolean
+		return Boolean(value != 0), nil
+	case 5: // string
+		text, err := dec.text(length, value)
+		if err != nil {
+			return nil, err
+		}
+		return String(text), nil
+	case 6: // immediately evaluated name
+		name, err := dec.decodeName(length, value)
+		if err != nil {
+			return nil, err
+		}
+		return immediateName(name), nil
+	case 9: // array
+		a, err := dec.decodeArray(int(value), length, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if executable {
+			return Procedure(a), nil
+		}
+		return Array(a), nil
+	case 10: // mark
+		return theMark, nil
+	default:
+		return nil, &postScriptError{eSyntaxerror, fmt.Sprintf("invalid object type %d in binary object sequence", tp)}
+	}
+}
+
+// decodeName decodes the length and value fields of a name object.
+func (dec *sequenceDecoder) decodeName(length int, value uint32) (Name, error) {
+	switch length {
+	case 0:
+		if value > math.MaxInt32 {
+			return "", &postScriptError{eUndefined, "invalid system name index"}
+		}
+		return systemName(int(value))
+	case 0xFFFF:
+		return "", &postScriptError{eUndefined, "user name table is not supported"}
+	default:
+		text, err := dec.text(length, value)
+		if err != nil {
+			return "", err
+		}
+		return Name(text), nil
+	}
+}
+
+// text returns a copy of the string data at the given offset.
+func (dec *sequenceDecoder) text(length int, offset uint32) ([]byte, error) {
+	if uint64(offset)+uint64(length) > uint64(len(dec.data)) {
+		return nil, &postScriptError{eSyntaxerror, "invalid string in binary object sequence"}
+	}
+	return append([]byte{}, dec.data[offset:int(offset)+length]...), nil
+}
+
+// readBinaryBytes reads the next n bytes of a binary token.
+func (s *scanner) readBinaryBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(s, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, &postScriptError{eSyntaxerror, "unexpected end of binary token"}
+	} else if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// byteOrder returns the byte order selected by a binary token type.
+func byteOrder(lowFirst bool) binary.ByteOrder {
+	if lowFirst {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// numberRepresentation describes the encoding of numbers in binary tokens
+// and in homogeneous number arrays, see section 3.14.5 of the PostScript
+// Language Reference Manual.
+type numberRepresentation int
+
+func (r numberRepresentation) isValid() bool {
+	return r&0x7F <= 49 && r < 256
+}
+
+// isReal reports whether r describes a floating point number, rather than
+// a fixed point number.
+func (r numberRepresentation) isReal() bool {
+	return r&0x7F >= 48
+}
+
+// size returns the number of bytes used for one number.
+func (r numberRepresentation) size() int {
+	if r&0x7F >= 32 && r&0x7F < 48 {
+		return 2
+	}
+	return 4
+}
+
+func (r numberRepresentation) order() binary.ByteOrder {
+	return byteOrder(r >= 128)
+}
+
+// decode decodes a number from the start of buf.  The caller must ensure
+// that r is valid and that buf is long enough.
+func (r numberRepresentation) decode(buf []byte) Object {
+	rr := r & 0x7F
+	order := r.order()
+	var val int64
+	var scale int
+	switch {
+	case rr < 32:
+		val = int64(int32(order.Uint32(buf)))
+		scale = int(rr)
+	case rr < 48:
+		val = int64(int16(order.Uint16(buf)))
+		scale = int(rr - 32)
+	case rr == 48:
+		return Real(math.Float32frombits(order.Uint32(buf)))
+	default: // native real
+		return Real(math.Float32frombits(binary.NativeEndian.Uint32(buf)))
+	}
+	if scale == 0 {
+		return Integer(val)
+	}
+	return Real(math.Ldexp(float64(val), -scale))
+}
+
+// systemName returns the name with the given index in the system name
+// table.
+func systemName(idx int) (Name, error) {
+	if idx < 0 || idx >= len(systemNames) {
+		return "", &postScriptError{eUndefined, fmt.Sprintf("system name index %d", idx)}
+	}
+	return systemNames[idx], nil
+}
+
+// systemNames is the system name table from appendix F of the PostScript
+// Language Reference Manual.
+var systemNames = [...]Name{
+	"abs", "add", "aload", "anchorsearch", "and",
+	"arc", "arcn", "arct", "arcto", "array",
+	"ashow", "astore", "awidthshow", "begin", "bind",
+	"bitshift", "ceiling", "charpath", "clear", "cleartomark",
+	"clip", "clippath", "closepath", "concat", "concatmatrix",
+	"copy", "copypage", "cos", "count", "counttomark",
+	"currentcmykcolor", "currentdash", "currentdict", "currentfile", "currentfont",
+	"currentgray", "currentgstate", "currenthsbcolor", "currentlinecap", "currentlinejoin",
+	"currentlinewidth", "currentmatrix", "currentpoint", "currentrgbcolor", "currentshared",
+	"curveto", "cvi", "cvlit", "cvn", "cvr",
+	"cvrs", "cvs", "cvx", "def", "defineusername",
+	"dict", "div", "dtransform", "dup", "end",
+	"eoclip", "eofill", "eoviewclip", "eq", "exch",
+	"exec", "exit", "file", "fill", "findfont",
+	"flattenpath", "floor", "flush", "flushfile", "for",
+	"forall", "ge", "get", "getinterval", "grestore",
+	"gsave", "gstate", "gt", "identmatrix", "idiv",
+	"idtransform", "if", "ifelse", "image", "imagemask",
+	"index", "ineofill", "infill", "initviewclip", "inueofill",
+	"inufill", "invertmatrix", "itransform", "known", "le",
+	"length", "lineto", "load", "loop", "lt",
+	"makefont", "matrix", "maxlength", "mod", "moveto",
+	"mul", "ne", "neg", "newpath", "not",
+	"null", "or", "pathbbox", "pathforall", "pop",
+	"print", "printobject", "put", "putinterval", "rcurveto",
+	"read", "readhexstring", "readline", "readstring", "rectclip",
+	"rectfill", "rectstroke", "rectviewclip", "repeat", "restore",
+	"rlineto", "rmoveto", "roll", "rotate", "round",
+	"save", "scale", "scalefont", "search", "selectfont",
+	"setbbox", "setcachedevice", "setcachedevice2", "setcharwidth", "setcmykcolor",
+	"setdash", "setfont", "setgray", "setgstate", "sethsbcolor",
+	"setlinecap", "setlinejoin", "setlinewidth", "setmatrix", "setrgbcolor",
+	"setshared", "shareddict", "show", "showpage", "stop",
+	"stopped", "store", "string", "stringwidth", "stroke",
+	"strokepath", "sub", "systemdict", "token", "transform",
+	"translate", "truncate", "type", "uappend", "ucache",
+	"ueofill", "ufill", "undef", "upath", "userdict",
+	"ustroke", "viewclip", "viewclippath", "where", "widthshow",
+	"write", "writehexstring", "writeobject", "writestring", "wtranslation",
+	"xor", "xshow", "xyshow", "yshow", "FontDirectory",
+	"SharedFontDirectory", "Courier", "Courier-Bold", "Courier-BoldOblique", "Courier-Oblique",
+	"Helvetica", "Helvetica-Bold", "Helvetica-BoldOblique", "Helvetica-Oblique", "Symbol",
+	"Times-Bold", "Times-BoldItalic", "Times-Italic", "Times-Roman", "execuserobject",
+	"currentcolor", "currentcolorspace", "currentglobal", "execform", "filter",
+	"findresource", "globaldict", "makepattern", "setcolor", "setcolorspace",
+	"setglobal", "setpagedevice", "setpattern",
+}
@@ -21,7 +21,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"maps"
 	"math"
 	"strconv"
 
@@ -32,6 +31,11 @@ func makeSystemDict() Dict {
 	FontDirectory := Dict{}
 	userDict := Dict{}
 	errorDict := Dict{}
+	errorState := Dict{
+		"newerror":     Boolean(false),
+		"recordstacks": Boolean(true),
+		"binary":       Boolean(false),
+	}
 
 	standardEncoding := make(Array, 256)
 	for i, name := range psenc.StandardEncoding {
@@ -39,98 +43,206 @@ func makeSystemDict() Dict {
 	}
 
 	systemDict := Dict{
-		"[":                builtin(bListStart),
-		"]":                builtin(bListEnd),
-		"<<":               builtin(bDictStart),
-		">>":               builtin(bDictEnd),
-		"abs":              builtin(bAbs),
-		"add":              builtin(bAdd),
-		"and":              builtin(bAnd),
-		"atan":             builtin(bAtan),
-		"array":            builtin(bArray),
-		"begin":            builtin(bBegin),
-		"bind":             builtin(bBind),
-		"bitshift":         builtin(bBitshift),
-		"ceiling":          builtin(bCeiling),
-		"cleartomark":      builtin(bCleartomark),
-		"closefile":        builtin(bClosefile),
-		"cos":              builtin(bCos),
-		"copy":             builtin(bCopy),
-		"count":            builtin(bCount),
-		"currentdict":      builtin(bCurrentdict),
-		"currentfile":      builtin(bCurrentfile),
-		"cvi":              builtin(bCvi),
-		"cvr":              builtin(bCvr),
-		"cvx":              builtin(bCvx),
-		"def":              builtin(bDef),
-		"definefont":       builtin(bDefinefont),
-		"defineresource":   builtin(bDefineresource),
-		"dict":             builtin(bDict),
-		"div":              builtin(bDiv),
-		"dup":              builtin(bDup),
-		"exec":             builtin(bExec),
-		"eexec":            builtin(eexec),
-		"end":              builtin(bEnd),
-		"eq":               builtin(bEq),
-		"errordict":        errorDict,
-		"exch":             builtin(bExch),
-		"executeonly":      builtin(bExecuteonly),
-		"exp":              builtin(bExp),
-		"exit":             builtin(bExit),
-		"false":            Boolean(false),
-		"findfont":         builtin(bFindfont),
-		"findresource":     builtin(bFindresource),
-		"floor":            builtin(bFloor),
-		"FontDirectory":    FontDirectory,
-		"for":              builtin(bFor),
-		"forall":           builtin(bForall),
-		"ge":               builtin(bGe),
-		"get":              builtin(bGet),
-		"getinterval":      builtin(bGetinterval),
-		"gt":               builtin(bGt),
-		"idiv":             builtin(bIdiv),
-		"if":               builtin(bIf),
-		"ifelse":           builtin(bIfelse),
-		"index":            builtin(bIndex),
-		"internaldict":     builtin(bInternaldict),
-		"known":            builtin(bKnown),
-		"le":               builtin(bLe),
-		"length":           builtin(bLength),
-		"ln":               builtin(bLn),
-		"load":             builtin(bLoad),
-		"log":              builtin(bLog),
-		"loop":             builtin(bLoop),
-		"lt":               builtin(bLt),
-		"mark":             builtin(bMark),
-		"matrix":           builtin(bMatrix),
-		"maxlength":        builtin(bMaxlength),
-		"mod":              builtin(bMod),
-		"mul":              builtin(bMul),
-		"neg":              builtin(bNeg),
-		"ne":               builtin(bNe),
-		"noaccess":         builtin(bNoaccess),
-		"not":              builtin(bNot),
-		"or":               builtin(bOr),
-		"pop":              builtin(bPop),
-		"put":              builtin(bPut),
-		"putinterval":      builtin(bPutinterval),
-		"readonly":         builtin(bReadonly),
-		"readstring":       builtin(bReadstring),
-		"repeat":           builtin(bRepeat),
-		"roll":             builtin(bRoll),
-		"round":            builtin(bRound),
-		"sin":              builtin(bSin),
-		"sqrt":             builtin(bSqrt),
-		"StandardEncoding": standardEncoding,
-		"stop":             builtin(bStop),
-		"string":           builtin(bString),
-		"sub":              builtin(bSub),
-		"true":             Boolean(true),
-		"truncate":         builtin(bTruncate),
-		"type":             builtin(bType),
-		"userdict":         userDict,
-		"where":            builtin(bWhere),
-		"xor":              builtin(bXor),
+		"[":                 builtin(bListStart),
+		"]":                 builtin(bListEnd),
+		"<<":                builtin(bDictStart),
+		">>":                builtin(bDictEnd),
+		"$error":            errorState,
+		"=":                 builtin(bEqual),
+		"==":                builtin(bEqualEqual),
+		"abs":               builtin(bAbs),
+		"add":               builtin(bAdd),
+		"anchorsearch":      builtin(bAnchorsearch),
+		"and":               builtin(bAnd),
+		"arc":               builtin(bArc),
+		"arcn":              builtin(bArcn),
+		"ashow":             builtin(bAshow),
+		"atan":              builtin(bAtan),
+		"array":             builtin(bArray),
+		"awidthshow":        builtin(bAwidthshow),
+		"begin":             builtin(bBegin),
+		"bind":              builtin(bBind),
+		"bitshift":          builtin(bBitshift),
+		"ceiling":           builtin(bCeiling),
+		"charpath":          builtin(bCharpath),
+		"cleartomark":       builtin(bCleartomark),
+		"clip":              builtin(bClip),
+		"closefile":         builtin(bClosefile),
+		"closepath":         builtin(bClosepath),
+		"concat":            builtin(bConcat),
+		"concatmatrix":      builtin(bConcatmatrix),
+		"cos":               builtin(bCos),
+		"copy":              builtin(bCopy),
+		"count":             builtin(bCount),
+		"countexecstack":    builtin(bCountexecstack),
+		"cshow":             builtin(bCshow),
+		"currentcmykcolor":  builtin(bCurrentcmykcolor),
+		"currentdash":       builtin(bCurrentdash),
+		"currentdict":       builtin(bCurrentdict),
+		"currentfile":       builtin(bCurrentfile),
+		"currentflat":       builtin(bCurrentflat),
+		"currentfont":       builtin(bCurrentfont),
+		"currentgray":       builtin(bCurrentgray),
+		"currenthsbcolor":   builtin(bCurrenthsbcolor),
+		"currentlinecap":    builtin(bCurrentlinecap),
+		"currentlinejoin":   builtin(bCurrentlinejoin),
+		"currentlinewidth":  builtin(bCurrentlinewidth),
+		"currentmatrix":     builtin(bCurrentmatrix),
+		"currentmiterlimit": builtin(bCurrentmiterlimit),
+		"currentpoint":      builtin(bCurrentpoint),
+		"currentrgbcolor":   builtin(bCurrentrgbcolor),
+		"curveto":           builtin(bCurveto),
+		"cvi":               builtin(bCvi),
+		"cvlit":             builtin(bCvlit),
+		"cvn":               builtin(bCvn),
+		"cvr":               builtin(bCvr),
+		"cvrs":              builtin(bCvrs),
+		"cvs":               builtin(bCvs),
+		"cvx":               builtin(bCvx),
+		"def":               builtin(bDef),
+		"defaultmatrix":     builtin(bDefaultmatrix),
+		"definefont":        builtin(bDefinefont),
+		"defineresource":    builtin(bDefineresource),
+		"dict":              builtin(bDict),
+		"div":               builtin(bDiv),
+		"dtransform":        builtin(bDtransform),
+		"dup":               builtin(bDup),
+		"eoclip":            builtin(bEoclip),
+		"eofill":            builtin(bEofill),
+		"exec":              builtin(bExec),
+		"eexec":             builtin(eexec),
+		"end":               builtin(bEnd),
+		"eq":                builtin(bEq),
+		"errordict":         errorDict,
+		"exch":              builtin(bExch),
+		"execstack":         builtin(bExecstack),
+		"executeonly":       builtin(bExecuteonly),
+		"exp":               builtin(bExp),
+		"exit":              builtin(bExit),
+		"false":             Boolean(false),
+		"fill":              builtin(bFill),
+		"filter":            builtin(bFilter),
+		"findfont":          builtin(bFindfont),
+		"findresource":      builtin(bFindresource),
+		"floor":             builtin(bFloor),
+		"flush":             builtin(bFlush),
+		"FontDirectory":     FontDirectory,
+		"for":               builtin(bFor),
+		"forall":            builtin(bForall),
+		"ge":                builtin(bGe),
+		"get":               builtin(bGet),
+		"getinterval":       builtin(bGetinterval),
+		"grestore":          builtin(bGrestore),
+		"grestoreall":       builtin(bGrestoreall),
+		"gsave":             builtin(bGsave),
+		"gt":                builtin(bGt),
+		"handleerror":       builtin(bHandleerror),
+		"identmatrix":       builtin(bIdentmatrix),
+		"idiv":              builtin(bIdiv),
+		"idtransform":       builtin(bIdtransform),
+		"if":                builtin(bIf),
+		"ifelse":            builtin(bIfelse),
+		"index":             builtin(bIndex),
+		"initclip":          builtin(bInitclip),
+		"initgraphics":      builtin(bInitgraphics),
+		"initmatrix":        builtin(bInitmatrix),
+		"internaldict":      builtin(bInternaldict),
+		"invertmatrix":      builtin(bInvertmatrix),
+		"itransform":        builtin(bItransform),
+		"known":             builtin(bKnown),
+		"kshow":             builtin(bKshow),
+		"le":                builtin(bLe),
+		"length":            builtin(bLength),
+		"lineto":            builtin(bLineto),
+		"ln":                builtin(bLn),
+		"load":              builtin(bLoad),
+		"log":               builtin(bLog),
+		"loop":              builtin(bLoop),
+		"lt":                builtin(bLt),
+		"makefont":          builtin(bMakefont),
+		"mark":              builtin(bMark),
+		"matrix":            builtin(bMatrix),
+		"maxlength":         builtin(bMaxlength),
+		"mod":               builtin(bMod),
+		"moveto":            builtin(bMoveto),
+		"mul":               builtin(bMul),
+		"neg":               builtin(bNeg),
+		"ne":                builtin(bNe),
+		"newpath":           builtin(bNewpath),
+		"noaccess":          builtin(bNoaccess),
+		"not":               builtin(bNot),
+		"or":                builtin(bOr),
+		"pathbbox":          builtin(bPathbbox),
+		"pop":               builtin(bPop),
+		"print":             builtin(bPrint),
+		"pstack":            builtin(bPstack),
+		"put":               builtin(bPut),
+		"putinterval":       builtin(bPutinterval),
+		"rcheck":            builtin(bRcheck),
+		"rcurveto":          builtin(bRcurveto),
+		"read":              builtin(bRead),
+		"readonly":          builtin(bReadonly),
+		"readstring":        builtin(bReadstring),
+		"rectfill":          builtin(bRectfill),
+		"rectstroke":        builtin(bRectstroke),
+		"repeat":            builtin(bRepeat),
+		"resourceforall":    builtin(bResourceforall),
+		"resourcestatus":    builtin(bResourcestatus),
+		"restore":           builtin(bRestore),
+		"rlineto":           builtin(bRlineto),
+		"rmoveto":           builtin(bRmoveto),
+		"roll":              builtin(bRoll),
+		"rootfont":          builtin(bRootfont),
+		"rotate":            builtin(bRotate),
+		"round":             builtin(bRound),
+		"save":              builtin(bSave),
+		"scale":             builtin(bScale),
+		"scalefont":         builtin(bScalefont),
+		"search":            builtin(bSearch),
+		"selectfont":        builtin(bSelectfont),
+		"setcachedevice":    builtin(bSetcachedevice),
+		"setcachedevice2":   builtin(bSetcachedevice2),
+		"setcharwidth":      builtin(bSetcharwidth),
+		"setcmykcolor":      builtin(bSetcmykcolor),
+		"setdash":           builtin(bSetdash),
+		"setflat":           builtin(bSetflat),
+		"setfont":           builtin(bSetfont),
+		"setgray":           builtin(bSetgray),
+		"sethsbcolor":       builtin(bSethsbcolor),
+		"setlinecap":        builtin(bSetlinecap),
+		"setlinejoin":       builtin(bSetlinejoin),
+		"setlinewidth":      builtin(bSetlinewidth),
+		"setmatrix":         builtin(bSetmatrix),
+		"setmiterlimit":     builtin(bSetmiterlimit),
+		"setrgbcolor":       builtin(bSetrgbcolor),
+		"show":              builtin(bShow),
+		"showpage":          builtin(bShowpage),
+		"sin":               builtin(bSin),
+		"sqrt":              builtin(bSqrt),
+		"stack":             builtin(bStack),
+		"StandardEncoding":  standardEncoding,
+		"stop":              builtin(bStop),
+		"stopped":           builtin(bStopped),
+		"string":            builtin(bString),
+		"stringwidth":       builtin(bStringwidth),
+		"stroke":            builtin(bStroke),
+		"sub":               builtin(bSub),
+		"token":             builtin(bToken),
+		"transform":         builtin(bTransform),
+		"translate":         builtin(bTranslate),
+		"true":              Boolean(true),
+		"truncate":          builtin(bTruncate),
+		"type":              builtin(bType),
+		"undefineresource":  builtin(bUndefineresource),
+		"userdict":          userDict,
+		"wcheck":            builtin(bWcheck),
+		"where":             builtin(bWhere),
+		"widthshow":         builtin(bWidthshow),
+		"xcheck":            builtin(bXcheck),
+		"xor":               builtin(bXor),
+		"xshow":             builtin(bXshow),
+		"xyshow":            builtin(bXyshow),
+		"yshow":             builtin(bYshow),
 	}
 	systemDict["systemdict"] = systemDict
 
@@ -310,6 +422,9 @@ func bBegin(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "begin: needs a dictionary")
 	}
+	if err := intp.checkRead("begin", d); err != nil {
+		return err
+	}
 	intp.Stack = intp.Stack[:len(intp.Stack)-1]
 	intp.DictStack = append(intp.DictStack, d)
 	return nil
@@ -341,11 +456,20 @@ func bClosefile(intp *Interpreter) error {
 	if len(intp.Stack) < 1 {
 		return intp.e(eStackunderflow, "closefile: not enough arguments")
 	}
-	if x := intp.Stack[len(intp.Stack)-1]; x != nil {
-		return intp.e(eTypecheck, "closefile: needs a file, not %T", x)
+	s, err := intp.fileScanner("closefile", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
 	}
+	f, isFilter := intp.Stack[len(intp.Stack)-1].(*file)
 	intp.Stack = intp.Stack[:len(intp.Stack)-1]
-	return io.EOF
+	if isFilter {
+		f.close()
+	}
+	if len(intp.scanners) > 0 && s == intp.scanners[len(intp.scanners)-1] {
+		// stop executing the current file
+		return io.EOF
+	}
+	return nil
 }
 
 func bCopy(intp *Interpreter) error {
@@ -368,6 +492,12 @@ func bCopy(intp *Interpreter) error {
 	}
 	a := intp.Stack[len(intp.Stack)-2]
 	b := intp.Stack[len(intp.Stack)-1]
+	if err := intp.checkRead("copy", a); err != nil {
+		return err
+	}
+	if err := intp.checkWrite("copy", b); err != nil {
+		return err
+	}
 	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	var res Object
 	switch a := a.(type) {
@@ -378,6 +508,7 @@ func bCopy(intp *Interpreter) error {
 		} else if len(b) < len(a) {
 			return intp.e(eRangecheck, "copy: not enough space in destination")
 		}
+		intp.saveElems(b[:len(a)])
 		n := copy(b, a)
 		res = b[:n]
 	case Dict:
@@ -385,7 +516,10 @@ func bCopy(intp *Interpreter) error {
 		if !ok {
 			return intp.e(eTypecheck, "copy: mismatched argument types")
 		}
-		maps.Copy(b, a)
+		for key, val := range a {
+			intp.saveDictEntry(b, key)
+			b[key] = val
+		}
 		res = b
 	case String:
 		b, ok := b.(String)
@@ -430,6 +564,7 @@ func bCvx(intp *Interpreter) error {
 		}
 		b := make(Procedure, len(a))
 		copy(b, a)
+		intp.inheritAccess(b, a)
 		intp.Stack[len(intp.Stack)-1] = b
 	}
 	return nil
@@ -443,7 +578,12 @@ func bDef(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "def: needs name, not %T", intp.Stack[len(intp.Stack)-2])
 	}
-	intp.DictStack[len(intp.DictStack)-1][name] = intp.Stack[len(intp.Stack)-1]
+	d := intp.DictStack[len(intp.DictStack)-1]
+	if err := intp.checkWrite("def", d); err != nil {
+		return err
+	}
+	intp.saveDictEntry(d, name)
+	d[name] = intp.Stack[len(intp.Stack)-1]
 	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	return nil
 }
@@ -460,8 +600,16 @@ func bDefinefont(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "definefont: needs font, not %T", intp.Stack[len(intp.Stack)-1])
 	}
+	if _, ok := font["FID"].(*fontID); !ok {
+		intp.saveDictEntry(font, "FID")
+		font["FID"] = &fontID{}
+	}
+	intp.saveDictEntry(intp.FontDirectory, name)
 	intp.FontDirectory[name] = font
 	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], font)
+	if intp.DefineResource != nil {
+		intp.DefineResource("Font", name, font)
+	}
 	return nil
 }
 
@@ -493,8 +641,12 @@ func bDefineresource(intp *Interpreter) error {
 		}
 	}
 
+	intp.saveDictEntry(classDict, key)
 	classDict[key] = instance
 	intp.Stack = append(intp.Stack[:len(intp.Stack)-3], instance)
+	if intp.DefineResource != nil {
+		intp.DefineResource(class, key, instance)
+	}
 	return nil
 }
 
@@ -534,10 +686,13 @@ func bExec(intp *Interpreter) error {
 	intp.Stack = intp.Stack[:len(intp.Stack)-1]
 
 	switch obj := obj.(type) {
-	case builtin:
-		return obj(intp)
-	case Procedure:
-		return intp.executeOne(obj, true)
+	case builtin, *GoOperator, Procedure:
+		return intp.execObject(obj, true)
+	case nil:
+		// The current file is already being executed.
+		return nil
+	case *file:
+		return intp.pushFile(obj.s, obj)
 	default:
 		return intp.e(eTypecheck, "exec: not implemented for %T", obj)
 	}
@@ -576,11 +731,6 @@ func bExch(intp *Interpreter) error {
 	return nil
 }
 
-func bExecuteonly(intp *Interpreter) error {
-	// not implemented
-	return nil
-}
-
 func bExit(intp *Interpreter) error {
 	return errExit
 }
@@ -593,48 +743,14 @@ func bFindfont(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "findfont: needs a name, not %T", intp.Stack[len(intp.Stack)-1])
 	}
-	font, ok := intp.FontDirectory[name]
-	if !ok {
-		return intp.e(eInvalidfont, "font %q not found", name)
+	font, err := intp.findFont("findfont", name)
+	if err != nil {
+		return err
 	}
 	intp.Stack = append(intp.Stack[:len(intp.Stack)-1], font)
 	return nil
 }
 
-func bFindresource(intp *Interpreter) error {
-	if len(intp.Stack) < 2 {
-		return intp.e(eStackunderflow, "findresource: not enough arguments")
-	}
-	catName, ok := intp.Stack[len(intp.Stack)-1].(Name)
-	if !ok {
-		return intp.e(eTypecheck, "findresource: needs a name, not %T", intp.Stack[len(intp.Stack)-1])
-	}
-	cat, ok := intp.Resources[catName]
-	if !ok {
-		return intp.e(eUndefined, "resource category %q not found", catName)
-	}
-	keyObj := intp.Stack[len(intp.Stack)-2]
-	var key Name
-	switch keyObj := keyObj.(type) {
-	case Name:
-		key = keyObj
-	case String:
-		key = Name(keyObj)
-	default:
-		return intp.e(eUndefinedresource, "findresource: needs a name or string, not %T", keyObj)
-	}
-	catDict, ok := cat.(Dict)
-	if !ok {
-		return intp.e(eUndefined, "resource category %q is not a dictionary", catName)
-	}
-	obj, ok := catDict[key]
-	if !ok {
-		return intp.e(eUndefinedresource, "resource %q not found in category %q", key, catName)
-	}
-	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], obj)
-	return nil
-}
-
 func bFor(intp *Interpreter) error {
 	if len(intp.Stack) < 4 {
 		return intp.e(eStackunderflow, "for: not enough arguments")
@@ -653,21 +769,11 @@ func bFor(intp *Interpreter) error {
 		return intp.e(eTypecheck, "for: invalid limit")
 	}
 	proc := intp.Stack[len(intp.Stack)-1]
-	intp.Stack = intp.Stack[:len(intp.Stack)-4]
-	val := initial
-	for {
-		if increment > 0 && val > limit || increment < 0 && val < limit {
-			break
-		}
-		intp.Stack = append(intp.Stack, val)
-		err := intp.executeOne(proc, true)
-		if err == errExit {
-			break
-		} else if err != nil {
-			return err
-		}
-		val += increment
+	err := intp.pushFrame(&forFrame{val: initial, inc: increment, limit: limit, proc: proc})
+	if err != nil {
+		return err
 	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-4]
 	return nil
 }
 
@@ -680,43 +786,20 @@ func bForall(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "forall: invalid argument")
 	}
-	switch obj := obj.(type) {
-	case Array:
-		intp.Stack = intp.Stack[:len(intp.Stack)-2]
-		for _, val := range obj {
-			intp.Stack = append(intp.Stack, val)
-			err := intp.executeOne(proc, true)
-			if err == errExit {
-				break
-			} else if err != nil {
-				return err
-			}
-		}
-	case String:
-		intp.Stack = intp.Stack[:len(intp.Stack)-2]
-		for _, c := range obj {
-			intp.Stack = append(intp.Stack, Integer(c))
-			err := intp.executeOne(proc, true)
-			if err == errExit {
-				break
-			} else if err != nil {
-				return err
-			}
-		}
-	case Dict:
-		intp.Stack = intp.Stack[:len(intp.Stack)-2]
-		for key, val := range obj {
-			intp.Stack = append(intp.Stack, key, val)
-			err := intp.executeOne(proc, true)
-			if err == errExit {
-				break
-			} else if err != nil {
-				return err
-			}
-		}
+	if err := intp.checkRead("forall", obj); err != nil {
+		return err
+	}
+	switch obj.(type) {
+	case Array, String, Dict:
+		// pass
 	default:
 		return intp.e(eTypecheck, "forall: invalid type %T", obj)
 	}
+	err := intp.pushFrame(newForallFrame(obj, proc))
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	return nil
 }
 
@@ -726,6 +809,9 @@ func bGet(intp *Interpreter) error {
 	}
 	obj := intp.Stack[len(intp.Stack)-2]
 	sel := intp.Stack[len(intp.Stack)-1]
+	if err := intp.checkRead("get", obj); err != nil {
+		return err
+	}
 	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	switch obj := obj.(type) {
 	case Array:
@@ -785,6 +871,9 @@ func bGetinterval(intp *Interpreter) error {
 	default:
 		return intp.e(eTypecheck, "getinterval: invalid argument type %T", obj)
 	}
+	if err := intp.checkRead("getinterval", obj); err != nil {
+		return err
+	}
 	index, ok := intp.Stack[len(intp.Stack)-2].(Integer)
 	if !ok {
 		return intp.e(eTypecheck, "getinterval: invalid index")
@@ -805,6 +894,7 @@ func bGetinterval(intp *Interpreter) error {
 	case String:
 		res = obj[index : index+count]
 	}
+	intp.inheritAccess(res, obj)
 	intp.Stack = append(intp.Stack, res)
 	return nil
 }
@@ -820,7 +910,7 @@ func bIf(intp *Interpreter) error {
 	proc := intp.Stack[len(intp.Stack)-1]
 	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	if cond {
-		return intp.executeOne(proc, true)
+		return intp.execObject(proc, true)
 	}
 	return nil
 }
@@ -837,9 +927,9 @@ func bIfelse(intp *Interpreter) error {
 	proc2 := intp.Stack[len(intp.Stack)-1]
 	intp.Stack = intp.Stack[:len(intp.Stack)-3]
 	if cond {
-		return intp.executeOne(proc1, true)
+		return intp.execObject(proc1, true)
 	} else {
-		return intp.executeOne(proc2, true)
+		return intp.execObject(proc2, true)
 	}
 }
 
@@ -886,6 +976,9 @@ func bKnown(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "known: invalid argument")
 	}
+	if err := intp.checkRead("known", d); err != nil {
+		return err
+	}
 	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	_, ok = d[name]
 	intp.Stack = append(intp.Stack, Boolean(ok))
@@ -897,6 +990,11 @@ func bLength(intp *Interpreter) error {
 		return intp.e(eStackunderflow, "length: not enough arguments")
 	}
 	obj := intp.Stack[len(intp.Stack)-1]
+	if _, isDict := obj.(Dict); isDict {
+		if err := intp.checkRead("length", obj); err != nil {
+			return err
+		}
+	}
 	intp.Stack = intp.Stack[:len(intp.Stack)-1]
 	var res int
 	switch obj := obj.(type) {
@@ -941,15 +1039,12 @@ func bLoop(intp *Interpreter) error {
 		return intp.e(eStackunderflow, "loop: not enough arguments")
 	}
 	proc := intp.Stack[len(intp.Stack)-1]
-	intp.Stack = intp.Stack[:len(intp.Stack)-1]
-	for {
-		err := intp.executeOne(proc, true)
-		if err == errExit {
-			return nil
-		} else if err != nil {
-			return err
-		}
+	err := intp.pushFrame(&loopFrame{proc: proc})
+	if err != nil {
+		return err
 	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	return nil
 }
 
 func bMark(intp *Interpreter) error {
@@ -971,6 +1066,9 @@ func bMaxlength(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "maxlength: invalid argument")
 	}
+	if err := intp.checkRead("maxlength", dict); err != nil {
+		return err
+	}
 	intp.Stack = append(intp.Stack[:len(intp.Stack)-1], Integer(len(dict)+1))
 	return nil
 }
@@ -1024,11 +1122,6 @@ func bNe(intp *Interpreter) error {
 	return nil
 }
 
-func bNoaccess(intp *Interpreter) error {
-	// not implemented
-	return nil
-}
-
 func bNot(intp *Interpreter) error {
 	if len(intp.Stack) < 1 {
 		return intp.e(eStackunderflow, "not: not enough arguments")
@@ -1086,6 +1179,9 @@ func bPut(intp *Interpreter) error {
 	obj := intp.Stack[len(intp.Stack)-3]
 	sel := intp.Stack[len(intp.Stack)-2]
 	value := intp.Stack[len(intp.Stack)-1]
+	if err := intp.checkWrite("put", obj); err != nil {
+		return err
+	}
 	intp.Stack = intp.Stack[:len(intp.Stack)-3]
 	switch obj := obj.(type) {
 	case Array:
@@ -1096,6 +1192,7 @@ func bPut(intp *Interpreter) error {
 		if index < 0 || index >= Integer(len(obj)) {
 			return intp.e(eRangecheck, "put: index %d out of range", index)
 		}
+		intp.saveElems(obj[index : index+1])
 		obj[index] = value
 	case Procedure:
 		index, ok := sel.(Integer)
@@ -1105,12 +1202,14 @@ func bPut(intp *Interpreter) error {
 		if index < 0 || index >= Integer(len(obj)) {
 			return intp.e(eRangecheck, "put: index %d out of range", index)
 		}
+		intp.saveElems(obj[index : index+1])
 		obj[index] = value
 	case Dict:
 		key, ok := sel.(Name)
 		if !ok {
 			return intp.e(eTypecheck, "put: invalid dict key")
 		}
+		intp.saveDictEntry(obj, key)
 		obj[key] = value
 	case String:
 		index, ok := sel.(Integer)
@@ -1143,6 +1242,12 @@ func bPutinterval(intp *Interpreter) error {
 		return intp.e(eRangecheck, "putinterval: index out of range")
 	}
 	src := intp.Stack[len(intp.Stack)-1]
+	if err := intp.checkWrite("putinterval", dst); err != nil {
+		return err
+	}
+	if err := intp.checkRead("putinterval", src); err != nil {
+		return err
+	}
 
 	switch dst := dst.(type) {
 	case Array:
@@ -1153,6 +1258,7 @@ func bPutinterval(intp *Interpreter) error {
 		if Integer(len(src)) > Integer(len(dst))-index {
 			return intp.e(eRangecheck, "putinterval: index out of range")
 		}
+		intp.saveElems(dst[index : int(index)+len(src)])
 		copy(dst[index:], src)
 	case String:
 		src, ok := src.(String)
@@ -1170,8 +1276,23 @@ func bPutinterval(intp *Interpreter) error {
 	return nil
 }
 
-func bReadonly(intp *Interpreter) error {
-	// not implemented
+func bRead(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "read: not enough arguments")
+	}
+	s, err := intp.fileScanner("read", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	b, err := s.ReadByte()
+	if err == io.EOF {
+		intp.Stack = append(intp.Stack, Boolean(false))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	intp.Stack = append(intp.Stack, Integer(b), Boolean(true))
 	return nil
 }
 
@@ -1183,12 +1304,14 @@ func bReadstring(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "readstring: invalid argument")
 	}
-	intp.Stack = intp.Stack[:len(intp.Stack)-2]
-	s := intp.scanners[len(intp.scanners)-1]
-	_, err := s.ReadByte()
-	if err != nil && err != io.EOF {
+	if err := intp.checkWrite("readstring", buf); err != nil {
+		return err
+	}
+	s, err := intp.fileScanner("readstring", intp.Stack[len(intp.Stack)-2])
+	if err != nil {
 		return err
 	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	n, err := s.Read(buf)
 	if err != nil && err != io.EOF {
 		return err
@@ -1212,15 +1335,11 @@ func bRepeat(intp *Interpreter) error {
 	if !ok {
 		return intp.e(eTypecheck, "repeat: invalid argument")
 	}
-	intp.Stack = intp.Stack[:len(intp.Stack)-2]
-	for range count {
-		err := intp.executeOne(proc, true)
-		if err == errStop {
-			break
-		} else if err != nil {
-			return err
-		}
+	err := intp.pushFrame(&repeatFrame{count: count, proc: proc})
+	if err != nil {
+		return err
 	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
 	return nil
 }
 
@@ -1266,6 +1385,19 @@ func bStop(intp *Interpreter) error {
 	return errStop
 }
 
+func bStopped(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "stopped: not enough arguments")
+	}
+	proc := intp.Stack[len(intp.Stack)-1]
+	err := intp.pushFrame(&stoppedFrame{})
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	return intp.execObject(proc, true)
+}
+
 func bString(intp *Interpreter) error {
 	if len(intp.Stack) < 1 {
 		return intp.e(eStackunderflow, "string: not enough arguments")
@@ -1331,21 +1463,23 @@ func bType(intp *Interpreter) error {
 		tp = "booleantype"
 	case Dict:
 		tp = "dicttype"
-	case nil: // TODO(voss)
+	case nil, *file:
 		tp = "filetype"
-	// fonttype
+	case *fontID:
+		tp = "fonttype"
 	// gstatetype (LanguageLevel 2)
 	case Integer:
 		tp = "integertype"
 	case Name, Operator:
 		tp = "nametype"
 	// tp = "nulltype"
-	case builtin:
+	case builtin, *GoOperator:
 		tp = "operatortype"
 	// tp = "packedarraytype" (LanguageLevel 2)
 	case Real:
 		tp = "realtype"
-	// tp = "savetype"
+	case *vmSnapshot:
+		tp = "savetype"
 	case String:
 		tp = "stringtype"
 	case mark:
@@ -1353,7 +1487,7 @@ func bType(intp *Interpreter) error {
 	default:
 		return intp.e(eTypecheck, "type: not implemented for %T", obj)
 	}
-	intp.Stack = append(intp.Stack, tp)
+	intp.Stack[len(intp.Stack)-1] = tp
 	return nil
 }
 
@@ -1394,6 +1528,8 @@ func equal(a, b Object) (bool, error) {
 			return string(obj), nil
 		case Name:
 			return string(obj), nil
+		case *fontID, *file, *GoOperator:
+			return obj, nil
 		default:
 			return nil, &postScriptError{eTypecheck, fmt.Sprintf("equality not implemented for %T", obj)}
 		}
@@ -1410,6 +1546,10 @@ func equal(a, b Object) (bool, error) {
 }
 
 func (intp *Interpreter) bindProc(proc Procedure) {
+	if intp.accessOf(proc) != accessUnlimited {
+		// read-only procedures are left unchanged
+		return
+	}
 	for i, elem := range proc {
 		switch obj := elem.(type) {
 		case Name:
@@ -1417,8 +1557,9 @@ func (intp *Interpreter) bindProc(proc Procedure) {
 			if err != nil {
 				continue
 			}
-			_, ok := val.(builtin)
-			if ok {
+			switch val.(type) {
+			case builtin, *GoOperator:
+				intp.saveElems(proc[i : i+1])
 				proc[i] = val
 			}
 		case Operator:
@@ -1426,8 +1567,9 @@ func (intp *Interpreter) bindProc(proc Procedure) {
 			if err != nil {
 				continue
 			}
-			_, ok := val.(builtin)
-			if ok {
+			switch val.(type) {
+			case builtin, *GoOperator:
+				intp.saveElems(proc[i : i+1])
 				proc[i] = val
 			}
 		case Procedure:
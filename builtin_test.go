@@ -1411,6 +1411,34 @@ func TestCmdTruncate(t *testing.T) {
 	}
 }
 
+func TestCmdType(t *testing.T) {
+	type testCase struct {
+		in  Object
+		out Object
+	}
+	cases := []testCase{
+		{Integer(1), Name("integertype")},
+		{Real(1.5), Name("realtype")},
+		{String("x"), Name("stringtype")},
+		{Array{}, Name("arraytype")},
+		{Dict{}, Name("dicttype")},
+	}
+	for _, c := range cases {
+		intp := NewInterpreter()
+		intp.Stack = []Object{c.in}
+		err := intp.ExecuteString("type")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(intp.Stack) != 1 {
+			t.Fatalf("len(intp.Stack): %d != 1", len(intp.Stack))
+		}
+		if intp.Stack[0] != c.out {
+			t.Fatalf("type(%v): %v != %v", c.in, intp.Stack[0], c.out)
+		}
+	}
+}
+
 func TestCmdXor(t *testing.T) {
 	type testCase struct {
 		a, b Object
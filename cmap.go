@@ -145,6 +145,7 @@ var cidInit = Dict{
 		})
 
 		dict := intp.DictStack[len(intp.DictStack)-1]
+		intp.saveDictEntry(dict, "CodeMap")
 		dict["CodeMap"] = intp.cmapMappings
 		intp.cmapMappings = nil
 		return nil
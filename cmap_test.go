@@ -17,8 +17,11 @@
 package postscript
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // TestReadCMapValid verifies that a minimal well-formed CMap is parsed into
@@ -111,3 +114,75 @@ end
 		t.Fatal("expected error for mutated CodeMap, got nil")
 	}
 }
+
+// TestWriteCMap checks that CMaps written by WriteCMap can be read back.
+func TestWriteCMap(t *testing.T) {
+	info := &CMapInfo{
+		UseCMap: "Parent-H",
+		CodeSpaceRanges: []CodeSpaceRange{
+			{Low: []byte{0x00}, High: []byte{0x80}},
+			{Low: []byte{0x81, 0x40}, High: []byte{0xFE, 0xFE}},
+		},
+		BfRanges: []RangeMap{
+			{Low: []byte{0x20}, High: []byte{0x21}, Dst: Array{String{0, 'a'}, Name("b")}},
+			{Low: []byte{0x30}, High: []byte{0x39}, Dst: String{0, '0'}},
+		},
+		CidRanges: []RangeMap{
+			{Low: []byte{0x81, 0x40}, High: []byte{0x81, 0xFE}, Dst: Integer(1000)},
+		},
+		NotdefChars: []CharMap{
+			{Src: []byte{0x7F}, Dst: Integer(1)},
+		},
+	}
+	// More than 100 entries must be split into several blocks.
+	for i := range 150 {
+		info.CidChars = append(info.CidChars, CharMap{Src: []byte{byte(i)}, Dst: Integer(i + 1)})
+	}
+	cmap := Dict{
+		"CMapName": Name("Test-H"),
+		"CMapType": Integer(1),
+		"CIDSystemInfo": Dict{
+			"Registry":   String("Adobe"),
+			"Ordering":   String("Japan1"),
+			"Supplement": Integer(6),
+		},
+		"CMapVersion": Real(1.5),
+		"WMode":       Integer(0),
+		"CodeMap":     info,
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteCMap(buf, cmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte("%%BeginResource: CMap (Test-H)\n")) {
+		t.Error("wrong %%BeginResource comment")
+	}
+
+	dict, codeMap, err := ReadCMap(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(info, codeMap); d != "" {
+		t.Error(d)
+	}
+	if d := cmp.Diff(cmap, dict); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestWriteCMapErrors(t *testing.T) {
+	for i, cmap := range []Dict{
+		{"CMapName": Name("X")},
+		{"CodeMap": &CMapInfo{}},
+		{"CMapName": Name("a b"), "CodeMap": &CMapInfo{}},
+		{"CMapName": Name("X"), "CodeMap": &CMapInfo{}, "Proc": Procedure{}},
+	} {
+		err := WriteCMap(&bytes.Buffer{}, cmap)
+		if err == nil {
+			t.Errorf("%d: expected error", i)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// WriteCMap writes a CMap as a PostScript CMap resource file.
+//
+// The cmap argument is a CMap dictionary, as returned by [ReadCMap], and
+// the mapping data is taken from the CodeMap entry.  The file written can
+// be read back using [ReadCMap].
+func WriteCMap(w io.Writer, cmap Dict) error {
+	info, ok := cmap["CodeMap"].(*CMapInfo)
+	if !ok || info == nil {
+		return errors.New("missing CodeMap")
+	}
+	name, ok := cmap["CMapName"].(Name)
+	if !ok || !isValidName(name) {
+		return errors.New("missing or invalid CMapName")
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("%!PS-Adobe-3.0 Resource-CMap\n")
+	buf.WriteString("%%DocumentNeededResources: ProcSet (CIDInit)\n")
+	if info.UseCMap != "" {
+		fmt.Fprintf(buf, "%%%%+ CMap (%s)\n", string(info.UseCMap))
+	}
+	buf.WriteString("%%IncludeResource: ProcSet (CIDInit)\n")
+	if info.UseCMap != "" {
+		fmt.Fprintf(buf, "%%%%IncludeResource: CMap (%s)\n", string(info.UseCMap))
+	}
+	fmt.Fprintf(buf, "%%%%BeginResource: CMap (%s)\n", string(name))
+	buf.WriteString("%%EndComments\n")
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n")
+	fmt.Fprintf(buf, "%d dict begin\n", len(cmap)+5)
+	buf.WriteString("begincmap\n")
+
+	for _, key := range slices.Sorted(maps.Keys(cmap)) {
+		if key == "CodeMap" {
+			continue
+		}
+		if !isValidName(key) {
+			return fmt.Errorf("invalid key %q in CMap dictionary", key)
+		}
+		buf.WriteString(key.PS())
+		buf.WriteByte(' ')
+		err := writeCMapObject(buf, cmap[key])
+		if err != nil {
+			return fmt.Errorf("/%s: %w", key, err)
+		}
+		buf.WriteString(" def\n")
+	}
+
+	if info.UseCMap != "" {
+		if !isValidName(info.UseCMap) {
+			return fmt.Errorf("invalid UseCMap %q", info.UseCMap)
+		}
+		fmt.Fprintf(buf, "%s usecmap\n", info.UseCMap.PS())
+	}
+
+	err := writeChunks(buf, "codespacerange", len(info.CodeSpaceRanges), func(i int) error {
+		r := info.CodeSpaceRanges[i]
+		fmt.Fprintf(buf, "<%X> <%X>", r.Low, r.High)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sections := []struct {
+		name  string
+		chars []CharMap
+	}{
+		{"cidchar", info.CidChars},
+		{"bfchar", info.BfChars},
+		{"notdefchar", info.NotdefChars},
+	}
+	for _, s := range sections {
+		err := writeChunks(buf, s.name, len(s.chars), func(i int) error {
+			fmt.Fprintf(buf, "<%X> ", s.chars[i].Src)
+			return writeCMapObject(buf, s.chars[i].Dst)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	rangeSections := []struct {
+		name   string
+		ranges []RangeMap
+	}{
+		{"cidrange", info.CidRanges},
+		{"bfrange", info.BfRanges},
+		{"notdefrange", info.NotdefRanges},
+	}
+	for _, s := range rangeSections {
+		err := writeChunks(buf, s.name, len(s.ranges), func(i int) error {
+			r := s.ranges[i]
+			fmt.Fprintf(buf, "<%X> <%X> ", r.Low, r.High)
+			return writeCMapObject(buf, r.Dst)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString("endcmap\n")
+	buf.WriteString("CMapName currentdict /CMap defineresource pop\n")
+	buf.WriteString("end\n")
+	buf.WriteString("end\n")
+	buf.WriteString("%%EndResource\n")
+	buf.WriteString("%%EOF\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// maxCMapChunk is the maximal number of entries in a single
+// begin...end block of a CMap.
+const maxCMapChunk = 100
+
+// writeChunks writes n entries of a CMap section, split into blocks of at
+// most maxCMapChunk entries.  The function writeEntry is called to write
+// entry i, without the trailing newline.
+func writeChunks(buf *bytes.Buffer, name string, n int, writeEntry func(i int) error) error {
+	for start := 0; start < n; start += maxCMapChunk {
+		end := min(start+maxCMapChunk, n)
+		fmt.Fprintf(buf, "%d begin%s\n", end-start, name)
+		for i := start; i < end; i++ {
+			err := writeEntry(i)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(buf, "end%s\n", name)
+	}
+	return nil
+}
+
+// writeCMapObject writes the values which can occur in a CMap.
+// Strings are written in hexadecimal form.
+func writeCMapObject(buf *bytes.Buffer, obj Object) error {
+	switch obj := obj.(type) {
+	case Integer:
+		buf.WriteString(strconv.Itoa(int(obj)))
+	case Real:
+		buf.WriteString(strconv.FormatFloat(float64(obj), 'f', -1, 64))
+	case Boolean:
+		buf.WriteString(strconv.FormatBool(bool(obj)))
+	case String:
+		fmt.Fprintf(buf, "<%X>", []byte(obj))
+	case Name:
+		if !isValidName(obj) {
+			return fmt.Errorf("invalid name %q", obj)
+		}
+		buf.WriteString(obj.PS())
+	case Array:
+		buf.WriteByte('[')
+		for i, elem := range obj {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			err := writeCMapObject(buf, elem)
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case Dict:
+		buf.WriteString("<<")
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			if !isValidName(key) {
+				return fmt.Errorf("invalid key %q", key)
+			}
+			buf.WriteString(" " + key.PS() + " ")
+			err := writeCMapObject(buf, obj[key])
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteString(" >>")
+	default:
+		return fmt.Errorf("unsupported type %T", obj)
+	}
+	return nil
+}
+
+// isValidName reports whether n can be written as a literal name.
+func isValidName(n Name) bool {
+	if n == "" {
+		return false
+	}
+	for _, c := range []byte(n) {
+		if class[c] != regular || isBinaryToken(c) {
+			return false
+		}
+	}
+	return true
+}
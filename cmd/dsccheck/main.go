@@ -0,0 +1,72 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Dsccheck reports violations of the Document Structuring Conventions in
+// PostScript files.
+//
+// Usage:
+//
+//	dsccheck file ...
+//
+// Each problem is printed as "file:line: message".  The exit status is 1
+// if any problems were found, and 2 if a file could not be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"seehuhn.de/go/postscript/dsc"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: dsccheck file ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, fname := range flag.Args() {
+		problems, err := check(fname)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dsccheck: %v\n", err)
+			status = 2
+			continue
+		}
+		for _, p := range problems {
+			fmt.Printf("%s:%d: %s\n", fname, p.Line, p.Message)
+		}
+		if len(problems) > 0 && status == 0 {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+func check(fname string) ([]dsc.Problem, error) {
+	fd, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return dsc.Check(fd)
+}
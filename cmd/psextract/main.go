@@ -0,0 +1,112 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Psextract extracts the fonts and other resources embedded in PostScript
+// documents.
+//
+// Usage:
+//
+//	psextract [-l] [-o dir] file
+//
+// The resources are written to the output directory, using the file
+// "category/name" for each resource, for example "Font/Times-Roman".  This
+// is the layout expected by postscript.FSResources, so that the output
+// directory can be used to provide the resources to the interpreter.  With
+// the -l flag, the resources are listed instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"seehuhn.de/go/postscript/extract"
+)
+
+func main() {
+	list := flag.Bool("l", false, "list the resources instead of writing them")
+	outDir := flag.String("o", ".", "output directory")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: psextract [-l] [-o dir] file")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fd, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "psextract:", err)
+		os.Exit(1)
+	}
+	res, err := extract.Extract(fd)
+	fd.Close()
+	if err != nil {
+		// Execution errors are common for documents which rely on
+		// unavailable resources, so the resources found are still written.
+		fmt.Fprintln(os.Stderr, "psextract: warning:", err)
+		if res == nil {
+			os.Exit(1)
+		}
+	}
+
+	for _, r := range res {
+		if *list {
+			status := ""
+			if r.Data == nil {
+				status = " (not extracted)"
+			}
+			fmt.Printf("%s/%s%s\n", r.Category, r.Name, status)
+			continue
+		}
+		if r.Data == nil {
+			continue
+		}
+		err := write(*outDir, r)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "psextract:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// write stores a resource in the file "category/name" below dir.
+func write(dir string, r *extract.Resource) error {
+	dir = filepath.Join(dir, safeName(r.Category))
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, safeName(r.Name)), r.Data, 0o644)
+}
+
+// safeName replaces characters which cannot be used in file names.
+func safeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
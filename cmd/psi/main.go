@@ -0,0 +1,248 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Psi runs the PostScript interpreter from the command line.
+//
+// Usage:
+//
+//	psi [-i] [file ...]
+//
+// The named files are executed in order, using a single interpreter.
+// The file name "-" denotes standard input.  If no files are given, or if
+// the -i flag is used, psi afterwards reads PostScript code from standard
+// input one line at a time and prints the operand stack after each line.
+// Errors are reported, but the state of the interpreter is kept.
+//
+// Print job files, which start with a PJL UEL sequence or Ctrl-D, may
+// contain several jobs.  Each of these is run inside a save/restore pair, so
+// that jobs cannot affect each other.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/pfb"
+	"seehuhn.de/go/postscript/pjl"
+	_ "seehuhn.de/go/postscript/type1"
+)
+
+func main() {
+	interactive := flag.Bool("i", false, "read commands from standard input after running the files")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: psi [-i] [file ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	intp := postscript.NewInterpreter()
+	intp.Stdout = out
+
+	for _, fname := range flag.Args() {
+		err := runFile(intp, fname)
+		out.Flush()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "psi: %s: %v\n", fname, err)
+			os.Exit(1)
+		}
+	}
+
+	if *interactive || flag.NArg() == 0 {
+		// Errors are shown by repl, so the report written by the
+		// default `handleerror` would only duplicate these.
+		intp.ErrorDict["handleerror"] = postscript.Procedure{}
+		repl(intp, os.Stdin, out)
+	}
+}
+
+// runFile executes the PostScript code in the named file.
+// Files in PFB format are decoded on the fly, and PJL commands and Ctrl-D
+// job separators are removed from print job files.
+func runFile(intp *postscript.Interpreter, fname string) error {
+	var r io.Reader
+	if fname == "-" {
+		r = os.Stdin
+	} else {
+		fd, err := os.Open(fname)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		r = fd
+	}
+
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(1)
+	if len(head) > 0 && head[0] == 0x80 {
+		return intp.Execute(pfb.Decode(br))
+	}
+	if len(head) > 0 && (head[0] == 0x1b || head[0] == 0x04) {
+		return runJobs(intp, pjl.NewReader(br))
+	}
+	return intp.Execute(br)
+}
+
+// runJobs executes the jobs of a print job file, after removing the PJL
+// commands and job separators.
+func runJobs(intp *postscript.Interpreter, r *pjl.Reader) error {
+	for {
+		err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = runJob(intp, r)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// runJob executes a single job of a print job file.  Like in a printer,
+// the job is enclosed in a save/restore pair and the operand and dictionary
+// stacks are reinstated afterwards, so that jobs cannot affect each other.  Jobs
+// which do not start with "%!", for example PCL jobs, are skipped.
+func runJob(intp *postscript.Interpreter, r io.Reader) error {
+	stack, dictStack := slices.Clone(intp.Stack), slices.Clone(intp.DictStack)
+	err := intp.ExecuteString("save")
+	if err != nil {
+		return err
+	}
+	save, err := intp.Pop()
+	if err != nil {
+		return err
+	}
+
+	intp.CheckStart = true
+	jobErr := intp.Execute(r)
+	intp.CheckStart = false
+
+	intp.Stack = append(stack, save)
+	intp.DictStack = dictStack
+	err = intp.ExecuteString("restore")
+	if jobErr != nil && !errors.Is(jobErr, postscript.ErrNoPostScript) {
+		return jobErr
+	}
+	return err
+}
+
+// repl reads PostScript code from r and executes it.  Lines are collected
+// until all strings and procedures are closed, and the operand stack is
+// printed after each chunk of code has been executed.
+func repl(intp *postscript.Interpreter, r io.Reader, out *bufio.Writer) {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(nil, 1<<20)
+
+	var code strings.Builder
+	for {
+		if code.Len() == 0 {
+			fmt.Fprintf(out, "PS<%d>", len(intp.Stack))
+		} else {
+			out.WriteString("..>")
+		}
+		out.Flush()
+
+		if !lines.Scan() {
+			break
+		}
+		code.WriteString(lines.Text())
+		code.WriteByte('\n')
+		if incomplete(code.String()) {
+			continue
+		}
+
+		err := intp.ExecuteString(code.String())
+		code.Reset()
+		out.Flush()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		showStack(out, intp.Stack)
+	}
+	out.WriteByte('\n')
+
+	if err := lines.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "psi:", err)
+	}
+}
+
+// incomplete reports whether code ends inside a string literal or a
+// procedure body.
+func incomplete(code string) bool {
+	braces := 0
+	for i := 0; i < len(code); i++ {
+		switch code[i] {
+		case '%':
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case '(':
+			parens := 1
+			for i++; i < len(code) && parens > 0; i++ {
+				switch code[i] {
+				case '\\':
+					i++
+				case '(':
+					parens++
+				case ')':
+					parens--
+				}
+			}
+			if parens > 0 {
+				return true
+			}
+			i--
+		case '<':
+			if i+1 < len(code) && code[i+1] == '<' {
+				i++
+				continue
+			}
+			end := strings.IndexByte(code[i:], '>')
+			if end < 0 {
+				return true
+			}
+			i += end
+		case '{':
+			braces++
+		case '}':
+			braces--
+		}
+	}
+	return braces > 0
+}
+
+// showStack prints the operand stack, with the bottom element first.
+func showStack(out io.Writer, stack []postscript.Object) {
+	if len(stack) == 0 {
+		return
+	}
+	parts := make([]string, len(stack))
+	for i, obj := range stack {
+		parts[i] = postscript.Format(obj)
+	}
+	fmt.Fprintln(out, strings.Join(parts, " "))
+}
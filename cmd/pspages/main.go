@@ -0,0 +1,167 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Pspages selects, reorders and imposes the pages of a DSC-conforming
+// PostScript file.
+//
+// Usage:
+//
+//	pspages [flags] [input [output]]
+//
+// The pages given by -p are written in the given order; see
+// [dsc.ParsePageRanges] for the format.  The flags -e and -o keep only the
+// even or odd pages of the selection, and -r reverses the order.  With -n,
+// several pages are placed on each output sheet.  If no input file is
+// given, or the input file is "-", the document is read from standard
+// input.  The result is written to standard output, unless an output file
+// is given.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/postscript/dsc"
+)
+
+// paperSizes gives the sizes of common paper formats in PostScript points.
+var paperSizes = map[string][2]float64{
+	"a3":     {842, 1191},
+	"a4":     {595, 842},
+	"a5":     {420, 595},
+	"letter": {612, 792},
+	"legal":  {612, 1008},
+}
+
+func main() {
+	pageSpec := flag.String("p", "", "page ranges to select, e.g. \"1-3,5,_1\"")
+	even := flag.Bool("e", false, "select even pages only")
+	odd := flag.Bool("o", false, "select odd pages only")
+	reverse := flag.Bool("r", false, "reverse the order of the pages")
+	nup := flag.Int("n", 1, "number of pages per output sheet")
+	paper := flag.String("paper", "", "size of the output sheets, e.g. \"a4\" or \"595x842\"")
+	margin := flag.Float64("margin", 0, "margin around each output sheet, in points")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pspages [flags] [input [output]]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 2 || *even && *odd {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := run(flag.Arg(0), flag.Arg(1), *pageSpec, *even, *odd, *reverse, *nup, *paper, *margin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pspages:", err)
+		os.Exit(1)
+	}
+}
+
+func run(inName, outName, pageSpec string, even, odd, reverse bool, nup int, paper string, margin float64) error {
+	// Read uses the io.Reader methods, while Write uses ReadAt.  These
+	// do not interfere with each other.
+	var src interface {
+		io.Reader
+		io.ReaderAt
+	}
+	if inName == "" || inName == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		src = bytes.NewReader(data)
+	} else {
+		fd, err := os.Open(inName)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		src = fd
+	}
+
+	doc, err := dsc.Read(src)
+	if err != nil {
+		return err
+	}
+
+	opt := &dsc.Options{
+		NUp:    nup,
+		Margin: margin,
+	}
+	if paper != "" {
+		opt.SheetWidth, opt.SheetHeight, err = parsePaper(paper)
+		if err != nil {
+			return err
+		}
+	}
+
+	pages := make([]int, len(doc.Pages))
+	for i := range pages {
+		pages[i] = i
+	}
+	if pageSpec != "" {
+		pages, err = dsc.ParsePageRanges(pageSpec, len(doc.Pages))
+		if err != nil {
+			return err
+		}
+	}
+	if even || odd {
+		pages = slices.DeleteFunc(pages, func(p int) bool {
+			return p >= 0 && (p%2 == 1) != even
+		})
+	}
+	if reverse {
+		slices.Reverse(pages)
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("no pages selected")
+	}
+	opt.Pages = pages
+
+	var w io.Writer = os.Stdout
+	if outName != "" && outName != "-" {
+		out, err := os.Create(outName)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		w = out
+	}
+	return dsc.Write(w, src, doc, opt)
+}
+
+// parsePaper parses a paper name like "a4", or a size like "595x842".
+func parsePaper(s string) (float64, float64, error) {
+	if size, ok := paperSizes[strings.ToLower(s)]; ok {
+		return size[0], size[1], nil
+	}
+	ws, hs, ok := strings.Cut(s, "x")
+	if ok {
+		w, err1 := strconv.ParseFloat(ws, 64)
+		h, err2 := strconv.ParseFloat(hs, 64)
+		if err1 == nil && err2 == nil && w > 0 && h > 0 {
+			return w, h, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid paper size %q", s)
+}
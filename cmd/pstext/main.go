@@ -0,0 +1,109 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Pstext extracts the text of a PostScript document.
+//
+// Usage:
+//
+//	pstext [-json] [-fonts dir] file
+//
+// The text of each page is printed in reading order, and pages are
+// separated by form feed characters.  With the -json flag, the text is
+// printed as a JSON array of pages instead, where each page lists the
+// strings shown together with their position, font and size.  The file
+// name "-" denotes standard input.
+//
+// Fonts which are not embedded in the document are loaded from the
+// directory given by the -fonts flag, using the file "Font/name" for the
+// font name.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/text"
+	_ "seehuhn.de/go/postscript/type1"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the text in JSON format")
+	fontDir := flag.String("fonts", "", "directory for fonts which are not embedded")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pstext [-json] [-fonts dir] file")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var r io.Reader
+	if fname := flag.Arg(0); fname == "-" {
+		r = os.Stdin
+	} else {
+		fd, err := os.Open(fname)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "pstext:", err)
+			os.Exit(1)
+		}
+		defer fd.Close()
+		r = fd
+	}
+
+	dev := text.NewDevice()
+	intp := postscript.NewInterpreter()
+	intp.Device = dev
+	if *fontDir != "" {
+		intp.ResourceProvider = postscript.FSResources(os.DirFS(*fontDir))
+	}
+	if err := intp.Execute(bufio.NewReader(r)); err != nil {
+		// The text found before the error is still useful.
+		fmt.Fprintln(os.Stderr, "pstext: warning:", err)
+	}
+	if len(dev.Page().Spans) > 0 {
+		// The document did not end with `showpage`.
+		dev.ShowPage()
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	var err error
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(dev.Pages)
+	} else {
+		for i, page := range dev.Pages {
+			if i > 0 {
+				out.WriteByte('\f')
+			}
+			out.WriteString(page.Text())
+		}
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pstext:", err)
+		os.Exit(1)
+	}
+}
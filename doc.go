@@ -17,6 +17,12 @@
 // Package postscript implements a rudimentary PostScript interpreter.
 //
 // The package implements enough of the PostScript language to be able to read
-// most Type 1 fonts and CMap files.  It does not contain a rasterizer and does
-// not implement any drawing operations.
+// most Type 1 fonts and CMap files.  The graphics state and the path
+// construction operators are implemented, and painting operators pass their
+// paths to a [Device].  The subpackage raster provides a Device which
+// renders pages into images.
+//
+// The text operators support Type 3 fonts directly.  Decoders for other
+// font types are added using [RegisterFontType]; importing the subpackage
+// type1 makes Type 1 fonts available.
 package postscript
@@ -0,0 +1,179 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Problem describes a violation of the Document Structuring Conventions.
+type Problem struct {
+	// Line is the number of the line where the problem was found,
+	// starting at 1.
+	Line int
+
+	// Message describes the problem.
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Check reads a PostScript file and reports violations of the Document
+// Structuring Conventions.  The problems are sorted by line number.  An
+// error is only returned if reading from r fails.
+func Check(r io.Reader) ([]Problem, error) {
+	p := newParser(r)
+	err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	doc := p.doc
+	problems := p.problems
+
+	report := func(c *Comment, format string, args ...any) {
+		problems = append(problems, Problem{Line: c.Line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Resources declared in the header.  Resources of types other than
+	// fonts are listed with version and revision, which are harmlessly
+	// included as names.
+	needed := make(map[string]bool)
+	for _, c := range doc.Header.Comments {
+		switch c.Key {
+		case "DocumentNeededResources":
+			tp := ""
+			for _, f := range strings.Fields(c.Value) {
+				if resourceTypes[f] {
+					tp = f
+				} else {
+					needed[tp+" "+f] = true
+				}
+			}
+		case "DocumentNeededFonts":
+			for _, f := range strings.Fields(c.Value) {
+				needed["font "+f] = true
+			}
+		}
+	}
+
+	for _, c := range doc.allComments() {
+		if c.Value == "(atend)" {
+			report(c, "no value for %%%%%s: (atend) in trailer", c.Key)
+			continue
+		}
+
+		switch c.Key {
+		case "Pages":
+			if c.Value == "" {
+				break
+			}
+			ff := strings.Fields(c.Value)
+			n, err := strconv.Atoi(ff[0])
+			if err != nil || n < 0 {
+				report(c, "invalid %%%%Pages: %s", c.Value)
+			} else if n != len(doc.Pages) && !(len(doc.Pages) == 0 && n <= 1) {
+				// EPS files without %%Page comments can declare one page.
+				report(c, "%%%%Pages: %d, but the document has %d pages", n, len(doc.Pages))
+			}
+		case "BoundingBox", "PageBoundingBox":
+			if !validBoundingBox(c.Value, true) {
+				report(c, "invalid %%%%%s: %s", c.Key, c.Value)
+			}
+		case "HiResBoundingBox", "PageHiResBoundingBox":
+			if !validBoundingBox(c.Value, false) {
+				report(c, "invalid %%%%%s: %s", c.Key, c.Value)
+			}
+		case "IncludeResource":
+			tp, name, _ := strings.Cut(c.Value, " ")
+			name, _, _ = strings.Cut(strings.TrimSpace(name), " ")
+			if !needed[tp+" "+name] {
+				report(c, "resource %s %s not declared in %%%%DocumentNeededResources", tp, name)
+			}
+		case "IncludeFont":
+			if !needed["font "+c.Value] {
+				report(c, "font %s not declared in %%%%DocumentNeededResources", c.Value)
+			}
+		}
+	}
+
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		return a.Line - b.Line
+	})
+	return problems, nil
+}
+
+// resourceTypes lists the resource types used in the DSC.
+var resourceTypes = map[string]bool{
+	"cidfont":  true,
+	"cmap":     true,
+	"encoding": true,
+	"file":     true,
+	"font":     true,
+	"form":     true,
+	"pattern":  true,
+	"procset":  true,
+}
+
+// allComments returns all comments in the document, in no particular
+// order.  Comments in the trailer are omitted, since their values are
+// included in the header comments whose value was "(atend)".
+func (d *Document) allComments() []*Comment {
+	var res []*Comment
+	add := func(s *Section) {
+		if s == nil {
+			return
+		}
+		for i := range s.Comments {
+			res = append(res, &s.Comments[i])
+		}
+	}
+	add(&d.Header)
+	add(d.Defaults)
+	add(d.Prolog)
+	add(d.Setup)
+	for _, page := range d.Pages {
+		add(&page.Section)
+	}
+	for _, res := range d.Resources {
+		add(&res.Section)
+	}
+	return res
+}
+
+// validBoundingBox reports whether val is a valid bounding box.  If
+// integer is true, the coordinates must be integers.
+func validBoundingBox(val string, integer bool) bool {
+	ff := strings.Fields(val)
+	if len(ff) != 4 {
+		return false
+	}
+	if integer {
+		for _, f := range ff {
+			if _, err := strconv.Atoi(f); err != nil {
+				return false
+			}
+		}
+	}
+	bbox, ok := parseBoundingBox(val)
+	return ok && bbox.LLx <= bbox.URx && bbox.LLy <= bbox.URy
+}
@@ -0,0 +1,97 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckValid(t *testing.T) {
+	const eps = `%!PS-Adobe-3.0 EPSF-3.0
+%%BoundingBox: 0 0 10 10
+%%Pages: 1
+%%DocumentNeededResources: font Times-Roman
+%%EndComments
+%%IncludeResource: font Times-Roman
+/Times-Roman 10 selectfont 0 0 moveto (x) show
+%%EOF
+`
+	for _, src := range []string{pagesDoc, eps} {
+		problems, err := Check(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(problems) != 0 {
+			t.Errorf("unexpected problems: %v", problems)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	const src = `%!PS-Adobe-3.0
+%%BoundingBox: 0 0 612.5 792
+%%HiResBoundingBox: 0 0 -1 1
+%%Pages: 2
+%%DocumentNeededResources: font Times-Roman Helvetica
+%%+ procset Util 1.0 0
+%%DocumentNeededFonts: Courier
+%%Title: (atend)
+/x 1 def
+%%BeginProlog
+%%BeginResource: procset Local 1.0 0
+%%EndFont
+%%EndProlog
+%%Page: 1 1
+%%IncludeResource: font Times-Roman
+%%IncludeResource: procset Util 1.0 0
+%%IncludeFont: Courier
+%%Page: 1 2
+%%IncludeResource: font Symbol
+%%IncludeFont: Symbol
+%%PageBoundingBox: 0 0 100
+%%Page: 3 4
+%%EndDocument
+%%BeginFont: Other
+%%Trailer
+%%EOF
+`
+	problems, err := Check(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Problem{
+		{2, "invalid %%BoundingBox: 0 0 612.5 792"},
+		{3, "invalid %%HiResBoundingBox: 0 0 -1 1"},
+		{4, "%%Pages: 2, but the document has 3 pages"},
+		{8, "no value for %%Title: (atend) in trailer"},
+		{9, "header not terminated by %%EndComments"},
+		{12, "%%EndFont does not match %%BeginResource in line 11"},
+		{18, "duplicate page label \"1\", first used in line 14"},
+		{19, "resource font Symbol not declared in %%DocumentNeededResources"},
+		{20, "font Symbol not declared in %%DocumentNeededResources"},
+		{21, "invalid %%PageBoundingBox: 0 0 100"},
+		{22, "page ordinal 4, expected 3"},
+		{23, "%%EndDocument without %%BeginDocument"},
+		{24, "%%BeginFont not terminated"},
+	}
+	if d := cmp.Diff(expected, problems); d != "" {
+		t.Error(d)
+	}
+}
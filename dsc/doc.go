@@ -0,0 +1,34 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package dsc parses the structure of PostScript files which follow the
+// Document Structuring Conventions (DSC).
+//
+// The file is not executed.  Instead, the structure is found by reading
+// the DSC comments, which makes parsing fast enough to find page counts and
+// bounding boxes for large numbers of files.  The parser locates the
+// header, the defaults, prolog and setup sections, the pages, the trailer
+// and all resources, and records the byte offsets of each part.  Comment
+// values given as "(atend)" are replaced by the corresponding values from
+// the trailer.  Binary data marked by %%BeginBinary or %%BeginData is
+// skipped, and DSC comments in embedded documents are ignored.
+//
+// [Check] reports violations of the conventions, and [Write] rearranges
+// the pages of a document.
+//
+// The conventions are documented in Adobe technical note #5001,
+// "PostScript Language Document Structuring Conventions Specification".
+package dsc
@@ -0,0 +1,171 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/geom/rect"
+)
+
+// Document describes the structure of a PostScript file.
+type Document struct {
+	// Version is the DSC version given in the first line of the file,
+	// for example "3.0" for a file starting with "%!PS-Adobe-3.0".
+	// If the file does not start with "%!PS-Adobe-", Version is empty.
+	Version string
+
+	// Type holds the keywords which follow the version in the first line
+	// of the file, for example "EPSF-3.0".
+	Type string
+
+	// Header is the header comments section at the start of the file.
+	// This includes the first line of the file.
+	Header Section
+
+	// Defaults, Prolog, Setup and Trailer are the corresponding sections
+	// of the document, or nil if the section is not present.
+	Defaults *Section
+	Prolog   *Section
+	Setup    *Section
+	Trailer  *Section
+
+	// Pages lists the pages of the document, in the order in which they
+	// appear in the file.
+	Pages []*Page
+
+	// Resources lists the resources found in the file, in the order of
+	// their %%BeginResource comments.  Nested resources are included.
+	Resources []*Resource
+
+	// Size is the length of the file in bytes.
+	Size int64
+}
+
+// BoundingBox returns the bounding box of the document, as given by the
+// %%HiResBoundingBox or %%BoundingBox comment in the header.
+func (d *Document) BoundingBox() (rect.Rect, bool) {
+	return d.Header.boundingBox("HiResBoundingBox", "BoundingBox")
+}
+
+// Section is a part of a PostScript file.
+type Section struct {
+	// Start is the byte offset of the first line of the section,
+	// and End is the byte offset just after the last line.
+	Start, End int64
+
+	// Comments lists the DSC comments in the section.  The comments which
+	// delimit the section are not included, and comments inside embedded
+	// documents are ignored.
+	Comments []Comment
+}
+
+// Comment returns the value of the first comment with the given key.
+func (s *Section) Comment(key string) (string, bool) {
+	for _, c := range s.Comments {
+		if c.Key == key {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// boundingBox returns the bounding box given by the first of the keys
+// which is present and valid.
+func (s *Section) boundingBox(keys ...string) (rect.Rect, bool) {
+	for _, key := range keys {
+		val, ok := s.Comment(key)
+		if !ok {
+			continue
+		}
+		if bbox, ok := parseBoundingBox(val); ok {
+			return bbox, true
+		}
+	}
+	return rect.Rect{}, false
+}
+
+// Page is a page of a PostScript document.
+type Page struct {
+	// Label is the page label given in the %%Page comment, for example
+	// "iv".  Enclosing parentheses are removed.
+	Label string
+
+	// Ordinal is the position of the page in the document, as given in the
+	// %%Page comment.  The first page has ordinal 1.
+	Ordinal int
+
+	// Section describes the page, starting with the %%Page comment.
+	// This includes the page setup and the page trailer.
+	Section
+}
+
+// BoundingBox returns the bounding box of the page, as given by the
+// %%PageHiResBoundingBox or %%PageBoundingBox comment.
+func (p *Page) BoundingBox() (rect.Rect, bool) {
+	return p.boundingBox("PageHiResBoundingBox", "PageBoundingBox")
+}
+
+// Resource is a resource included in a PostScript file.
+type Resource struct {
+	// Type is the resource type, for example "font" or "procset".
+	Type string
+
+	// Name is the name of the resource, together with any further
+	// information given in the %%BeginResource comment, for example the
+	// version of a procset.
+	Name string
+
+	// Section describes the resource, from the %%BeginResource comment
+	// to the %%EndResource comment.
+	Section
+}
+
+// Comment is a DSC comment.
+type Comment struct {
+	// Key is the comment keyword without the leading "%%" and without the
+	// trailing colon, for example "BoundingBox".
+	Key string
+
+	// Value is the text after the keyword, with leading and trailing
+	// white space removed.  Continuation lines starting with "%%+" are
+	// appended, separated by a space.
+	Value string
+
+	// Offset is the byte offset of the comment in the file, and Line is
+	// the line number, starting at 1.
+	Offset int64
+	Line   int
+}
+
+// parseBoundingBox parses the value of a bounding box comment.
+func parseBoundingBox(val string) (rect.Rect, bool) {
+	ff := strings.Fields(val)
+	if len(ff) != 4 {
+		return rect.Rect{}, false
+	}
+	var x [4]float64
+	for i, f := range ff {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return rect.Rect{}, false
+		}
+		x[i] = v
+	}
+	return rect.Rect{LLx: x[0], LLy: x[1], URx: x[2], URy: x[3]}, true
+}
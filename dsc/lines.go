@@ -0,0 +1,135 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// maxLineLength is the number of bytes kept from each line.  Longer lines
+// are truncated.  The DSC specification limits lines to 255 bytes, but
+// longer lines are found in practice.
+const maxLineLength = 4096
+
+// lineReader splits a file into lines, keeping track of byte offsets.
+// Lines can be terminated by CR, LF or CR LF.
+type lineReader struct {
+	r    *bufio.Reader
+	line []byte
+
+	// pos is the byte offset of the next unread byte.
+	pos int64
+
+	// lineNo is the line number of the line most recently returned by
+	// next, starting at 1.  While data is skipped, lastCR records whether
+	// the last byte skipped was a carriage return, so that CR LF is counted
+	// as a single line end.
+	lineNo int
+	lastCR bool
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{
+		r: bufio.NewReaderSize(r, 64*1024),
+	}
+}
+
+// next returns the next line, without the line terminator, together with
+// the byte offset of the start of the line.  The returned slice is only
+// valid until the next call.  At the end of input, io.EOF is returned.
+func (l *lineReader) next() ([]byte, int64, error) {
+	start := l.pos
+	l.line = l.line[:0]
+	l.lineNo++
+	for {
+		buf, err := l.r.Peek(max(l.r.Buffered(), 1))
+		if len(buf) == 0 {
+			if err == io.EOF && l.pos > start {
+				return l.line, start, nil
+			}
+			return nil, start, err
+		}
+
+		i := bytes.IndexAny(buf, "\r\n")
+		if i < 0 {
+			l.append(buf)
+			l.discard(len(buf))
+			continue
+		}
+		l.append(buf[:i])
+		isCR := buf[i] == '\r'
+		l.discard(i + 1)
+		if isCR {
+			if next, _ := l.r.Peek(1); len(next) > 0 && next[0] == '\n' {
+				l.discard(1)
+			}
+		}
+		return l.line, start, nil
+	}
+}
+
+// append adds data to the current line, up to maxLineLength bytes.
+func (l *lineReader) append(data []byte) {
+	if room := maxLineLength - len(l.line); room < len(data) {
+		data = data[:max(room, 0)]
+	}
+	l.line = append(l.line, data...)
+}
+
+func (l *lineReader) discard(n int) {
+	l.r.Discard(n)
+	l.pos += int64(n)
+}
+
+// skip skips n bytes of input.  Line ends in the skipped data are
+// counted, so that line numbers stay correct.
+func (l *lineReader) skip(n int64) error {
+	l.lastCR = false
+	for n > 0 {
+		buf, err := l.r.Peek(int(min(int64(max(l.r.Buffered(), 1)), n)))
+		if len(buf) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		for _, b := range buf {
+			if b == '\r' || b == '\n' && !l.lastCR {
+				l.lineNo++
+			}
+			l.lastCR = b == '\r'
+		}
+		l.discard(len(buf))
+		n -= int64(len(buf))
+	}
+	return nil
+}
+
+// skipLines skips n lines of input.
+func (l *lineReader) skipLines(n int64) error {
+	for range n {
+		_, _, err := l.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
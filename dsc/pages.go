@@ -0,0 +1,94 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePageRanges parses a list of page ranges, in the format used by
+// psselect, for a document with numPages pages.  The result can be used as
+// Options.Pages.
+//
+// The list consists of comma-separated entries.  Each entry is either a
+// single page number or a range "first-last", where either end may be
+// omitted to denote the first or last page of the document.  Pages are
+// numbered from 1, and numbers prefixed by "_" count from the end of the
+// document, so that "_1" is the last page.  A range whose first page comes
+// after its last page selects the pages in reverse order.  The entry "_"
+// inserts a blank page.
+func ParsePageRanges(spec string, numPages int) ([]int, error) {
+	var res []int
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "_" {
+			res = append(res, -1)
+			continue
+		}
+
+		first, last, isRange := strings.Cut(entry, "-")
+		a, b := 1, numPages
+		var err error
+		if first != "" || !isRange {
+			a, err = parsePageNumber(first, numPages)
+			if err != nil {
+				return nil, fmt.Errorf("invalid page range %q: %w", entry, err)
+			}
+		}
+		if !isRange {
+			b = a
+		} else if last != "" {
+			b, err = parsePageNumber(last, numPages)
+			if err != nil {
+				return nil, fmt.Errorf("invalid page range %q: %w", entry, err)
+			}
+		}
+
+		if a <= b {
+			for p := a; p <= b; p++ {
+				res = append(res, p-1)
+			}
+		} else {
+			for p := a; p >= b; p-- {
+				res = append(res, p-1)
+			}
+		}
+	}
+	return res, nil
+}
+
+// parsePageNumber parses a page number for ParsePageRanges.
+func parsePageNumber(s string, numPages int) (int, error) {
+	fromEnd := false
+	if rest, ok := strings.CutPrefix(s, "_"); ok {
+		fromEnd = true
+		s = rest
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid page number %q", s)
+	}
+	if n > numPages {
+		return 0, fmt.Errorf("page %d out of range 1-%d", n, numPages)
+	}
+	if fromEnd {
+		n = numPages + 1 - n
+	}
+	return n, nil
+}
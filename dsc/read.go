@@ -0,0 +1,419 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Read parses the DSC structure of a PostScript file.
+//
+// The parser is lenient: comments which do not follow the conventions are
+// ignored, and an error is only returned if reading from r fails.
+func Read(r io.Reader) (*Document, error) {
+	p := newParser(r)
+	err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return p.doc, nil
+}
+
+type parser struct {
+	lines *lineReader
+	doc   *Document
+
+	// current is the section which is currently open, or nil if the
+	// current position is outside all sections.
+	current *Section
+
+	// page is the current page, or nil if current is not a page.
+	// pageTrailer is the index of the first comment in the page trailer,
+	// or -1 if no %%PageTrailer has been seen.
+	page        *Page
+	pageTrailer int
+
+	// resources holds the open resources, innermost last.
+	resources []openResource
+
+	// docDepth is the nesting depth of embedded documents, and docLine is
+	// the line number of the outermost %%BeginDocument comment.
+	docDepth int
+	docLine  int
+
+	// pageLabels maps page labels to the line of their %%Page comment.
+	pageLabels map[string]int
+
+	// last is the list of comments which received the most recent
+	// comment, for use by continuation lines.
+	last *[]Comment
+
+	// problems lists the violations of the DSC found while parsing.
+	problems []Problem
+}
+
+// openResource is a resource whose end has not been seen yet.
+type openResource struct {
+	*Resource
+	key  string
+	line int
+}
+
+func newParser(r io.Reader) *parser {
+	return &parser{
+		lines:      newLineReader(r),
+		doc:        &Document{},
+		pageLabels: make(map[string]int),
+	}
+}
+
+func (p *parser) parse() error {
+	doc := p.doc
+
+	line, start, err := p.lines.next()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(line, []byte("%!PS-Adobe-")) {
+		p.report(1, "file does not start with %%!PS-Adobe-")
+	}
+	inHeader := bytes.HasPrefix(line, []byte("%!"))
+	if inHeader {
+		if rest, ok := bytes.CutPrefix(line, []byte("%!PS-Adobe-")); ok {
+			version, tp, _ := strings.Cut(string(rest), " ")
+			doc.Version = version
+			doc.Type = strings.TrimSpace(tp)
+		}
+	} else {
+		doc.Prolog = p.open(start)
+		err = p.comment(line, start)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		line, start, err = p.lines.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if inHeader {
+			if isHeaderLine(line) {
+				if string(line) == "%%EndComments" {
+					inHeader = false
+					doc.Header.End = p.lines.pos
+					doc.Prolog = p.open(p.lines.pos)
+				} else if bytes.HasPrefix(line, []byte("%%+")) {
+					p.continueComment(line)
+				} else if bytes.HasPrefix(line, []byte("%%")) {
+					p.addComment(&doc.Header.Comments, line, start)
+				}
+				continue
+			}
+			inHeader = false
+			doc.Header.End = start
+			doc.Prolog = p.open(start)
+			p.report(p.lines.lineNo, "header not terminated by %%%%EndComments")
+		}
+
+		err = p.comment(line, start)
+		if err != nil {
+			return err
+		}
+	}
+
+	end := p.lines.pos
+	if inHeader {
+		doc.Header.End = end
+		p.report(p.lines.lineNo, "header not terminated by %%%%EndComments")
+	}
+	p.close(end)
+	for _, res := range p.resources {
+		res.End = end
+		p.report(res.line, "%%%%%s not terminated", res.key)
+	}
+	if p.docDepth > 0 {
+		p.report(p.docLine, "%%%%BeginDocument not terminated")
+	}
+	if doc.Prolog != nil && doc.Prolog.Start == doc.Prolog.End {
+		doc.Prolog = nil
+	}
+	if doc.Trailer != nil {
+		resolveAtend(doc.Header.Comments, doc.Trailer.Comments)
+	}
+	doc.Size = end
+	return nil
+}
+
+// comment processes a line of the document body.
+func (p *parser) comment(line []byte, start int64) error {
+	if !bytes.HasPrefix(line, []byte("%%")) {
+		return nil
+	}
+	if bytes.HasPrefix(line, []byte("%%+")) {
+		if p.docDepth == 0 {
+			p.continueComment(line)
+		}
+		return nil
+	}
+	p.last = nil
+
+	key, value := splitComment(line)
+	end := p.lines.pos
+	doc := p.doc
+
+	switch key {
+	case "BeginBinary":
+		n, _ := strconv.ParseInt(value, 10, 64)
+		return p.lines.skip(n)
+	case "BeginData":
+		ff := strings.Fields(value)
+		if len(ff) == 0 {
+			return nil
+		}
+		n, _ := strconv.ParseInt(ff[0], 10, 64)
+		if len(ff) >= 3 && ff[2] == "Lines" {
+			return p.lines.skipLines(n)
+		}
+		return p.lines.skip(n)
+	case "EndBinary", "EndData":
+		return nil
+	}
+
+	if p.docDepth > 0 {
+		switch key {
+		case "BeginDocument":
+			p.docDepth++
+		case "EndDocument":
+			p.docDepth--
+		}
+		// The %%EndDocument comment which ends the outermost embedded
+		// document is recorded, like the matching %%BeginDocument.
+		if p.docDepth == 0 && key == "EndDocument" {
+			p.record(line, start)
+		}
+		return nil
+	}
+
+	switch key {
+	case "BeginDocument":
+		p.docDepth++
+		p.docLine = p.lines.lineNo
+	case "EndDocument":
+		p.report(p.lines.lineNo, "%%%%EndDocument without %%%%BeginDocument")
+	case "BeginDefaults":
+		p.close(start)
+		doc.Defaults = p.open(start)
+		return nil
+	case "EndDefaults":
+		if p.current == doc.Defaults {
+			p.close(end)
+			doc.Prolog = p.open(end)
+		}
+		return nil
+	case "EndProlog":
+		if p.current == doc.Prolog {
+			p.close(end)
+		}
+		return nil
+	case "BeginSetup":
+		p.close(start)
+		doc.Setup = p.open(start)
+		return nil
+	case "EndSetup":
+		if p.current == doc.Setup {
+			p.close(end)
+		}
+		return nil
+	case "Page":
+		p.close(start)
+		page := &Page{Section: Section{Start: start}}
+		page.Label, page.Ordinal = parsePageComment(value)
+		doc.Pages = append(doc.Pages, page)
+		line := p.lines.lineNo
+		if page.Ordinal != len(doc.Pages) {
+			p.report(line, "page ordinal %d, expected %d", page.Ordinal, len(doc.Pages))
+		}
+		if first, seen := p.pageLabels[page.Label]; seen && page.Label != "?" {
+			p.report(line, "duplicate page label %q, first used in line %d", page.Label, first)
+		} else if !seen {
+			p.pageLabels[page.Label] = line
+		}
+		p.current = &page.Section
+		p.page = page
+		p.pageTrailer = -1
+		return nil
+	case "PageTrailer":
+		if p.page != nil {
+			p.pageTrailer = len(p.page.Comments)
+		}
+		return nil
+	case "Trailer":
+		p.close(start)
+		doc.Trailer = p.open(start)
+		return nil
+	case "EOF":
+		p.close(start)
+		return nil
+	case "BeginResource", "BeginFont", "BeginProcSet", "BeginFile":
+		res := &Resource{Section: Section{Start: start}}
+		if key == "BeginResource" {
+			tp, name, _ := strings.Cut(value, " ")
+			res.Type = tp
+			res.Name = strings.TrimSpace(name)
+		} else {
+			res.Type = strings.ToLower(strings.TrimPrefix(key, "Begin"))
+			res.Name = value
+		}
+		doc.Resources = append(doc.Resources, res)
+		p.resources = append(p.resources, openResource{res, key, p.lines.lineNo})
+		return nil
+	case "EndResource", "EndFont", "EndProcSet", "EndFile":
+		n := len(p.resources)
+		if n == 0 {
+			p.report(p.lines.lineNo, "%%%%%s without %%%%Begin%s", key, key[3:])
+			return nil
+		}
+		res := p.resources[n-1]
+		if res.key[5:] != key[3:] {
+			p.report(p.lines.lineNo, "%%%%%s does not match %%%%%s in line %d", key, res.key, res.line)
+		}
+		res.End = end
+		p.resources = p.resources[:n-1]
+		return nil
+	}
+
+	p.record(line, start)
+	return nil
+}
+
+// record adds a comment to the innermost enclosing part of the document.
+func (p *parser) record(line []byte, start int64) {
+	if n := len(p.resources); n > 0 {
+		p.addComment(&p.resources[n-1].Comments, line, start)
+	} else if p.current != nil {
+		p.addComment(&p.current.Comments, line, start)
+	}
+}
+
+// open starts a new section at the given offset.
+func (p *parser) open(start int64) *Section {
+	p.current = &Section{Start: start}
+	return p.current
+}
+
+// close ends the current section at the given offset.
+func (p *parser) close(end int64) {
+	if p.current == nil {
+		return
+	}
+	p.current.End = end
+	p.current = nil
+	p.last = nil
+
+	if p.page != nil {
+		if p.pageTrailer >= 0 {
+			comments := p.page.Comments
+			resolveAtend(comments[:p.pageTrailer], comments[p.pageTrailer:])
+		}
+		p.page = nil
+	}
+}
+
+// addComment appends the comment in line to the given list.
+func (p *parser) addComment(list *[]Comment, line []byte, start int64) {
+	key, value := splitComment(line)
+	*list = append(*list, Comment{Key: key, Value: value, Offset: start, Line: p.lines.lineNo})
+	p.last = list
+}
+
+// report records a violation of the DSC.
+func (p *parser) report(line int, format string, args ...any) {
+	p.problems = append(p.problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// continueComment appends the text of a "%%+" continuation line to the
+// most recent comment.
+func (p *parser) continueComment(line []byte) {
+	if p.last == nil || len(*p.last) == 0 {
+		return
+	}
+	c := &(*p.last)[len(*p.last)-1]
+	c.Value = strings.TrimSpace(c.Value + " " + strings.TrimSpace(string(line[3:])))
+}
+
+// isHeaderLine reports whether line can be part of the header comments.
+// The header ends with the first line which does not start with "%"
+// followed by a printable character.
+func isHeaderLine(line []byte) bool {
+	return len(line) >= 2 && line[0] == '%' && line[1] > ' ' && line[1] < 127
+}
+
+// splitComment splits a DSC comment into keyword and value.
+func splitComment(line []byte) (key, value string) {
+	s := string(line[2:])
+	if key, value, ok := strings.Cut(s, ":"); ok && !strings.ContainsAny(key, " \t") {
+		return key, strings.TrimSpace(value)
+	}
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// parsePageComment parses the value of a %%Page comment.
+func parsePageComment(value string) (label string, ordinal int) {
+	label = value
+	if i := strings.LastIndexAny(value, " \t"); i >= 0 {
+		if n, err := strconv.Atoi(value[i+1:]); err == nil {
+			label = strings.TrimSpace(value[:i])
+			ordinal = n
+		}
+	} else if n, err := strconv.Atoi(value); err == nil {
+		ordinal = n
+	}
+	if len(label) >= 2 && label[0] == '(' && label[len(label)-1] == ')' {
+		label = label[1 : len(label)-1]
+	}
+	return label, ordinal
+}
+
+// resolveAtend replaces comment values "(atend)" by the value of the last
+// comment with the same key in trailer.
+func resolveAtend(comments, trailer []Comment) {
+	for i := range comments {
+		if comments[i].Value != "(atend)" {
+			continue
+		}
+		for j := len(trailer) - 1; j >= 0; j-- {
+			if trailer[j].Key == comments[i].Key {
+				comments[i].Value = trailer[j].Value
+				break
+			}
+		}
+	}
+}
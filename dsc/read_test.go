@@ -0,0 +1,316 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"seehuhn.de/go/geom/rect"
+)
+
+const testDoc = `%!PS-Adobe-3.0
+%%Title: Test
+%%+ Document
+%%BoundingBox: (atend)
+%%Pages: (atend)
+%%EndComments
+%%BeginDefaults
+%%PageMedia: a4
+%%EndDefaults
+%%BeginProlog
+%%BeginResource: procset test 1.0 0
+/x 1 def
+%%EndResource
+%%EndProlog
+%%BeginSetup
+%%IncludeResource: font Times-Roman
+%%EndSetup
+%%Page: (i) 1
+%%PageBoundingBox: (atend)
+0 0 moveto showpage
+%%PageTrailer
+%%PageBoundingBox: 1 2 3 4
+%%Page: 2 2
+%%PageHiResBoundingBox: 0.5 1.5 100.25 200
+showpage
+%%Trailer
+%%BoundingBox: 0 0 612 792
+%%Pages: 2
+%%EOF
+`
+
+func TestRead(t *testing.T) {
+	doc, err := Read(strings.NewReader(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pos returns the offset of the line starting with prefix.
+	pos := func(prefix string) int64 {
+		i := strings.Index(testDoc, "\n"+prefix)
+		if i < 0 {
+			t.Fatalf("%q not found", prefix)
+		}
+		return int64(i + 1)
+	}
+	line := func(prefix string) int {
+		return strings.Count(testDoc[:pos(prefix)], "\n") + 1
+	}
+
+	if doc.Version != "3.0" || doc.Type != "" {
+		t.Errorf("wrong version %q %q", doc.Version, doc.Type)
+	}
+	if doc.Size != int64(len(testDoc)) {
+		t.Errorf("wrong size %d", doc.Size)
+	}
+
+	expectedHeader := Section{
+		Start: 0,
+		End:   pos("%%BeginDefaults"),
+		Comments: []Comment{
+			{Key: "Title", Value: "Test Document", Offset: pos("%%Title"), Line: line("%%Title")},
+			{Key: "BoundingBox", Value: "0 0 612 792", Offset: pos("%%BoundingBox: (atend)"), Line: line("%%BoundingBox: (atend)")},
+			{Key: "Pages", Value: "2", Offset: pos("%%Pages: (atend)"), Line: line("%%Pages: (atend)")},
+		},
+	}
+	if d := cmp.Diff(expectedHeader, doc.Header); d != "" {
+		t.Error(d)
+	}
+	bbox, ok := doc.BoundingBox()
+	if !ok || bbox != (rect.Rect{LLx: 0, LLy: 0, URx: 612, URy: 792}) {
+		t.Errorf("wrong bounding box %v %t", bbox, ok)
+	}
+
+	expectedDefaults := &Section{
+		Start:    pos("%%BeginDefaults"),
+		End:      pos("%%BeginProlog"),
+		Comments: []Comment{{Key: "PageMedia", Value: "a4", Offset: pos("%%PageMedia"), Line: line("%%PageMedia")}},
+	}
+	if d := cmp.Diff(expectedDefaults, doc.Defaults); d != "" {
+		t.Error(d)
+	}
+	expectedProlog := &Section{
+		Start:    pos("%%BeginProlog"),
+		End:      pos("%%BeginSetup"),
+		Comments: []Comment{{Key: "BeginProlog", Offset: pos("%%BeginProlog"), Line: line("%%BeginProlog")}},
+	}
+	if d := cmp.Diff(expectedProlog, doc.Prolog); d != "" {
+		t.Error(d)
+	}
+	expectedSetup := &Section{
+		Start:    pos("%%BeginSetup"),
+		End:      pos("%%Page: (i)"),
+		Comments: []Comment{{Key: "IncludeResource", Value: "font Times-Roman", Offset: pos("%%IncludeResource"), Line: line("%%IncludeResource")}},
+	}
+	if d := cmp.Diff(expectedSetup, doc.Setup); d != "" {
+		t.Error(d)
+	}
+
+	expectedResources := []*Resource{{
+		Type:    "procset",
+		Name:    "test 1.0 0",
+		Section: Section{Start: pos("%%BeginResource"), End: pos("%%EndProlog")},
+	}}
+	if d := cmp.Diff(expectedResources, doc.Resources); d != "" {
+		t.Error(d)
+	}
+
+	if len(doc.Pages) != 2 {
+		t.Fatalf("wrong number of pages %d", len(doc.Pages))
+	}
+	p1, p2 := doc.Pages[0], doc.Pages[1]
+	if p1.Label != "i" || p1.Ordinal != 1 || p2.Label != "2" || p2.Ordinal != 2 {
+		t.Errorf("wrong page labels %q %d %q %d", p1.Label, p1.Ordinal, p2.Label, p2.Ordinal)
+	}
+	if p1.Start != pos("%%Page: (i)") || p1.End != pos("%%Page: 2") ||
+		p2.Start != pos("%%Page: 2") || p2.End != pos("%%Trailer") {
+		t.Errorf("wrong page offsets")
+	}
+	bbox, ok = p1.BoundingBox()
+	if !ok || bbox != (rect.Rect{LLx: 1, LLy: 2, URx: 3, URy: 4}) {
+		t.Errorf("wrong bounding box for page 1: %v %t", bbox, ok)
+	}
+	bbox, ok = p2.BoundingBox()
+	if !ok || bbox != (rect.Rect{LLx: 0.5, LLy: 1.5, URx: 100.25, URy: 200}) {
+		t.Errorf("wrong bounding box for page 2: %v %t", bbox, ok)
+	}
+
+	if doc.Trailer == nil || doc.Trailer.Start != pos("%%Trailer") || doc.Trailer.End != pos("%%EOF") {
+		t.Errorf("wrong trailer %v", doc.Trailer)
+	}
+}
+
+func TestReadLineEndings(t *testing.T) {
+	for _, eol := range []string{"\n", "\r", "\r\n"} {
+		src := strings.ReplaceAll(testDoc, "\n", eol)
+		doc, err := Read(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Pages) != 2 {
+			t.Errorf("%q: wrong number of pages %d", eol, len(doc.Pages))
+			continue
+		}
+		start := int64(strings.Index(src, "%%Page: 2"))
+		if doc.Pages[1].Start != start || doc.Pages[0].End != start {
+			t.Errorf("%q: wrong page offsets", eol)
+		}
+		if doc.Size != int64(len(src)) {
+			t.Errorf("%q: wrong size %d", eol, doc.Size)
+		}
+	}
+}
+
+func TestReadSkipData(t *testing.T) {
+	const src = `%!PS-Adobe-3.0
+%%Pages: 1
+%%EndComments
+%%Page: 1 1
+%%BeginBinary: 18
+%%Page: 2 2
+%%EOF
+%%EndBinary
+%%BeginData: 2 ASCII Lines
+%%Page: 3 3
+%%Trailer
+%%EndData
+%%BeginDocument: embedded.eps
+%%Page: 4 4
+%%BeginDocument: inner.eps
+%%Trailer
+%%EndDocument
+%%Page: 5 5
+%%EndDocument
+%%PageBoundingBox: 0 0 10 10
+%%Trailer
+%%EOF
+`
+	doc, err := Read(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Pages) != 1 {
+		t.Fatalf("wrong number of pages %d", len(doc.Pages))
+	}
+	expected := []Comment{
+		{Key: "BeginDocument", Value: "embedded.eps", Offset: int64(strings.Index(src, "%%BeginDocument: embedded")), Line: 13},
+		{Key: "EndDocument", Offset: int64(strings.LastIndex(src, "%%EndDocument")), Line: 19},
+		{Key: "PageBoundingBox", Value: "0 0 10 10", Offset: int64(strings.Index(src, "%%PageBoundingBox")), Line: 20},
+	}
+	if d := cmp.Diff(expected, doc.Pages[0].Comments); d != "" {
+		t.Error(d)
+	}
+	if doc.Trailer == nil || doc.Trailer.Start != int64(strings.LastIndex(src, "%%Trailer")) {
+		t.Errorf("wrong trailer %v", doc.Trailer)
+	}
+}
+
+func TestReadNoDSC(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"%!\n0 0 moveto\n",
+		"/x 1 def\n%%Page: 1 1\nshowpage",
+	} {
+		doc, err := Read(strings.NewReader(src))
+		if err != nil {
+			t.Errorf("%q: %v", src, err)
+			continue
+		}
+		if doc.Version != "" {
+			t.Errorf("%q: unexpected version %q", src, doc.Version)
+		}
+		if doc.Size != int64(len(src)) {
+			t.Errorf("%q: wrong size %d", src, doc.Size)
+		}
+	}
+
+	doc, _ := Read(strings.NewReader("/x 1 def\n%%Page: 1 1\nshowpage"))
+	if len(doc.Pages) != 1 || doc.Pages[0].End != doc.Size {
+		t.Errorf("wrong pages %v", doc.Pages)
+	}
+	if doc.Prolog == nil || doc.Prolog.Start != 0 || doc.Prolog.End != 9 {
+		t.Errorf("wrong prolog %v", doc.Prolog)
+	}
+}
+
+func TestParsePageComment(t *testing.T) {
+	for _, test := range []struct {
+		value   string
+		label   string
+		ordinal int
+	}{
+		{"1 1", "1", 1},
+		{"(ii) 2", "ii", 2},
+		{"(Chapter 1) 7", "Chapter 1", 7},
+		{"? 3", "?", 3},
+		{"5", "5", 5},
+		{"cover", "cover", 0},
+	} {
+		label, ordinal := parsePageComment(test.value)
+		if label != test.label || ordinal != test.ordinal {
+			t.Errorf("%q: got %q %d", test.value, label, ordinal)
+		}
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	var buf strings.Builder
+	buf.WriteString(testDoc[:strings.Index(testDoc, "%%Page:")])
+	for i := range 1000 {
+		buf.WriteString("%%Page: " + strings.Repeat("x", i%10+1) + " 1\n")
+		buf.WriteString(strings.Repeat("0 0 moveto 100 100 lineto stroke\n", 50))
+	}
+	src := buf.String()
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		Read(strings.NewReader(src))
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	f.Add(testDoc)
+	f.Add("%!PS-Adobe-3.0\r%%Page: 1 1\r\n%%BeginData: 1 Hex Lines\r\n%%EOF")
+	f.Add("%%BeginResource: font\n%%BeginBinary: 100\n")
+	f.Fuzz(func(t *testing.T, src string) {
+		doc, err := Read(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Size != int64(len(src)) {
+			t.Errorf("wrong size %d", doc.Size)
+		}
+		check := func(s *Section) {
+			if s.Start < 0 || s.Start > s.End || s.End > doc.Size {
+				t.Errorf("invalid section %d-%d", s.Start, s.End)
+			}
+		}
+		check(&doc.Header)
+		for _, s := range []*Section{doc.Defaults, doc.Prolog, doc.Setup, doc.Trailer} {
+			if s != nil {
+				check(s)
+			}
+		}
+		for _, page := range doc.Pages {
+			check(&page.Section)
+		}
+		for _, res := range doc.Resources {
+			check(&res.Section)
+		}
+	})
+}
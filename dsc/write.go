@@ -0,0 +1,434 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Options control how Write rearranges the pages of a document.
+type Options struct {
+	// Pages lists the pages to write, as indices into Document.Pages.
+	// Pages can be repeated, and the value -1 denotes a blank page.
+	// If Pages is nil, all pages are written in their original order.
+	Pages []int
+
+	// NUp is the number of pages placed on each output sheet.  The pages
+	// are arranged in a grid and scaled to fit; if this allows for a
+	// larger scale, the pages are rotated by 90 degrees.  The values 0
+	// and 1 leave the pages unchanged.
+	NUp int
+
+	// PageWidth and PageHeight give the size of the input pages in
+	// PostScript points.  If these are zero, the size is taken from the
+	// %%DocumentMedia or %%BoundingBox comment, or A4 paper is assumed.
+	PageWidth, PageHeight float64
+
+	// SheetWidth and SheetHeight give the size of the output sheets for
+	// N-up printing.  If these are zero, the input page size is used.
+	SheetWidth, SheetHeight float64
+
+	// Margin is the width of the blank border around each output sheet for
+	// N-up printing.
+	Margin float64
+}
+
+// Write writes a new DSC-conforming PostScript file to w, which contains
+// the pages of doc selected by opt.  The original file is read from src,
+// which must contain the data from which doc was read.  The header
+// comments, the prolog, the document setup and the trailer are copied to
+// the output.
+func Write(w io.Writer, src io.ReaderAt, doc *Document, opt *Options) error {
+	if opt == nil {
+		opt = &Options{}
+	}
+	if len(doc.Pages) == 0 {
+		return errNoPages
+	}
+	pages := opt.Pages
+	if pages == nil {
+		pages = make([]int, len(doc.Pages))
+		for i := range pages {
+			pages[i] = i
+		}
+	}
+	for _, p := range pages {
+		if p < -1 || p >= len(doc.Pages) {
+			return fmt.Errorf("dsc: invalid page index %d", p)
+		}
+	}
+
+	nup := max(opt.NUp, 1)
+	var cells []nupCell
+	var sheetW, sheetH float64
+	if nup > 1 {
+		pageW, pageH := opt.PageWidth, opt.PageHeight
+		if pageW <= 0 || pageH <= 0 {
+			pageW, pageH = doc.pageSize()
+		}
+		sheetW, sheetH = opt.SheetWidth, opt.SheetHeight
+		if sheetW <= 0 || sheetH <= 0 {
+			sheetW, sheetH = pageW, pageH
+		}
+		cells = imposeLayout(nup, pageW, pageH, sheetW, sheetH, opt.Margin)
+		if cells == nil {
+			return errors.New("dsc: margin too large")
+		}
+	}
+	numSheets := (len(pages) + nup - 1) / nup
+
+	out := bufio.NewWriter(w)
+
+	// header
+	version := "%!PS-Adobe-3.0"
+	if doc.Type != "" && numSheets == 1 && nup == 1 {
+		version += " " + doc.Type
+	}
+	out.WriteString(version + "\n")
+	for _, c := range doc.Header.Comments {
+		if c.Value == "(atend)" || rewrittenComments[c.Key] ||
+			nup > 1 && boundingBoxComments[c.Key] {
+			continue
+		}
+		writeComment(out, c.Key, c.Value)
+	}
+	if nup > 1 {
+		writeComment(out, "BoundingBox", fmt.Sprintf("0 0 %d %d",
+			int(math.Ceil(sheetW)), int(math.Ceil(sheetH))))
+	}
+	writeComment(out, "Pages", strconv.Itoa(numSheets))
+	writeComment(out, "PageOrder", "Ascend")
+	out.WriteString("%%EndComments\n")
+
+	// defaults, prolog and setup
+	pos := doc.Header.End
+	if doc.Defaults != nil {
+		err := copyRange(out, src, pos, doc.Defaults.End)
+		if err != nil {
+			return err
+		}
+		pos = doc.Defaults.End
+	}
+	if nup > 1 {
+		out.WriteString(nupProcSet)
+	}
+	err := copyRange(out, src, pos, doc.Pages[0].Start)
+	if err != nil {
+		return err
+	}
+
+	// pages
+	for sheet := range numSheets {
+		sheetPages := pages[sheet*nup : min((sheet+1)*nup, len(pages))]
+
+		if nup == 1 {
+			p := sheetPages[0]
+			if p < 0 {
+				fmt.Fprintf(out, "%%%%Page: %d %d\nshowpage\n", sheet+1, sheet+1)
+				continue
+			}
+			fmt.Fprintf(out, "%%%%Page: %s %d\n", formatLabel(doc.Pages[p].Label), sheet+1)
+			err := copyPageBody(out, src, doc.Pages[p])
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		fmt.Fprintf(out, "%%%%Page: %d %d\n", sheet+1, sheet+1)
+		for i, p := range sheetPages {
+			if p < 0 {
+				continue
+			}
+			cells[i].writeSetup(out)
+			out.WriteString("userdict /nup@save save put initgraphics\n")
+			err := copyPageBody(out, src, doc.Pages[p])
+			if err != nil {
+				return err
+			}
+			out.WriteString("nup@save restore\n")
+		}
+		out.WriteString("nup@showpage\n")
+	}
+
+	// trailer
+	out.WriteString("%%Trailer\n")
+	if doc.Trailer != nil {
+		err := writeTrailer(out, src, doc.Trailer)
+		if err != nil {
+			return err
+		}
+	}
+	out.WriteString("%%EOF\n")
+
+	return out.Flush()
+}
+
+// rewrittenComments lists the header comments which Write replaces by new
+// values.
+var rewrittenComments = map[string]bool{
+	"Pages":       true,
+	"PageOrder":   true,
+	"EndComments": true,
+}
+
+// boundingBoxComments lists the header comments which describe the page
+// geometry, and which are no longer valid after N-up imposition.
+var boundingBoxComments = map[string]bool{
+	"BoundingBox":      true,
+	"HiResBoundingBox": true,
+	"DocumentMedia":    true,
+}
+
+// nupProcSet is inserted into the prolog of N-up documents.  The
+// procedures redefine the operators which would otherwise escape from the
+// area assigned to a page on the sheet.  Since this code runs before the
+// prolog of the document, procedures bound in the prolog use the new
+// definitions.
+const nupProcSet = `%%BeginResource: procset nup 1.0 0
+userdict begin
+/nup@showpage /showpage load def
+/nup@initgraphics /initgraphics load def
+/nup@initmatrix /initmatrix load def
+/nup@initclip /initclip load def
+/nup@defaultmatrix /defaultmatrix load def
+/nup@matrix matrix def
+/nup@clip {} def
+/showpage {} def
+/initmatrix { nup@initmatrix nup@matrix concat } def
+/initclip { matrix currentmatrix nup@initmatrix nup@initclip nup@clip setmatrix } def
+/initgraphics { nup@initgraphics nup@clip nup@matrix concat } def
+/defaultmatrix { nup@defaultmatrix nup@matrix exch dup concatmatrix } def
+end
+%%EndResource
+`
+
+// nupCell describes where a page is placed on an N-up sheet.
+type nupCell struct {
+	// matrix maps the page to the sheet.
+	matrix [6]float64
+
+	// clip is the area of the page on the sheet, given as
+	// llx, lly, urx, ury.
+	clip [4]float64
+}
+
+// writeSetup writes PostScript code which selects the cell for the next
+// page.
+func (c *nupCell) writeSetup(w *bufio.Writer) {
+	m := c.matrix
+	fmt.Fprintf(w, "userdict /nup@matrix [%s %s %s %s %s %s] put\n",
+		num(m[0]), num(m[1]), num(m[2]), num(m[3]), num(m[4]), num(m[5]))
+	x0, y0, x1, y1 := num(c.clip[0]), num(c.clip[1]), num(c.clip[2]), num(c.clip[3])
+	fmt.Fprintf(w, "userdict /nup@clip { %s %s moveto %s %s lineto %s %s lineto %s %s lineto closepath clip newpath } put\n",
+		x0, y0, x1, y0, x1, y1, x0, y1)
+}
+
+// imposeLayout computes the positions of n pages of size pageW x pageH on a
+// sheet of size sheetW x sheetH.  The grid and orientation are chosen to
+// maximise the size of the pages.  Cells are ordered from left to right
+// and from top to bottom, as seen when the sheet is viewed such that the
+// pages are upright.
+func imposeLayout(n int, pageW, pageH, sheetW, sheetH, margin float64) []nupCell {
+	w, h := sheetW-2*margin, sheetH-2*margin
+	if w <= 0 || h <= 0 || pageW <= 0 || pageH <= 0 {
+		return nil
+	}
+
+	var cols, rows int
+	var rotate bool
+	scale := 0.0
+	for c := 1; c <= n; c++ {
+		if n%c != 0 {
+			continue
+		}
+		r := n / c
+		for _, rot := range []bool{false, true} {
+			cellW, cellH := w/float64(c), h/float64(r)
+			if rot {
+				cellW, cellH = h/float64(c), w/float64(r)
+			}
+			s := min(cellW/pageW, cellH/pageH)
+			if s > scale*(1+1e-9) {
+				scale, cols, rows, rotate = s, c, r, rot
+			}
+		}
+	}
+
+	sw, sh := scale*pageW, scale*pageH
+	cells := make([]nupCell, 0, n)
+	for j := range rows {
+		for i := range cols {
+			var cell nupCell
+			if !rotate {
+				cellW, cellH := w/float64(cols), h/float64(rows)
+				x0 := margin + float64(i)*cellW + (cellW-sw)/2
+				y0 := margin + h - float64(j+1)*cellH + (cellH-sh)/2
+				cell.matrix = [6]float64{scale, 0, 0, scale, x0, y0}
+				cell.clip = [4]float64{x0, y0, x0 + sw, y0 + sh}
+			} else {
+				// The sheet is viewed rotated by 90 degrees clockwise:
+				// columns run along the y-axis of the sheet, and rows
+				// along the x-axis.
+				cellW, cellH := h/float64(cols), w/float64(rows)
+				x1 := margin + float64(j+1)*cellH - (cellH-sh)/2
+				y0 := margin + float64(i)*cellW + (cellW-sw)/2
+				cell.matrix = [6]float64{0, scale, -scale, 0, x1, y0}
+				cell.clip = [4]float64{x1 - sh, y0, x1, y0 + sw}
+			}
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+// pageSize returns the size of the pages of the document.
+func (d *Document) pageSize() (float64, float64) {
+	if val, ok := d.Header.Comment("DocumentMedia"); ok {
+		ff := strings.Fields(val)
+		if len(ff) >= 3 {
+			w, err1 := strconv.ParseFloat(ff[1], 64)
+			h, err2 := strconv.ParseFloat(ff[2], 64)
+			if err1 == nil && err2 == nil && w > 0 && h > 0 {
+				return w, h
+			}
+		}
+	}
+	if bbox, ok := d.BoundingBox(); ok && bbox.URx > 0 && bbox.URy > 0 {
+		return bbox.URx, bbox.URy
+	}
+	return 595, 842 // A4
+}
+
+// writeComment writes a DSC comment.
+func writeComment(w *bufio.Writer, key, value string) {
+	if value == "" {
+		fmt.Fprintf(w, "%%%%%s\n", key)
+	} else {
+		fmt.Fprintf(w, "%%%%%s: %s\n", key, value)
+	}
+}
+
+// writeTrailer copies the trailer, omitting the comments which Write
+// places in the header.
+func writeTrailer(w *bufio.Writer, src io.ReaderAt, trailer *Section) error {
+	data := make([]byte, trailer.End-trailer.Start)
+	_, err := src.ReadAt(data, trailer.Start)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	data = skipLine(data) // %%Trailer
+
+	for len(data) > 0 {
+		line := data
+		rest := skipLine(data)
+		line = line[:len(line)-len(rest)]
+		data = rest
+
+		if bytes.HasPrefix(line, []byte("%%")) {
+			key, _ := splitComment(bytes.TrimRight(line, "\r\n"))
+			if rewrittenComments[key] || boundingBoxComments[key] || key == "EOF" {
+				continue
+			}
+		}
+		w.Write(line)
+	}
+	return nil
+}
+
+// copyPageBody copies the data of a page, without the %%Page comment.
+func copyPageBody(w *bufio.Writer, src io.ReaderAt, page *Page) error {
+	r := bufio.NewReader(io.NewSectionReader(src, page.Start, page.End-page.Start))
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if b == '\n' {
+			break
+		} else if b == '\r' {
+			if next, _ := r.Peek(1); len(next) > 0 && next[0] == '\n' {
+				r.Discard(1)
+			}
+			break
+		}
+	}
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		// The last page of a file may lack the final end-of-line marker.
+		var last [1]byte
+		_, err = src.ReadAt(last[:], page.End-1)
+		if err != nil {
+			return err
+		}
+		if last[0] != '\n' && last[0] != '\r' {
+			w.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+// copyRange copies the bytes from start to end of src to w.
+func copyRange(w io.Writer, src io.ReaderAt, start, end int64) error {
+	if end <= start {
+		return nil
+	}
+	_, err := io.Copy(w, io.NewSectionReader(src, start, end-start))
+	return err
+}
+
+// skipLine returns the data after the first end-of-line marker.
+func skipLine(data []byte) []byte {
+	i := bytes.IndexAny(data, "\r\n")
+	if i < 0 {
+		return nil
+	}
+	if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+		i++
+	}
+	return data[i+1:]
+}
+
+// formatLabel formats a page label for use in a %%Page comment.
+func formatLabel(label string) string {
+	if label == "" || strings.ContainsAny(label, " \t()\\") {
+		return "(" + labelEscaper.Replace(label) + ")"
+	}
+	return label
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+
+// num formats a number for use in PostScript code.
+func num(x float64) string {
+	return strconv.FormatFloat(math.Round(x*1e4)/1e4, 'f', -1, 64)
+}
+
+var errNoPages = errors.New("dsc: document has no pages")
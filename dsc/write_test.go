@@ -0,0 +1,215 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dsc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/postscript"
+)
+
+// pagesDoc is a document with three pages.  Each page prints its name and
+// the position of its origin on the output sheet.
+const pagesDoc = `%!PS-Adobe-3.0
+%%Title: Pages
+%%DocumentMedia: plain 400 600 0 () ()
+%%Pages: (atend)
+%%EndComments
+%%BeginProlog
+/mark-page { = initmatrix 0 0 transform exch cvi = cvi = showpage } bind def
+%%EndProlog
+%%BeginSetup
+%%EndSetup
+%%Page: 1 1
+(page 1) mark-page
+%%Page: 2 2
+(page 2) mark-page
+%%Page: (iii) 3
+(page 3) mark-page
+%%Trailer
+%%Pages: 3
+(done) =
+%%EOF
+`
+
+// pageCounter is a postscript.Device which counts the output pages.
+type pageCounter struct {
+	pages int
+}
+
+func (d *pageCounter) DefaultMatrix() matrix.Matrix                          { return matrix.Identity }
+func (d *pageCounter) Fill(gs *postscript.GraphicsState, evenOdd bool) error { return nil }
+func (d *pageCounter) Stroke(gs *postscript.GraphicsState) error             { return nil }
+func (d *pageCounter) ShowPage() error {
+	d.pages++
+	return nil
+}
+
+// rewrite applies Write to pagesDoc, runs the result, and returns the
+// output of the PostScript code and the number of pages produced.
+func rewrite(t *testing.T, opt *Options) (*Document, string, int) {
+	t.Helper()
+
+	src := strings.NewReader(pagesDoc)
+	doc, err := Read(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	err = Write(buf, src, doc, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := buf.Bytes()
+
+	out, err := Read(bytes.NewReader(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	dev := &pageCounter{}
+	intp := postscript.NewInterpreter()
+	intp.Stdout = stdout
+	intp.Device = dev
+	err = intp.Execute(bytes.NewReader(res))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, res)
+	}
+	return out, stdout.String(), dev.pages
+}
+
+func TestWriteSelect(t *testing.T) {
+	out, stdout, pages := rewrite(t, &Options{Pages: []int{2, 0, -1, 0}})
+
+	expected := "page 3\n0\n0\npage 1\n0\n0\npage 1\n0\n0\ndone\n"
+	if stdout != expected {
+		t.Errorf("wrong output %q", stdout)
+	}
+	if pages != 4 {
+		t.Errorf("wrong number of pages %d", pages)
+	}
+
+	var labels []string
+	for _, page := range out.Pages {
+		labels = append(labels, page.Label)
+	}
+	if d := cmp.Diff([]string{"iii", "1", "3", "1"}, labels); d != "" {
+		t.Error(d)
+	}
+	if val, _ := out.Header.Comment("Pages"); val != "4" {
+		t.Errorf("wrong page count %q", val)
+	}
+	if val, _ := out.Header.Comment("DocumentMedia"); val != "plain 400 600 0 () ()" {
+		t.Errorf("wrong media %q", val)
+	}
+	if _, ok := out.Trailer.Comment("Pages"); ok {
+		t.Error("unexpected %%Pages comment in trailer")
+	}
+}
+
+func TestWriteNUp(t *testing.T) {
+	// Two pages of size 400x600 fit side by side on a rotated 600x800 sheet.
+	out, stdout, pages := rewrite(t, &Options{
+		NUp:         2,
+		SheetWidth:  600,
+		SheetHeight: 800,
+	})
+	expected := "page 1\n600\n0\npage 2\n600\n400\npage 3\n600\n0\ndone\n"
+	if stdout != expected {
+		t.Errorf("wrong output %q", stdout)
+	}
+	if pages != 2 {
+		t.Errorf("wrong number of sheets %d", pages)
+	}
+	if len(out.Pages) != 2 {
+		t.Errorf("wrong number of pages in output %d", len(out.Pages))
+	}
+	if val, _ := out.Header.Comment("BoundingBox"); val != "0 0 600 800" {
+		t.Errorf("wrong bounding box %q", val)
+	}
+
+	// Four pages are shrunk onto a sheet of the original size.
+	_, stdout, pages = rewrite(t, &Options{NUp: 4, Pages: []int{0, 1, -1, 2}})
+	expected = "page 1\n0\n300\npage 2\n200\n300\npage 3\n200\n0\ndone\n"
+	if stdout != expected {
+		t.Errorf("wrong output %q", stdout)
+	}
+	if pages != 1 {
+		t.Errorf("wrong number of sheets %d", pages)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	src := strings.NewReader(pagesDoc)
+	doc, err := Read(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range []*Options{
+		{Pages: []int{3}},
+		{Pages: []int{-2}},
+		{NUp: 2, Margin: 300},
+	} {
+		err := Write(&bytes.Buffer{}, src, doc, opt)
+		if err == nil {
+			t.Errorf("%v: expected error", opt)
+		}
+	}
+
+	empty, _ := Read(strings.NewReader("%!PS-Adobe-3.0\n"))
+	if err := Write(&bytes.Buffer{}, strings.NewReader(""), empty, nil); err != errNoPages {
+		t.Errorf("expected %v, got %v", errNoPages, err)
+	}
+}
+
+func TestParsePageRanges(t *testing.T) {
+	for _, test := range []struct {
+		spec     string
+		expected []int
+	}{
+		{"1", []int{0}},
+		{"1-3", []int{0, 1, 2}},
+		{"-", []int{0, 1, 2, 3, 4}},
+		{"4-", []int{3, 4}},
+		{"-2", []int{0, 1}},
+		{"_1-1", []int{4, 3, 2, 1, 0}},
+		{"_2", []int{3}},
+		{"1,1,_,2", []int{0, 0, -1, 1}},
+		{" 2 , 5-4 ", []int{1, 4, 3}},
+	} {
+		got, err := ParsePageRanges(test.spec, 5)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if d := cmp.Diff(test.expected, got); d != "" {
+			t.Errorf("%q: %s", test.spec, d)
+		}
+	}
+
+	for _, spec := range []string{"", "0", "6", "_6", "a", "1-x", "1,,2", "1-2-3"} {
+		_, err := ParsePageRanges(spec, 5)
+		if err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
@@ -16,8 +16,6 @@
 
 package postscript
 
-import "io"
-
 func eexec(intp *Interpreter) error {
 	if len(intp.Stack) < 1 {
 		return &postScriptError{eStackunderflow, "eexec"}
@@ -35,13 +33,29 @@ func eexec(intp *Interpreter) error {
 	if err != nil {
 		return err
 	}
-	err = intp.executeScanner(s)
-	if err != nil && err != io.EOF {
+
+	// The encrypted part of the file is executed by a new fileFrame for
+	// the same scanner.  Once the end of the encrypted section is reached,
+	// the eexecFrame switches the scanner back to plain text.
+	err = intp.pushFrame(&eexecFrame{s: s, dictDepth: k})
+	if err != nil {
 		return err
 	}
-	s.EndEexec()
+	return intp.pushFile(s, nil)
+}
 
-	intp.DictStack = intp.DictStack[:k]
+// eexecFrame ends the encrypted section of a file started by `eexec`.
+type eexecFrame struct {
+	s         *scanner
+	dictDepth int
+}
+
+func (f *eexecFrame) step(intp *Interpreter) error {
+	intp.popFrame()
+	f.s.EndEexec()
+	if len(intp.DictStack) > f.dictDepth {
+		intp.DictStack = intp.DictStack[:f.dictDepth]
+	}
 	return nil
 }
 
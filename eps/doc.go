@@ -0,0 +1,25 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package eps reads Encapsulated PostScript (EPS) files.
+//
+// Both plain EPS files and files with the binary header used by DOS EPS
+// files are supported.  In the latter case, the TIFF and Windows Metafile
+// previews are extracted together with the PostScript code.
+//
+// The EPS format is documented in Adobe technical note #5002,
+// "Encapsulated PostScript File Format Specification".
+package eps
@@ -0,0 +1,131 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package eps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"seehuhn.de/go/geom/rect"
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/dsc"
+)
+
+// File is an Encapsulated PostScript file.
+type File struct {
+	// PostScript is the PostScript code of the file.
+	PostScript []byte
+
+	// TIFF and WMF are the TIFF and Windows Metafile previews from the
+	// binary header of a DOS EPS file, or nil if there is no such preview.
+	TIFF []byte
+	WMF  []byte
+
+	// BBox is the bounding box of the graphics, in default user space.
+	// The value is taken from the %%HiResBoundingBox comment if present,
+	// and from the %%BoundingBox comment otherwise.  HasBBox is false if
+	// the file gives no valid bounding box.
+	BBox    rect.Rect
+	HasBBox bool
+
+	// DSC describes the DSC structure of the PostScript code.
+	DSC *dsc.Document
+}
+
+// Read reads an EPS file.
+func Read(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	if bytes.HasPrefix(data, dosMagic) {
+		err = f.parseDOSHeader(data)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		f.PostScript = data
+	}
+	if !bytes.HasPrefix(f.PostScript, []byte("%!")) {
+		return nil, ErrNotEPS
+	}
+
+	f.DSC, err = dsc.Read(bytes.NewReader(f.PostScript))
+	if err != nil {
+		return nil, err
+	}
+	f.BBox, f.HasBBox = f.DSC.BoundingBox()
+
+	return f, nil
+}
+
+// Execute runs the PostScript code of the file in the interpreter.
+func (f *File) Execute(intp *postscript.Interpreter) error {
+	return intp.Execute(bytes.NewReader(f.PostScript))
+}
+
+// dosMagic identifies the binary header of DOS EPS files.
+var dosMagic = []byte{0xC5, 0xD0, 0xD3, 0xC6}
+
+// dosHeaderSize is the length of the binary header of DOS EPS files.
+const dosHeaderSize = 30
+
+// parseDOSHeader extracts the sections of a DOS EPS file.
+// The header consists of the magic number, followed by offset and length
+// of the PostScript code, the WMF preview and the TIFF preview, and a
+// checksum.  All values are stored in little-endian byte order.  The
+// checksum is not verified.
+func (f *File) parseDOSHeader(data []byte) error {
+	if len(data) < dosHeaderSize {
+		return ErrInvalidHeader
+	}
+
+	var sections [3][]byte
+	for i := range sections {
+		offs := binary.LittleEndian.Uint32(data[4+8*i:])
+		length := binary.LittleEndian.Uint32(data[8+8*i:])
+		if offs == 0 || length == 0 {
+			continue
+		}
+		if uint64(offs)+uint64(length) > uint64(len(data)) || offs < dosHeaderSize {
+			return ErrInvalidHeader
+		}
+		sections[i] = data[offs : offs+length]
+	}
+	if sections[0] == nil {
+		return ErrInvalidHeader
+	}
+
+	f.PostScript = sections[0]
+	f.WMF = sections[1]
+	f.TIFF = sections[2]
+	return nil
+}
+
+var (
+	// ErrNotEPS is returned by Read if the file does not contain
+	// PostScript code.
+	ErrNotEPS = errors.New("eps: not an EPS file")
+
+	// ErrInvalidHeader is returned by Read if the binary header of a DOS
+	// EPS file is malformed.
+	ErrInvalidHeader = errors.New("eps: invalid DOS EPS header")
+)
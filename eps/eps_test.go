@@ -0,0 +1,150 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package eps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"seehuhn.de/go/geom/rect"
+	"seehuhn.de/go/postscript"
+)
+
+const testEPS = `%!PS-Adobe-3.0 EPSF-3.0
+%%BoundingBox: 10 20 100 200
+%%HiResBoundingBox: 10.5 20.25 99.5 199.75
+%%EndComments
+1 2 add
+%%EOF
+`
+
+// dosEPS returns a DOS EPS file with the given sections.
+func dosEPS(ps, wmf, tiff []byte) []byte {
+	header := make([]byte, dosHeaderSize)
+	copy(header, dosMagic)
+	offs := uint32(dosHeaderSize)
+	for i, section := range [][]byte{ps, wmf, tiff} {
+		if section == nil {
+			continue
+		}
+		binary.LittleEndian.PutUint32(header[4+8*i:], offs)
+		binary.LittleEndian.PutUint32(header[8+8*i:], uint32(len(section)))
+		offs += uint32(len(section))
+	}
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFF)
+
+	res := header
+	for _, section := range [][]byte{ps, wmf, tiff} {
+		res = append(res, section...)
+	}
+	return res
+}
+
+func TestReadPlain(t *testing.T) {
+	f, err := Read(bytes.NewReader([]byte(testEPS)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(f.PostScript) != testEPS {
+		t.Errorf("wrong PostScript code %q", f.PostScript)
+	}
+	if f.TIFF != nil || f.WMF != nil {
+		t.Error("unexpected preview")
+	}
+	expected := rect.Rect{LLx: 10.5, LLy: 20.25, URx: 99.5, URy: 199.75}
+	if !f.HasBBox || f.BBox != expected {
+		t.Errorf("wrong bounding box %v", f.BBox)
+	}
+	if f.DSC.Type != "EPSF-3.0" {
+		t.Errorf("wrong type %q", f.DSC.Type)
+	}
+}
+
+func TestReadDOS(t *testing.T) {
+	tiff := []byte("II*\x00 tiff preview")
+	wmf := []byte("\xd7\xcd\xc6\x9a wmf preview")
+	ps := []byte("%!PS-Adobe-3.0 EPSF-3.0\n%%BoundingBox: 0 0 10 (atend)\n%%EndComments\n3 4 mul\n%%Trailer\n%%BoundingBox: 1 2 3 4\n")
+
+	f, err := Read(bytes.NewReader(dosEPS(ps, wmf, tiff)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(f.PostScript, ps) || !bytes.Equal(f.TIFF, tiff) || !bytes.Equal(f.WMF, wmf) {
+		t.Error("wrong sections")
+	}
+	if f.HasBBox {
+		t.Errorf("unexpected bounding box %v", f.BBox)
+	}
+
+	ps = []byte("%!PS-Adobe-3.0 EPSF-3.0\n%%BoundingBox: (atend)\n%%EndComments\n3 4 mul\n%%Trailer\n%%BoundingBox: 1 2 3 4\n")
+	f, err = Read(bytes.NewReader(dosEPS(ps, nil, tiff)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.WMF != nil || !bytes.Equal(f.TIFF, tiff) {
+		t.Error("wrong previews")
+	}
+	expected := rect.Rect{LLx: 1, LLy: 2, URx: 3, URy: 4}
+	if !f.HasBBox || f.BBox != expected {
+		t.Errorf("wrong bounding box %v", f.BBox)
+	}
+
+	intp := postscript.NewInterpreter()
+	intp.CheckStart = true
+	err = f.Execute(intp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intp.Stack) != 1 || intp.Stack[0] != postscript.Integer(12) {
+		t.Errorf("wrong stack %v", intp.Stack)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	valid := dosEPS([]byte(testEPS), nil, nil)
+
+	outOfRange := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(outOfRange[8:], uint32(len(testEPS)+1))
+
+	overlapsHeader := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(overlapsHeader[4:], 4)
+
+	noPostScript := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(noPostScript[8:], 0)
+
+	notPostScript := dosEPS([]byte("hello"), nil, nil)
+
+	for i, test := range []struct {
+		data     []byte
+		expected error
+	}{
+		{valid[:20], ErrInvalidHeader},
+		{outOfRange, ErrInvalidHeader},
+		{overlapsHeader, ErrInvalidHeader},
+		{noPostScript, ErrInvalidHeader},
+		{notPostScript, ErrNotEPS},
+		{[]byte("hello"), ErrNotEPS},
+		{nil, ErrNotEPS},
+	} {
+		_, err := Read(bytes.NewReader(test.data))
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%d: expected %v, got %v", i, test.expected, err)
+		}
+	}
+}
@@ -19,14 +19,155 @@ package postscript
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"slices"
+	"sync"
 )
 
 var defaultErrorHandler = builtin(defaultErrorHandlerFn)
 
+// defaultErrorHandlerFn is the error handler used for all errors in the
+// initial errordict.  As described in section 3.11 of the PostScript
+// Language Reference Manual, the handler records the error in $error and
+// then executes `stop`.
 func defaultErrorHandlerFn(intp *Interpreter) error {
-	return intp.errors[len(intp.errors)-1]
+	e := intp.errors[len(intp.errors)-1]
+
+	var command Object
+	if len(intp.Stack) > 0 {
+		command = intp.Stack[len(intp.Stack)-1]
+		intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	}
+	dstack := make(Array, len(intp.DictStack))
+	for i, d := range intp.DictStack {
+		dstack[i] = d
+	}
+
+	errorState := intp.SystemDict["$error"].(Dict)
+	for _, key := range []Name{"newerror", "errorname", "command", "ostack", "estack", "dstack"} {
+		intp.saveDictEntry(errorState, key)
+	}
+	errorState["newerror"] = Boolean(true)
+	errorState["errorname"] = e.tp
+	errorState["command"] = command
+	errorState["ostack"] = Array(slices.Clone(intp.Stack))
+	errorState["estack"] = Array(intp.execStackObjects())
+	errorState["dstack"] = dstack
+	intp.lastError = e
+
+	return errStop
+}
+
+// defaultHandleerror is the initial value of `handleerror` in errordict.
+// If an error has been recorded in $error, a report is written to
+// intp.Stdout.
+func defaultHandleerror(intp *Interpreter) error {
+	errorState := intp.SystemDict["$error"].(Dict)
+	if errorState["newerror"] != Boolean(true) {
+		return nil
+	}
+	intp.saveDictEntry(errorState, "newerror")
+	errorState["newerror"] = Boolean(false)
+
+	if intp.Stdout != nil {
+		name, _ := errorState["errorname"].(Name)
+		command := commandName(errorState["command"])
+		_, err := fmt.Fprintf(intp.Stdout, "%%%%[ Error: %s; OffendingCommand: %s ]%%%%\n", string(name), command)
+		if err != nil {
+			return intp.e(eIoerror, "handleerror: %v", err)
+		}
+	}
+	return nil
 }
 
+func bHandleerror(intp *Interpreter) error {
+	handler, ok := intp.ErrorDict[eHandleerror]
+	if !ok {
+		return nil
+	}
+	return intp.execObject(handler, true)
+}
+
+// handleError passes a PostScript error to the corresponding procedure in
+// errordict.  The offending command is pushed onto the operand stack before
+// the procedure is executed.  Other errors are returned unchanged.
+func (intp *Interpreter) handleError(err error, command Object) error {
+	e, ok := err.(*postScriptError)
+	if !ok || e == ErrExecutionLimitExceeded {
+		// The execution limit must not be caught by `stopped`.
+		return err
+	}
+	handler, ok := intp.ErrorDict[e.tp]
+	if !ok {
+		return err
+	}
+	level := len(intp.errors)
+	if level >= maxErrorDepth {
+		return err
+	}
+
+	// The errorFrame restores the state once the handler has finished.
+	// This frame is not subject to the limit for the execution stack,
+	// since the handler for execstackoverflow must be able to run.
+	intp.estack = append(intp.estack, &errorFrame{level: level, procStart: intp.procStart})
+	intp.errors = append(intp.errors, e)
+
+	// Errors can occur while a procedure body is being scanned, for
+	// example for undefined immediately evaluated names.  The handler is
+	// executed, rather than added to the procedure.
+	intp.procStart = nil
+
+	intp.Stack = append(intp.Stack, command)
+	return intp.execObject(handler, true)
+}
+
+// maxErrorDepth is the maximal nesting depth of error handlers.
+const maxErrorDepth = 5
+
+// commandName returns the name of an operator, for use in error reports.
+func commandName(command Object) string {
+	switch command := command.(type) {
+	case Operator:
+		return string(command)
+	case Name:
+		return string(command)
+	case builtin:
+		if name, ok := builtinNames()[reflect.ValueOf(command).Pointer()]; ok {
+			return string(name)
+		}
+		return "--unknown--"
+	case *GoOperator:
+		return string(command.name)
+	default:
+		return fmt.Sprint(command)
+	}
+}
+
+// builtinNames returns a map from the built-in operators to their names in
+// systemdict.
+func builtinNames() map[uintptr]Name {
+	builtinNamesOnce.Do(func() {
+		builtinNamesMap = make(map[uintptr]Name)
+		for name, val := range makeSystemDict() {
+			b, ok := val.(builtin)
+			if !ok {
+				continue
+			}
+			// If an operator has several names, use the first one.
+			ptr := reflect.ValueOf(b).Pointer()
+			if old, seen := builtinNamesMap[ptr]; !seen || name < old {
+				builtinNamesMap[ptr] = name
+			}
+		}
+	})
+	return builtinNamesMap
+}
+
+var (
+	builtinNamesOnce sync.Once
+	builtinNamesMap  map[uintptr]Name
+)
+
 func (intp *Interpreter) e(tp Name, format string, a ...any) error {
 	return &postScriptError{tp, fmt.Sprintf(format, a...)}
 }
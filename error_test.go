@@ -0,0 +1,160 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStopped(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected []Object
+	}{
+		{"{1} stopped", []Object{Integer(1), Boolean(false)}},
+		{"{1 stop 2} stopped", []Object{Integer(1), Boolean(true)}},
+		{"{1 (a) add} stopped", []Object{Integer(1), String("a"), Boolean(true)}},
+		{"{undefinedname} stopped", []Object{Boolean(true)}},
+		{"{{stop} stopped {3} if 4} stopped", []Object{Integer(3), Integer(4), Boolean(false)}},
+		{"{3 {stop} repeat} stopped", []Object{Boolean(true)}},
+		{"{3 {1 exit} repeat} stopped", []Object{Integer(1), Boolean(false)}},
+		{"{1 0 1 {pop stop} for} stopped", []Object{Boolean(true)}},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if d := cmp.Diff(intp.Stack, test.expected); d != "" {
+			t.Errorf("%q: %s", test.code, d)
+		}
+	}
+}
+
+func TestErrorState(t *testing.T) {
+	intp, err := run(`
+		/d 1 dict def d begin
+		{ 7 (x) add } stopped pop pop pop
+		end
+		$error /newerror get
+		$error /errorname get
+		$error /command get
+		$error /ostack get
+		$error /dstack get length`, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{
+		Boolean(true),
+		eTypecheck,
+		Operator("add"),
+		Array{Integer(7), String("x")},
+		Integer(3),
+	}
+	if d := cmp.Diff(intp.Stack, expected); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestErrorHandlerOperand(t *testing.T) {
+	// Error handlers are called with the offending command on the stack.
+	// If the handler returns normally, execution continues.
+	intp, err := run(`
+		errordict /undefined { [ /caught 3 -1 roll ] } put
+		foo 42`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Array{Name("caught"), Operator("foo")}, Integer(42)}
+	if d := cmp.Diff(intp.Stack, expected); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestHandleerror(t *testing.T) {
+	buf := &bytes.Buffer{}
+	intp := NewInterpreter()
+	intp.Stdout = buf
+	err := intp.ExecuteString("1 2 3 foo 4")
+	var psErr *postScriptError
+	if !errors.As(err, &psErr) || psErr.tp != eUndefined {
+		t.Fatalf("expected undefined, got %v", err)
+	}
+	expected := "%%[ Error: undefined; OffendingCommand: foo ]%%\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("wrong report %q", got)
+	}
+	if intp.SystemDict["$error"].(Dict)["newerror"] != Boolean(false) {
+		t.Error("newerror not reset")
+	}
+
+	// After the error, the interpreter can be used again.
+	buf.Reset()
+	err = intp.ExecuteString("stop")
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("unexpected report %q", buf.String())
+	}
+}
+
+func TestCustomHandleerror(t *testing.T) {
+	buf := &bytes.Buffer{}
+	intp := NewInterpreter()
+	intp.Stdout = buf
+	err := intp.ExecuteString(`
+		errordict /handleerror { userdict /reported $error /errorname get put } put
+		{ 1 0 idiv } bind exec`)
+	if err == nil {
+		t.Fatal("error not returned")
+	}
+	if buf.Len() > 0 {
+		t.Errorf("default handler was used: %q", buf.String())
+	}
+	if got := intp.UserDict["reported"]; got != eUndefinedresult {
+		t.Errorf("reported %v, expected undefinedresult", got)
+	}
+}
+
+func TestFailingHandleerror(t *testing.T) {
+	for _, test := range []struct {
+		handler string
+		failure string
+	}{
+		{"{ 1 0 idiv }", "undefinedresult"},
+		{"{ stop }", "stop outside stopped context"},
+		{"{ exit }", "invalidexit"},
+	} {
+		intp := NewInterpreter()
+		intp.Stdout = &bytes.Buffer{}
+		err := intp.ExecuteString("errordict /handleerror " + test.handler + " put nosuchname")
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eUndefined {
+			t.Errorf("%s: expected undefined, got %v", test.handler, err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "handleerror failed: "+test.failure) {
+			t.Errorf("%s: handler failure not reported: %v", test.handler, err)
+		}
+	}
+}
@@ -0,0 +1,556 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"io"
+	"maps"
+	"slices"
+)
+
+// The interpreter keeps all pending work on an explicit execution stack.
+// Procedures, files and the looping operators are represented by frames
+// on this stack, and the main loop in [Interpreter.run] repeatedly lets
+// the topmost frame perform one step.  Since PostScript procedure calls do
+// not recurse through Go function calls, the nesting depth of PostScript
+// code is only limited by [Interpreter.MaxExecStack].
+//
+// The control flow operators `exit` and `stop`, as well as `closefile` on
+// the current file, are signalled by the errors errExit, errStop and
+// io.EOF.  These unwind the execution stack to the nearest looping
+// context, `stopped` context, or file, respectively.
+
+// An execFrame is an entry on the execution stack.
+type execFrame interface {
+	// step performs the next step of the computation represented by the
+	// frame.  Once the computation is complete, the frame removes itself
+	// from the execution stack using popFrame.
+	step(intp *Interpreter) error
+}
+
+// A frameCloser is an execFrame which needs to release resources when it
+// is removed from the execution stack, either after completing normally
+// or by unwinding.
+type frameCloser interface {
+	close(intp *Interpreter)
+}
+
+// A visibleFrame is an execFrame which is shown by `execstack`.
+// Frames which do not implement this interface are internal to the
+// interpreter.
+type visibleFrame interface {
+	object() Object
+}
+
+// A looper is the execFrame of a looping operator, which can be
+// terminated by `exit`.
+type looper interface {
+	isLoop()
+}
+
+// defaultMaxExecStack is the limit for the depth of the execution stack,
+// if Interpreter.MaxExecStack is not set.
+const defaultMaxExecStack = 5000
+
+// maxRunDepth is the maximal nesting depth of calls to run.  Nested calls
+// occur where Go code needs the result of a PostScript computation, for
+// example when executing the BuildGlyph procedure of a Type 3 font.
+const maxRunDepth = 100
+
+// pushFrame adds a new frame to the top of the execution stack.
+func (intp *Interpreter) pushFrame(f execFrame) error {
+	limit := intp.MaxExecStack
+	if limit <= 0 {
+		limit = defaultMaxExecStack
+	}
+	if len(intp.estack) >= limit {
+		return intp.e(eExecstackoverflow, "exec stack overflow")
+	}
+	intp.estack = append(intp.estack, f)
+	return nil
+}
+
+// popFrame removes the topmost frame from the execution stack.
+func (intp *Interpreter) popFrame() {
+	n := len(intp.estack) - 1
+	f := intp.estack[n]
+	intp.estack[n] = nil
+	intp.estack = intp.estack[:n]
+	if c, ok := f.(frameCloser); ok {
+		c.close(intp)
+	}
+}
+
+// run executes the frames on the execution stack, until the height of the
+// stack has dropped to base.  Control flow signals which cannot be
+// resolved above base, and errors which are not handled by errordict, are
+// returned to the caller.
+func (intp *Interpreter) run(base int) error {
+	if intp.runDepth >= maxRunDepth {
+		return intp.unwind(base, intp.e(eExecstackoverflow, "exec stack overflow"))
+	}
+	intp.runDepth++
+	defer func() { intp.runDepth-- }()
+
+	for len(intp.estack) > base {
+		err := intp.estack[len(intp.estack)-1].step(intp)
+		if err != nil {
+			err = intp.unwind(base, err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unwind removes frames from the execution stack, in response to an
+// error returned by a frame.  If the error is resolved above base, nil is
+// returned.
+func (intp *Interpreter) unwind(base int, err error) error {
+	switch err {
+	case nil:
+		return nil
+
+	case errStop:
+		for len(intp.estack) > base {
+			_, isStopped := intp.estack[len(intp.estack)-1].(*stoppedFrame)
+			intp.popFrame()
+			if isStopped {
+				intp.Stack = append(intp.Stack, Boolean(true))
+				return nil
+			}
+		}
+		return errStop
+
+	case errExit:
+		for i := len(intp.estack) - 1; i >= base; i-- {
+			switch intp.estack[i].(type) {
+			case looper:
+				for len(intp.estack) > i {
+					intp.popFrame()
+				}
+				return nil
+			case *stoppedFrame, *fileFrame:
+				err = intp.handleError(intp.e(eInvalidexit, "exit outside loop"), Operator("exit"))
+				return intp.unwind(base, err)
+			}
+		}
+		return errExit
+
+	case io.EOF:
+		for len(intp.estack) > base {
+			_, isFile := intp.estack[len(intp.estack)-1].(*fileFrame)
+			intp.popFrame()
+			if isFile {
+				return nil
+			}
+		}
+		return io.EOF
+
+	default:
+		for len(intp.estack) > base {
+			intp.popFrame()
+		}
+		return err
+	}
+}
+
+// execute executes obj and returns when the execution is complete.  This
+// is used where Go code needs the result of a PostScript computation.
+func (intp *Interpreter) execute(obj Object) error {
+	base := len(intp.estack)
+	err := intp.pushFrame(&objectFrame{obj: obj})
+	if err != nil {
+		return err
+	}
+	return intp.run(base)
+}
+
+// execObject executes a single object.  If direct is false, the object
+// was encountered while executing a procedure or a file; in this case,
+// procedures are pushed onto the operand stack instead of being executed.
+func (intp *Interpreter) execObject(obj Object, direct bool) error {
+	if len(intp.Stack) > maxOperandStackDepth {
+		return intp.handleError(intp.e(eStackoverflow, "operand stack overflow"), obj)
+	}
+
+	if obj == Operator("}") {
+		if len(intp.procStart) == 0 {
+			return intp.handleError(intp.e(eSyntaxerror, "unmatched '}'"), obj)
+		}
+		a := intp.procStart[len(intp.procStart)-1]
+		intp.procStart = intp.procStart[:len(intp.procStart)-1]
+		b := len(intp.Stack)
+		if err := intp.charge((b - a) * objectSize); err != nil {
+			return err
+		}
+		proc := make(Procedure, b-a)
+		copy(proc, intp.Stack[a:])
+		intp.Stack = append(intp.Stack[:a], proc)
+		return nil
+	} else if obj == Operator("{") {
+		intp.procStart = append(intp.procStart, len(intp.Stack))
+		return nil
+	} else if len(intp.procStart) > 0 {
+		intp.Stack = append(intp.Stack, obj)
+		return nil
+	}
+
+	// command is the name used to invoke the current object, if any
+	var command Object
+
+	for {
+		intp.NumOps++
+		if intp.MaxOps > 0 && intp.NumOps > intp.MaxOps {
+			return ErrExecutionLimitExceeded
+		}
+
+		switch o := obj.(type) {
+		case Operator:
+			// obj is used instead of o, to avoid converting the name
+			// into an interface value again.
+			val, err := intp.load(obj)
+			if err != nil {
+				return intp.handleError(err, obj)
+			}
+			command = obj
+			obj = val
+			direct = true
+			continue
+
+		case builtin:
+			err := o(intp)
+			if err != nil {
+				if command == nil {
+					command = o
+				}
+				return intp.handleError(err, command)
+			}
+
+		case *GoOperator:
+			err := intp.callOperator(o)
+			if err != nil {
+				if command == nil {
+					command = o
+				}
+				return intp.handleError(err, command)
+			}
+
+		case Procedure:
+			if !direct {
+				intp.Stack = append(intp.Stack, o)
+			} else if len(o) > 0 {
+				var err error
+				if intp.accessOf(o) == accessNone {
+					err = intp.e(eInvalidaccess, "cannot execute noaccess procedure")
+				} else {
+					err = intp.pushProc(o)
+				}
+				if err != nil {
+					if command == nil {
+						command = o
+					}
+					return intp.handleError(err, command)
+				}
+			}
+
+		default:
+			intp.Stack = append(intp.Stack, o)
+		}
+		return nil
+	}
+}
+
+// execStackObjects returns the contents of the execution stack, as shown
+// by `execstack`.  The bottom of the stack comes first.
+func (intp *Interpreter) execStackObjects() []Object {
+	var res []Object
+	for _, f := range intp.estack {
+		if v, ok := f.(visibleFrame); ok {
+			res = append(res, v.object())
+		}
+	}
+	return res
+}
+
+func bCountexecstack(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Integer(len(intp.execStackObjects())))
+	return nil
+}
+
+func bExecstack(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "execstack: not enough arguments")
+	}
+	a, ok := intp.Stack[len(intp.Stack)-1].(Array)
+	if !ok {
+		return intp.e(eTypecheck, "execstack: needs an array, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	if err := intp.checkWrite("execstack", a); err != nil {
+		return err
+	}
+	objs := intp.execStackObjects()
+	if len(a) < len(objs) {
+		return intp.e(eRangecheck, "execstack: array too short")
+	}
+	intp.saveElems(a[:len(objs)])
+	n := copy(a, objs)
+	intp.Stack[len(intp.Stack)-1] = a[:n]
+	return nil
+}
+
+// objectFrame executes a single object.
+type objectFrame struct {
+	obj Object
+}
+
+func (f *objectFrame) step(intp *Interpreter) error {
+	intp.popFrame()
+	return intp.execObject(f.obj, true)
+}
+
+// procFrame executes the elements of a procedure.
+type procFrame struct {
+	proc Procedure
+	pos  int
+}
+
+// pushProc starts executing a procedure.  To reduce allocations, frames
+// of finished procedures are re-used.
+func (intp *Interpreter) pushProc(proc Procedure) error {
+	var f *procFrame
+	if n := len(intp.freeProcs); n > 0 {
+		f = intp.freeProcs[n-1]
+		intp.freeProcs = intp.freeProcs[:n-1]
+	} else {
+		f = &procFrame{}
+	}
+	f.proc, f.pos = proc, 0
+	err := intp.pushFrame(f)
+	if err != nil {
+		intp.freeProcs = append(intp.freeProcs, f)
+	}
+	return err
+}
+
+// step executes the elements of the procedure, until either the procedure
+// is finished or a new frame has been pushed onto the execution stack.
+func (f *procFrame) step(intp *Interpreter) error {
+	for {
+		obj := f.proc[f.pos]
+		f.pos++
+		if f.pos == len(f.proc) {
+			// The frame is removed before the last element is executed,
+			// so that tail calls do not grow the execution stack.
+			intp.popFrame()
+			f.proc = nil
+			intp.freeProcs = append(intp.freeProcs, f)
+			return intp.execObject(obj, false)
+		}
+		err := intp.execObject(obj, false)
+		if err != nil || intp.estack[len(intp.estack)-1] != execFrame(f) {
+			return err
+		}
+	}
+}
+
+func (f *procFrame) object() Object {
+	return f.proc[f.pos:]
+}
+
+// fileFrame executes the tokens read from a file.
+type fileFrame struct {
+	s *scanner
+
+	// file is the file object shown by `execstack`.  This is nil for the
+	// input passed to Execute.
+	file Object
+}
+
+// pushFile starts executing the tokens read from s.
+func (intp *Interpreter) pushFile(s *scanner, file Object) error {
+	err := intp.pushFrame(&fileFrame{s: s, file: file})
+	if err != nil {
+		return err
+	}
+	intp.scanners = append(intp.scanners, s)
+	return nil
+}
+
+func (f *fileFrame) step(intp *Interpreter) error {
+	o, err := f.s.ScanToken()
+	if err == io.EOF {
+		intp.popFrame()
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch o.(type) {
+	case immediateName, binarySequence:
+		var ok bool
+		o, ok, err = intp.resolveImmediate(o)
+		if err != nil || !ok {
+			return err
+		}
+	}
+	if seq, ok := o.(binarySequence); ok {
+		// Binary object sequences are executed immediately, except
+		// inside procedure bodies.
+		if len(intp.procStart) == 0 {
+			return intp.execObject(Procedure(seq), true)
+		}
+		o = Procedure(seq)
+	}
+	return intp.execObject(o, false)
+}
+
+func (f *fileFrame) close(intp *Interpreter) {
+	intp.scanners = intp.scanners[:len(intp.scanners)-1]
+}
+
+func (f *fileFrame) object() Object {
+	return f.file
+}
+
+// stoppedFrame marks the context established by `stopped`.
+type stoppedFrame struct{}
+
+func (f *stoppedFrame) step(intp *Interpreter) error {
+	intp.popFrame()
+	intp.Stack = append(intp.Stack, Boolean(false))
+	return nil
+}
+
+func (f *stoppedFrame) object() Object {
+	return builtin(bStopped)
+}
+
+// errorFrame restores the interpreter state after an error handler from
+// errordict has finished.
+type errorFrame struct {
+	level     int
+	procStart []int
+}
+
+func (f *errorFrame) step(intp *Interpreter) error {
+	intp.popFrame()
+	return nil
+}
+
+func (f *errorFrame) close(intp *Interpreter) {
+	intp.errors = intp.errors[:f.level]
+	intp.procStart = f.procStart
+}
+
+// forFrame implements the `for` operator.
+type forFrame struct {
+	val, inc, limit Integer
+	proc            Object
+}
+
+func (f *forFrame) step(intp *Interpreter) error {
+	if f.inc > 0 && f.val > f.limit || f.inc < 0 && f.val < f.limit {
+		intp.popFrame()
+		return nil
+	}
+	intp.Stack = append(intp.Stack, f.val)
+	f.val += f.inc
+	return intp.execObject(f.proc, true)
+}
+
+func (f *forFrame) object() Object { return builtin(bFor) }
+func (f *forFrame) isLoop()        {}
+
+// repeatFrame implements the `repeat` operator.
+type repeatFrame struct {
+	count Integer
+	proc  Object
+}
+
+func (f *repeatFrame) step(intp *Interpreter) error {
+	if f.count <= 0 {
+		intp.popFrame()
+		return nil
+	}
+	f.count--
+	return intp.execObject(f.proc, true)
+}
+
+func (f *repeatFrame) object() Object { return builtin(bRepeat) }
+func (f *repeatFrame) isLoop()        {}
+
+// loopFrame implements the `loop` operator.
+type loopFrame struct {
+	proc Object
+}
+
+func (f *loopFrame) step(intp *Interpreter) error {
+	return intp.execObject(f.proc, true)
+}
+
+func (f *loopFrame) object() Object { return builtin(bLoop) }
+func (f *loopFrame) isLoop()        {}
+
+// forallFrame implements the `forall` operator.
+type forallFrame struct {
+	obj  Object // an Array, String or Dict
+	keys []Name // the keys of a Dict, in the order they are visited
+	pos  int
+	proc Object
+}
+
+func newForallFrame(obj Object, proc Object) *forallFrame {
+	f := &forallFrame{obj: obj, proc: proc}
+	if d, ok := obj.(Dict); ok {
+		f.keys = slices.Collect(maps.Keys(d))
+	}
+	return f
+}
+
+func (f *forallFrame) step(intp *Interpreter) error {
+	switch obj := f.obj.(type) {
+	case Array:
+		if f.pos < len(obj) {
+			intp.Stack = append(intp.Stack, obj[f.pos])
+			f.pos++
+			return intp.execObject(f.proc, true)
+		}
+	case String:
+		if f.pos < len(obj) {
+			intp.Stack = append(intp.Stack, Integer(obj[f.pos]))
+			f.pos++
+			return intp.execObject(f.proc, true)
+		}
+	case Dict:
+		for f.pos < len(f.keys) {
+			key := f.keys[f.pos]
+			f.pos++
+			// entries removed by the procedure are skipped
+			if val, ok := obj[key]; ok {
+				intp.Stack = append(intp.Stack, key, val)
+				return intp.execObject(f.proc, true)
+			}
+		}
+	}
+	intp.popFrame()
+	return nil
+}
+
+func (f *forallFrame) object() Object { return builtin(bForall) }
+func (f *forallFrame) isLoop()        {}
@@ -0,0 +1,139 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const recursiveCount = `
+	/count { dup 0 gt { 1 sub count 1 add } if } def
+`
+
+func TestDeepRecursion(t *testing.T) {
+	intp, err := run(recursiveCount+"2000 count", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 2000)
+	if len(intp.estack) != 0 {
+		t.Errorf("execution stack not empty: %d frames", len(intp.estack))
+	}
+}
+
+func TestMaxExecStack(t *testing.T) {
+	intp := NewInterpreter()
+	intp.MaxExecStack = 50
+	err := intp.ExecuteString(recursiveCount + "100 count")
+	var psErr *postScriptError
+	if !errors.As(err, &psErr) || psErr.tp != eExecstackoverflow {
+		t.Errorf("expected execstackoverflow, got %v", err)
+	}
+	if len(intp.estack) != 0 {
+		t.Errorf("execution stack not empty: %d frames", len(intp.estack))
+	}
+
+	// The interpreter can still be used after the error.
+	intp.Stack = intp.Stack[:0]
+	err = intp.ExecuteString("20 count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 20)
+}
+
+func TestTailCalls(t *testing.T) {
+	// Calls in tail position do not grow the execution stack.
+	intp := NewInterpreter()
+	intp.MaxExecStack = 10
+	err := intp.ExecuteString("/loop { dup 0 gt { 1 sub loop } if } def 1000 loop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 0)
+}
+
+func TestExecStack(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected string
+	}{
+		{"countexecstack =", "1\n"},
+		{"{ countexecstack } exec =", "1\n"},
+		{"{ countexecstack = } exec", "2\n"},
+		{"/p { 5 array execstack == 1 pop } def p", "[null {== 1 pop}]\n"},
+		{"{ 5 array execstack == exit } loop", "[null --loop-- {== exit}]\n"},
+		{"{ 5 array execstack == } stopped pop", "[null --stopped-- {==}]\n"},
+		{"1 { 5 array execstack == } repeat", "[null --repeat-- {==}]\n"},
+	} {
+		buf := &bytes.Buffer{}
+		intp := NewInterpreter()
+		intp.Stdout = buf
+		err := intp.ExecuteString(test.code)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if got := buf.String(); got != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.code, got, test.expected)
+		}
+	}
+}
+
+func TestExecStackErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"0 array execstack", eRangecheck},
+		{"5 execstack", eTypecheck},
+		{"execstack", eStackunderflow},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
+
+func TestExitStopped(t *testing.T) {
+	// `exit` cannot leave a `stopped` context.
+	intp, err := run("1 { { exit } stopped $error /errorname get exit } repeat", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Boolean(true), Name(eInvalidexit)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestErrorEstack(t *testing.T) {
+	intp, err := run("{ 1 (a) add } stopped pop pop pop $error /estack get length", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := intp.Stack[0].(Integer); !ok || n < 1 {
+		t.Errorf("wrong estack length %v", intp.Stack[0])
+	}
+}
@@ -0,0 +1,26 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package extract finds the fonts and other resources embedded in
+// PostScript documents.
+//
+// Resources are found in two ways.  [FromDSC] copies the resources which
+// are enclosed in %%BeginResource and %%EndResource comments, without
+// executing the document.  [Capture] executes the document and records
+// every instance passed to `definefont` or `defineresource`.  Type 1 fonts
+// found in this way are written using [type1.Font.Write], and CMaps using
+// [postscript.WriteCMap].  [Extract] combines both methods.
+package extract
@@ -0,0 +1,231 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package extract
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"strings"
+
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/dsc"
+	"seehuhn.de/go/postscript/type1"
+)
+
+// Resource is a resource found in a PostScript document.
+type Resource struct {
+	// Category is the resource category, for example "Font", "CMap" or
+	// "ProcSet".
+	Category string
+
+	// Name is the name of the resource.
+	Name string
+
+	// Data is PostScript code which defines the resource, or nil if the
+	// resource cannot be written as PostScript code.
+	Data []byte
+
+	// Instance is the value of the resource, for resources found by
+	// Capture.  For resources found by FromDSC, Instance is nil.
+	Instance postscript.Object
+}
+
+// Extract finds the resources in a PostScript document.
+//
+// The resources enclosed in DSC resource comments are returned first,
+// followed by the resources which are defined when the document is
+// executed but which are not enclosed in DSC comments.  If execution of
+// the document fails, the resources found so far are returned together
+// with the error.
+func Extract(r io.Reader) ([]*Resource, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := dsc.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	res, err := FromDSC(bytes.NewReader(data), doc)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range res {
+		seen[r.Category+"/"+r.Name] = true
+	}
+	captured, err := Capture(postscript.NewInterpreter(), bytes.NewReader(data))
+	for _, r := range captured {
+		if !seen[r.Category+"/"+r.Name] {
+			res = append(res, r)
+		}
+	}
+	return res, err
+}
+
+// FromDSC returns the resources which are enclosed in %%BeginResource and
+// %%EndResource comments, or in the older %%BeginFont, %%BeginProcSet and
+// %%BeginFile comments.  The data of each resource is copied from r,
+// without the enclosing comments.
+func FromDSC(r io.ReaderAt, doc *dsc.Document) ([]*Resource, error) {
+	var res []*Resource
+	for _, dr := range doc.Resources {
+		body := make([]byte, dr.End-dr.Start)
+		_, err := r.ReadAt(body, dr.Start)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		body = stripComments(body)
+
+		category, ok := dscCategories[dr.Type]
+		if !ok {
+			category = dr.Type
+		}
+		res = append(res, &Resource{
+			Category: category,
+			Name:     resourceName(dr.Name),
+			Data:     body,
+		})
+	}
+	return res, nil
+}
+
+// dscCategories maps the resource types used in DSC comments to the
+// corresponding resource categories.
+var dscCategories = map[string]string{
+	"cidfont":  "CIDFont",
+	"cmap":     "CMap",
+	"encoding": "Encoding",
+	"file":     "File",
+	"font":     "Font",
+	"form":     "Form",
+	"pattern":  "Pattern",
+	"procset":  "ProcSet",
+}
+
+// stripComments removes the first line, and the last line if this is a
+// DSC comment starting with "%%End", from the data of a resource.
+func stripComments(body []byte) []byte {
+	if i := bytes.IndexAny(body, "\r\n"); i >= 0 {
+		if body[i] == '\r' && i+1 < len(body) && body[i+1] == '\n' {
+			i++
+		}
+		body = body[i+1:]
+	} else {
+		return nil
+	}
+
+	trimmed := bytes.TrimRight(body, "\r\n")
+	lastLine := trimmed[bytes.LastIndexAny(trimmed, "\r\n")+1:]
+	if bytes.HasPrefix(lastLine, []byte("%%End")) {
+		body = body[:len(trimmed)-len(lastLine)]
+	}
+	return body
+}
+
+// resourceName extracts the name of a resource from the value of a DSC
+// resource comment, omitting any version information.  Parentheses around
+// the name are removed.
+func resourceName(val string) string {
+	if rest, ok := strings.CutPrefix(val, "("); ok {
+		if name, _, ok := strings.Cut(rest, ")"); ok {
+			return name
+		}
+	}
+	name, _, _ := strings.Cut(val, " ")
+	return name
+}
+
+// Capture executes a PostScript document and returns the resources which
+// are defined using `definefont` or `defineresource`.  Resources are
+// returned in the order in which they are first defined; later definitions
+// with the same category and name are ignored.  Since every definition is
+// recorded, this also finds resources which are removed again, for
+// example by `restore`.
+//
+// Type 1 fonts and CMaps are converted to PostScript code.  For other
+// resources, the Data field is nil.  If execution of the document fails,
+// the resources found so far are returned together with the error.
+func Capture(intp *postscript.Interpreter, r io.Reader) ([]*Resource, error) {
+	var res []*Resource
+	seen := make(map[string]bool)
+
+	prev := intp.DefineResource
+	intp.DefineResource = func(category, key postscript.Name, instance postscript.Object) {
+		if prev != nil {
+			prev(category, key, instance)
+		}
+		id := string(category) + "/" + string(key)
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		res = append(res, &Resource{
+			Category: string(category),
+			Name:     string(key),
+			Data:     encode(category, key, instance),
+			Instance: instance,
+		})
+	}
+	defer func() { intp.DefineResource = prev }()
+
+	err := intp.Execute(r)
+	return res, err
+}
+
+// encode returns PostScript code which defines the given resource, or nil
+// if the resource cannot be converted.
+func encode(category, key postscript.Name, instance postscript.Object) []byte {
+	d, ok := instance.(postscript.Dict)
+	if !ok {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	switch category {
+	case "Font":
+		if tp, _ := d["FontType"].(postscript.Integer); tp != 1 {
+			return nil
+		}
+		font, err := type1.FromDict(d)
+		if err != nil {
+			return nil
+		}
+		if font.FontName == "" {
+			font.FontName = string(key)
+		}
+		err = font.Write(buf, nil)
+		if err != nil {
+			return nil
+		}
+	case "CMap":
+		if _, ok := d["CMapName"].(postscript.Name); !ok {
+			d = maps.Clone(d)
+			d["CMapName"] = key
+		}
+		err := postscript.WriteCMap(buf, d)
+		if err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,191 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package extract
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/dsc"
+	"seehuhn.de/go/postscript/type1"
+)
+
+// testFont returns a Type 1 font program for a font with the given name.
+func testFont(t *testing.T, name string) []byte {
+	t.Helper()
+	encoding := make([]string, 256)
+	for i := range encoding {
+		encoding[i] = ".notdef"
+	}
+	encoding['A'] = "A"
+	F := &type1.Font{
+		FontInfo: &type1.FontInfo{
+			FontName:   name,
+			FontMatrix: matrix.Matrix{0.001, 0, 0, 0.001, 0, 0},
+		},
+		Outlines: &type1.Outlines{
+			Private:  &type1.PrivateDict{BlueScale: 0.039625, BlueShift: 7, BlueFuzz: 1},
+			Glyphs:   map[string]*type1.Glyph{},
+			Encoding: encoding,
+		},
+	}
+	F.NewGlyph(".notdef", 250)
+	g := F.NewGlyph("A", 500)
+	g.MoveTo(0, 0)
+	g.LineTo(500, 0)
+	g.LineTo(250, 700)
+	g.ClosePath()
+
+	buf := &bytes.Buffer{}
+	err := F.Write(buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const testCMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CMapName /Test-H def
+/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> def
+1 begincodespacerange <00> <FF> endcodespacerange
+1 begincidrange <20> <7E> 1 endcidrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end
+`
+
+func testDocument(t *testing.T) string {
+	t.Helper()
+	return "%!PS-Adobe-3.0\n" +
+		"%%DocumentNeededResources: procset Test-Procs\n" +
+		"%%EndComments\n" +
+		"%%BeginProlog\n" +
+		"%%BeginResource: procset (Test-Procs) 1.0 0\n" +
+		"/Test-Procs 5 dict dup begin /x { 1 } def end /ProcSet defineresource pop\n" +
+		"%%EndResource\n" +
+		"%%BeginFont: DSC-Font\r\n" +
+		string(testFont(t, "DSC-Font")) +
+		"%%EndFont\r\n" +
+		"%%EndProlog\n" +
+		"%%Page: 1 1\n" +
+		"save\n" +
+		string(testFont(t, "Hidden-Font")) +
+		testCMap +
+		"/Hidden-Font 12 selectfont 10 10 moveto (A) show\n" +
+		"restore\n" +
+		"showpage\n" +
+		"%%EOF\n"
+}
+
+func TestFromDSC(t *testing.T) {
+	src := testDocument(t)
+	doc, err := dsc.Read(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := FromDSC(strings.NewReader(src), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(res))
+	}
+
+	if res[0].Category != "ProcSet" || res[0].Name != "Test-Procs" {
+		t.Errorf("wrong resource %s/%s", res[0].Category, res[0].Name)
+	}
+	expected := "/Test-Procs 5 dict dup begin /x { 1 } def end /ProcSet defineresource pop\n"
+	if string(res[0].Data) != expected {
+		t.Errorf("wrong data %q", res[0].Data)
+	}
+
+	if res[1].Category != "Font" || res[1].Name != "DSC-Font" {
+		t.Errorf("wrong resource %s/%s", res[1].Category, res[1].Name)
+	}
+	if !bytes.Equal(res[1].Data, testFont(t, "DSC-Font")) {
+		t.Errorf("wrong font data")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	res, err := Extract(strings.NewReader(testDocument(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, r := range res {
+		names = append(names, r.Category+"/"+r.Name)
+	}
+	expected := []string{"ProcSet/Test-Procs", "Font/DSC-Font", "Font/Hidden-Font", "CMap/Test-H"}
+	if d := cmp.Diff(expected, names); d != "" {
+		t.Fatal(d)
+	}
+
+	font, err := type1.Read(bytes.NewReader(res[2].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if font.FontName != "Hidden-Font" || len(font.Glyphs) != 2 {
+		t.Errorf("wrong font %q with %d glyphs", font.FontName, len(font.Glyphs))
+	}
+	if _, ok := res[2].Instance.(postscript.Dict); !ok {
+		t.Errorf("wrong instance %T", res[2].Instance)
+	}
+
+	cmap, info, err := postscript.ReadCMap(bytes.NewReader(res[3].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmap["CMapName"] != postscript.Name("Test-H") || len(info.CidRanges) != 1 {
+		t.Errorf("wrong CMap %v", info)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	var hooked []postscript.Name
+	intp := postscript.NewInterpreter()
+	intp.DefineResource = func(category, key postscript.Name, instance postscript.Object) {
+		hooked = append(hooked, key)
+	}
+	res, err := Capture(intp, strings.NewReader(`
+		/A 1 /ProcSet defineresource pop
+		/A 2 /ProcSet defineresource pop
+		/F << /FontType 3 >> definefont pop
+		undefined-operator`))
+	if err == nil {
+		t.Error("expected error")
+	}
+	if len(res) != 2 || res[0].Instance != postscript.Integer(1) || res[1].Data != nil {
+		t.Errorf("wrong resources %v", res)
+	}
+	// The previous hook is still called, and is restored afterwards.
+	if d := cmp.Diff([]postscript.Name{"A", "A", "F"}, hooked); d != "" {
+		t.Error(d)
+	}
+	if intp.DefineResource == nil {
+		t.Error("hook not restored")
+	}
+}
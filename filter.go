@@ -0,0 +1,671 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// file is an input file created by the `filter` operator.  The current
+// file, as returned by `currentfile`, is represented by nil.
+type file struct {
+	s *scanner
+}
+
+// close discards all remaining input of the file.
+func (f *file) close() {
+	f.s.peek = nil
+	f.s.pos = f.s.used
+	if f.s.err == nil {
+		f.s.err = io.EOF
+	}
+}
+
+// byteSource is the data source of a filter.  All sources implement
+// io.ByteReader, so that filters never read beyond their end-of-data
+// marker.
+type byteSource interface {
+	io.Reader
+	io.ByteReader
+}
+
+// fileScanner returns the scanner which reads from the file object obj.
+func (intp *Interpreter) fileScanner(op string, obj Object) (*scanner, error) {
+	switch obj := obj.(type) {
+	case nil:
+		if len(intp.scanners) == 0 {
+			return nil, intp.e(eIoerror, "%s: no current file", op)
+		}
+		return intp.scanners[len(intp.scanners)-1], nil
+	case *file:
+		return obj.s, nil
+	default:
+		return nil, intp.e(eTypecheck, "%s: needs a file, not %T", op, obj)
+	}
+}
+
+func bFilter(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "filter: not enough arguments")
+	}
+	name, ok := intp.Stack[len(intp.Stack)-1].(Name)
+	if !ok {
+		return intp.e(eTypecheck, "filter: invalid filter name")
+	}
+
+	// The number of operands used, including the filter name and the source.
+	numArgs := 2
+	var params Dict
+	if d, ok := intp.Stack[len(intp.Stack)-2].(Dict); ok {
+		if err := intp.checkRead("filter", d); err != nil {
+			return err
+		}
+		params = d
+		numArgs = 3
+	} else if name == "SubFileDecode" {
+		if len(intp.Stack) < 4 {
+			return intp.e(eStackunderflow, "filter: not enough arguments")
+		}
+		params = Dict{
+			"EODCount":  intp.Stack[len(intp.Stack)-3],
+			"EODString": intp.Stack[len(intp.Stack)-2],
+		}
+		numArgs = 4
+	}
+	if len(intp.Stack) < numArgs {
+		return intp.e(eStackunderflow, "filter: not enough arguments")
+	}
+
+	src, err := intp.filterSource(intp.Stack[len(intp.Stack)-numArgs])
+	if err != nil {
+		return err
+	}
+
+	var dec io.Reader
+	switch name {
+	case "ASCIIHexDecode":
+		dec = &asciiHexDecoder{src: src}
+	case "ASCII85Decode":
+		dec = &ascii85Decoder{src: src}
+	case "RunLengthDecode":
+		dec = &runLengthDecoder{src: src}
+	case "LZWDecode":
+		earlyChange := Integer(1)
+		if val, ok := params["EarlyChange"]; ok {
+			earlyChange, ok = val.(Integer)
+			if !ok {
+				return intp.e(eTypecheck, "filter: invalid EarlyChange")
+			} else if earlyChange != 0 && earlyChange != 1 {
+				return intp.e(eRangecheck, "filter: invalid EarlyChange %d", earlyChange)
+			}
+		}
+		if err := intp.checkPredictor(params); err != nil {
+			return err
+		}
+		dec = newLZWDecoder(src, int(earlyChange))
+	case "FlateDecode":
+		if err := intp.checkPredictor(params); err != nil {
+			return err
+		}
+		dec = &flateDecoder{src: src}
+	case "SubFileDecode":
+		count, ok := params["EODCount"].(Integer)
+		if !ok {
+			return intp.e(eTypecheck, "filter: invalid EODCount")
+		} else if count < 0 {
+			return intp.e(eRangecheck, "filter: negative EODCount")
+		}
+		eod, ok := params["EODString"].(String)
+		if !ok {
+			return intp.e(eTypecheck, "filter: invalid EODString")
+		}
+		dec = newSubFileDecoder(src, int(count), bytes.Clone(eod))
+	default:
+		return intp.e(eUndefined, "filter: unsupported filter %s", name)
+	}
+
+	f := &file{s: newScanner(dec)}
+	if err := intp.charge(len(f.s.buf)); err != nil {
+		return err
+	}
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-numArgs], f)
+	return nil
+}
+
+// filterSource returns a byteSource which reads data from the file, string
+// or procedure obj.
+func (intp *Interpreter) filterSource(obj Object) (byteSource, error) {
+	switch obj := obj.(type) {
+	case nil, *file:
+		return intp.fileScanner("filter", obj)
+	case String:
+		if err := intp.checkRead("filter", obj); err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(obj), nil
+	case Procedure:
+		return &procSource{intp: intp, proc: obj}, nil
+	default:
+		return nil, intp.e(eTypecheck, "filter: invalid data source %T", obj)
+	}
+}
+
+// checkPredictor verifies that no predictor function is requested in the
+// parameters of an LZWDecode or FlateDecode filter.
+func (intp *Interpreter) checkPredictor(params Dict) error {
+	val, ok := params["Predictor"]
+	if !ok {
+		return nil
+	}
+	predictor, ok := val.(Integer)
+	if !ok {
+		return intp.e(eTypecheck, "filter: invalid Predictor")
+	} else if predictor != 1 {
+		return intp.e(eRangecheck, "filter: predictor %d not supported", predictor)
+	}
+	return nil
+}
+
+// procSource reads data from a procedure.  The procedure is called each
+// time more data is needed, and must return a string.  An empty string
+// indicates the end of the data.
+type procSource struct {
+	intp *Interpreter
+	proc Procedure
+	buf  []byte
+	eof  bool
+}
+
+func (p *procSource) Read(buf []byte) (int, error) {
+	for len(p.buf) == 0 {
+		if p.eof {
+			return 0, io.EOF
+		}
+		if err := p.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(buf, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
+
+func (p *procSource) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := p.Read(buf[:])
+	return buf[0], err
+}
+
+func (p *procSource) fill() error {
+	intp := p.intp
+	err := intp.execute(p.proc)
+	if err != nil {
+		return err
+	}
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "filter: data source procedure returned no data")
+	}
+	s, ok := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "filter: data source procedure returned %T", intp.Stack[len(intp.Stack)-1])
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+
+	// The procedure may re-use the string, so the data must be copied.
+	p.buf = bytes.Clone(s)
+	p.eof = len(s) == 0
+	return nil
+}
+
+// readSource reads the next byte from a filter source.  I/O errors from
+// the underlying reader are converted into ioerror.
+func readSource(src byteSource) (byte, error) {
+	b, err := src.ReadByte()
+	if err != nil && err != io.EOF && err != errStop && err != errExit {
+		if _, ok := err.(*postScriptError); !ok {
+			err = &postScriptError{eIoerror, err.Error()}
+		}
+	}
+	return b, err
+}
+
+// asciiHexDecoder implements the ASCIIHexDecode filter.
+type asciiHexDecoder struct {
+	src byteSource
+	eod bool
+}
+
+func (d *asciiHexDecoder) Read(p []byte) (int, error) {
+	n := 0
+	var digits [2]byte
+	for n < len(p) && !d.eod {
+		k := 0
+		for k < 2 {
+			b, err := readSource(d.src)
+			if err == io.EOF {
+				d.eod = true
+				break
+			} else if err != nil {
+				return n, err
+			}
+			switch {
+			case b == '>':
+				d.eod = true
+			case class[b] == space:
+				continue
+			case b >= '0' && b <= '9':
+				digits[k] = b - '0'
+			case b >= 'A' && b <= 'F':
+				digits[k] = b - 'A' + 10
+			case b >= 'a' && b <= 'f':
+				digits[k] = b - 'a' + 10
+			default:
+				return n, &postScriptError{eIoerror, fmt.Sprintf("ASCIIHexDecode: invalid character %q", b)}
+			}
+			if d.eod {
+				break
+			}
+			k++
+		}
+		switch k {
+		case 1: // an odd number of digits is padded with 0
+			p[n] = digits[0] << 4
+			n++
+		case 2:
+			p[n] = digits[0]<<4 | digits[1]
+			n++
+		}
+	}
+	if n == 0 && d.eod {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// ascii85Decoder implements the ASCII85Decode filter.
+type ascii85Decoder struct {
+	src byteSource
+	out []byte
+	eod bool
+}
+
+func (d *ascii85Decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.eod {
+			return 0, io.EOF
+		}
+		if err := d.decodeGroup(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// decodeGroup decodes the next group of up to five characters.
+func (d *ascii85Decoder) decodeGroup() error {
+	var val uint64
+	k := 0
+	for k < 5 {
+		b, err := readSource(d.src)
+		if err == io.EOF {
+			d.eod = true
+			break
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case class[b] == space:
+			continue
+		case b == 'z' && k == 0:
+			d.out = append(d.out[:0], 0, 0, 0, 0)
+			return nil
+		case b == '~':
+			b, err = readSource(d.src)
+			if err != nil && err != io.EOF {
+				return err
+			} else if err == io.EOF || b != '>' {
+				return &postScriptError{eIoerror, "ASCII85Decode: invalid end-of-data marker"}
+			}
+			d.eod = true
+		case b >= '!' && b <= 'u':
+			val = val*85 + uint64(b-'!')
+			k++
+			continue
+		default:
+			return &postScriptError{eIoerror, fmt.Sprintf("ASCII85Decode: invalid character %q", b)}
+		}
+		break
+	}
+
+	switch k {
+	case 0:
+		return nil
+	case 1:
+		return &postScriptError{eIoerror, "ASCII85Decode: unexpected end of data"}
+	}
+	for i := k; i < 5; i++ {
+		val = val*85 + 84
+	}
+	if val > 0xFFFFFFFF {
+		return &postScriptError{eIoerror, "ASCII85Decode: invalid group"}
+	}
+	d.out = append(d.out[:0], byte(val>>24), byte(val>>16), byte(val>>8), byte(val))
+	d.out = d.out[:k-1]
+	return nil
+}
+
+// runLengthDecoder implements the RunLengthDecode filter.
+type runLengthDecoder struct {
+	src byteSource
+	out []byte
+	eod bool
+}
+
+func (d *runLengthDecoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.eod {
+			return 0, io.EOF
+		}
+		length, err := readSource(d.src)
+		if err == io.EOF {
+			d.eod = true
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		switch {
+		case length == 128:
+			d.eod = true
+		case length < 128:
+			d.out = d.out[:0]
+			for range int(length) + 1 {
+				b, err := readSource(d.src)
+				if err == io.EOF {
+					return 0, &postScriptError{eIoerror, "RunLengthDecode: unexpected end of data"}
+				} else if err != nil {
+					return 0, err
+				}
+				d.out = append(d.out, b)
+			}
+		default:
+			b, err := readSource(d.src)
+			if err == io.EOF {
+				return 0, &postScriptError{eIoerror, "RunLengthDecode: unexpected end of data"}
+			} else if err != nil {
+				return 0, err
+			}
+			d.out = append(d.out[:0], bytes.Repeat([]byte{b}, 257-int(length))...)
+		}
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// lzwDecoder implements the LZWDecode filter.
+type lzwDecoder struct {
+	src         byteSource
+	earlyChange int
+
+	bits  uint32
+	nBits int
+	width int
+
+	table [][]byte
+	prev  []byte
+	out   []byte
+	eod   bool
+}
+
+const (
+	lzwClear    = 256
+	lzwEOD      = 257
+	lzwMaxCodes = 4096
+)
+
+func newLZWDecoder(src byteSource, earlyChange int) *lzwDecoder {
+	d := &lzwDecoder{
+		src:         src,
+		earlyChange: earlyChange,
+		table:       make([][]byte, 258, lzwMaxCodes),
+	}
+	for i := range 256 {
+		d.table[i] = []byte{byte(i)}
+	}
+	d.reset()
+	return d
+}
+
+func (d *lzwDecoder) reset() {
+	d.table = d.table[:258]
+	d.width = 9
+	d.prev = nil
+}
+
+func (d *lzwDecoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.eod {
+			return 0, io.EOF
+		}
+		if err := d.decodeCode(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// decodeCode reads and decodes the next code.
+func (d *lzwDecoder) decodeCode() error {
+	for d.nBits < d.width {
+		b, err := readSource(d.src)
+		if err == io.EOF {
+			d.eod = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+		d.bits = d.bits<<8 | uint32(b)
+		d.nBits += 8
+	}
+	code := int(d.bits>>(d.nBits-d.width)) & (1<<d.width - 1)
+	d.nBits -= d.width
+
+	var entry []byte
+	switch {
+	case code == lzwClear:
+		d.reset()
+		return nil
+	case code == lzwEOD:
+		d.eod = true
+		return nil
+	case code < len(d.table):
+		entry = d.table[code]
+	case code == len(d.table) && d.prev != nil:
+		entry = append(d.prev[:len(d.prev):len(d.prev)], d.prev[0])
+	default:
+		return &postScriptError{eIoerror, fmt.Sprintf("LZWDecode: invalid code %d", code)}
+	}
+
+	if d.prev != nil && len(d.table) < lzwMaxCodes {
+		d.table = append(d.table, append(d.prev[:len(d.prev):len(d.prev)], entry[0]))
+	}
+	if len(d.table)+d.earlyChange >= 1<<d.width && d.width < 12 {
+		d.width++
+	}
+	d.prev = entry
+	d.out = entry
+	return nil
+}
+
+// flateDecoder implements the FlateDecode filter.  The zlib reader is
+// created on the first read, so that the header is not read when the
+// filter is created.
+type flateDecoder struct {
+	src byteSource
+	r   io.ReadCloser
+}
+
+func (d *flateDecoder) Read(p []byte) (int, error) {
+	if d.r == nil {
+		r, err := zlib.NewReader(d.src)
+		if err != nil {
+			return 0, flateError(err)
+		}
+		d.r = r
+	}
+	n, err := d.r.Read(p)
+	return n, flateError(err)
+}
+
+// flateError converts errors from the zlib reader into ioerror.
+func flateError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	var psErr *postScriptError
+	if errors.As(err, &psErr) {
+		return psErr
+	}
+	return &postScriptError{eIoerror, "FlateDecode: " + err.Error()}
+}
+
+// subFileDecoder implements the SubFileDecode filter.
+//
+// If eod is empty, the filter passes through count bytes of data.
+// Otherwise, the filter passes through data up to the (count+1)th
+// occurrence of eod.  The final occurrence of eod is consumed but not
+// passed through.  If both count and eod are zero, all data up to the end
+// of the source is passed through.
+type subFileDecoder struct {
+	src       byteSource
+	count     int
+	unlimited bool
+	eod       []byte
+
+	// fail is the failure function of the Knuth-Morris-Pratt algorithm
+	// for eod.
+	fail []int
+
+	// match is the length of the prefix of eod which matches the most
+	// recent input.  These bytes have not been passed through yet.
+	match int
+
+	out  []byte
+	done bool
+}
+
+func newSubFileDecoder(src byteSource, count int, eod []byte) *subFileDecoder {
+	d := &subFileDecoder{
+		src:       src,
+		count:     count,
+		unlimited: count == 0,
+		eod:       eod,
+		fail:      make([]int, len(eod)),
+	}
+	k := 0
+	for i := 1; i < len(eod); i++ {
+		for k > 0 && eod[i] != eod[k] {
+			k = d.fail[k-1]
+		}
+		if eod[i] == eod[k] {
+			k++
+		}
+		d.fail[i] = k
+	}
+	return d
+}
+
+func (d *subFileDecoder) Read(p []byte) (int, error) {
+	if len(d.eod) == 0 {
+		return d.readCount(p)
+	}
+
+	for len(d.out) == 0 && !d.done {
+		b, err := readSource(d.src)
+		if err == io.EOF {
+			d.out = append(d.out, d.eod[:d.match]...)
+			d.done = true
+			break
+		} else if err != nil {
+			return 0, err
+		}
+
+		for {
+			if b == d.eod[d.match] {
+				d.match++
+				break
+			} else if d.match == 0 {
+				d.out = append(d.out, b)
+				break
+			}
+			k := d.fail[d.match-1]
+			d.out = append(d.out, d.eod[:d.match-k]...)
+			d.match = k
+		}
+
+		if d.match == len(d.eod) {
+			d.match = 0
+			if d.count == 0 {
+				d.done = true
+			} else {
+				d.count--
+				d.out = append(d.out, d.eod...)
+			}
+		}
+	}
+	if len(d.out) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// readCount implements the SubFileDecode filter for an empty EODString.
+func (d *subFileDecoder) readCount(p []byte) (int, error) {
+	if d.done {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && (d.unlimited || d.count > 0) {
+		b, err := readSource(d.src)
+		if err == io.EOF {
+			d.done = true
+			break
+		} else if err != nil {
+			return n, err
+		}
+		p[n] = b
+		n++
+		d.count--
+	}
+	if !d.unlimited && d.count <= 0 {
+		d.done = true
+	}
+	if n == 0 && d.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,177 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterStrings(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected string
+	}{
+		{"(48 65 6c6C 6F>) /ASCIIHexDecode", "Hello"},
+		{"(414>) /ASCIIHexDecode", "A@"},
+		{"(414) /ASCIIHexDecode", "A@"},
+		{"(87cURD_*#TDfTZ\\)+T~>) /ASCII85Decode", "Hello, world!"},
+		{"(z!!~>) /ASCII85Decode", "\000\000\000\000\000"},
+		{"<02616263fd7880> /RunLengthDecode", "abcxxxx"},
+		{"<800b6050220c0c8501> /LZWDecode", "-----A---B"},
+		{"<800b6050220c0c8501> << /EarlyChange 1 >> /LZWDecode", "-----A---B"},
+		{"(abcdef) 3 () /SubFileDecode", "abc"},
+		{"(abcdef) 0 () /SubFileDecode", "abcdef"},
+		{"(abXcdXef) 0 (X) /SubFileDecode", "ab"},
+		{"(abXcdXef) 1 (X) /SubFileDecode", "abXcd"},
+		{"(aabab) 0 (ab) /SubFileDecode", "a"},
+		{"(xxabaabx) 0 (aab) /SubFileDecode", "xxab"},
+		{"(abc) << /EODCount 0 /EODString (c) >> /SubFileDecode", "ab"},
+		{"(3431343> garbage) /ASCIIHexDecode filter /ASCIIHexDecode", "A@"},
+	} {
+		code := test.code + " filter 100 string readstring pop"
+		intp, err := run(code, 1)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if d := cmp.Diff(String(test.expected), intp.Stack[0]); d != "" {
+			t.Errorf("%q: %s", test.code, d)
+		}
+	}
+}
+
+func TestFilterFlate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zlib.NewWriter(buf)
+	w.Write([]byte("1 2 add 3 mul"))
+	w.Close()
+
+	code := fmt.Sprintf("<%x> /FlateDecode filter cvx exec", buf.Bytes())
+	intp, err := run(code, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Integer(9) {
+		t.Errorf("wrong result %v", intp.Stack[0])
+	}
+}
+
+func TestFilterCurrentFile(t *testing.T) {
+	// The filter stops at the end-of-data marker, and the interpreter
+	// continues reading the current file after the marker.
+	intp, err := run("currentfile /ASCII85Decode filter cvx exec\n1B~> 4 5", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 3, 4, 5)
+
+	intp, err = run("currentfile 0 (%EOD) /SubFileDecode filter 20 string readstring\nline 1\nline 2\n%EOD 7", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{String("line 1\nline 2\n"), Boolean(false), Integer(7)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFilterProcedure(t *testing.T) {
+	intp, err := run(`
+		/n 0 def
+		{ /n n 1 add def n 3 le { (41) } { () } ifelse } /ASCIIHexDecode filter
+		10 string readstring`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{String("AAA"), Boolean(false)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFilterRead(t *testing.T) {
+	intp, err := run(`
+		/f (4142>) /ASCIIHexDecode filter def
+		f read f read f read`, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Integer('A'), Boolean(true), Integer('B'), Boolean(true), Boolean(false)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFilterClose(t *testing.T) {
+	intp, err := run(`
+		(414243) /ASCIIHexDecode filter
+		dup read pop pop dup closefile read
+		(41) /ASCIIHexDecode filter type`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Boolean(false), Name("filetype")}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFilterAccess(t *testing.T) {
+	intp, err := run(`
+		(414243>) /ASCIIHexDecode filter readonly rcheck
+		(414243>) /ASCIIHexDecode filter wcheck`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Boolean(true), Boolean(true)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"(x) /NoSuchDecode filter", eUndefined},
+		{"(x) /ASCIIHexEncode filter", eUndefined},
+		{"1 /ASCIIHexDecode filter", eTypecheck},
+		{"(x) 1 /ASCIIHexDecode filter", eTypecheck},
+		{"(x) -1 () /SubFileDecode filter", eRangecheck},
+		{"(x) << /Predictor 2 >> /FlateDecode filter", eRangecheck},
+		{"(4x) /ASCIIHexDecode filter 10 string readstring", eIoerror},
+		{"(ab~x) /ASCII85Decode filter 10 string readstring", eIoerror},
+		{"<0261> /RunLengthDecode filter 10 string readstring", eIoerror},
+		{"<ffffff> /LZWDecode filter 10 string readstring", eIoerror},
+		{"(not flate) /FlateDecode filter 10 string readstring", eIoerror},
+		{"/ASCIIHexDecode filter", eStackunderflow},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
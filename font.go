@@ -0,0 +1,267 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"maps"
+	"sync"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// A GlyphDecoder gives access to the glyph outlines of a font.
+type GlyphDecoder interface {
+	// DecodeGlyph returns the outline and the advance width of the named
+	// glyph, in character space.  If the font has no glyph of this name,
+	// ok is false.
+	DecodeGlyph(name Name) (outline *path.Data, width vec.Vec2, ok bool)
+}
+
+// A FontDecoder prepares a font dictionary for use by the text operators.
+type FontDecoder func(font Dict) (GlyphDecoder, error)
+
+var (
+	fontDecodersMu sync.RWMutex
+	fontDecoders   = map[Integer]FontDecoder{}
+)
+
+// RegisterFontType registers the decoder used by the text operators for
+// fonts with the given FontType.  Type 3 fonts are implemented by the
+// interpreter itself, decoders for other font types are registered by the
+// packages which implement them.  For example, importing the package
+// seehuhn.de/go/postscript/type1 registers a decoder for Type 1 fonts.
+func RegisterFontType(fontType int, decode FontDecoder) {
+	fontDecodersMu.Lock()
+	defer fontDecodersMu.Unlock()
+	fontDecoders[Integer(fontType)] = decode
+}
+
+func getFontDecoder(fontType Integer) FontDecoder {
+	fontDecodersMu.RLock()
+	defer fontDecodersMu.RUnlock()
+	return fontDecoders[fontType]
+}
+
+// fontID is the value of the FID entry which `definefont` adds to font
+// dictionaries.  Since copies of a font dictionary share the FID, this
+// is used to cache the decoded glyphs.
+type fontID struct {
+	decoded bool
+	glyphs  GlyphDecoder
+	err     error
+}
+
+// glyphDecoder returns the GlyphDecoder for a base font other than a
+// Type 3 font.
+func (intp *Interpreter) glyphDecoder(op string, font Dict) (GlyphDecoder, error) {
+	fid, ok := font["FID"].(*fontID)
+	if !ok {
+		return nil, intp.e(eInvalidfont, "%s: font was not defined using definefont", op)
+	}
+	if !fid.decoded {
+		fontType, _ := font["FontType"].(Integer)
+		decode := getFontDecoder(fontType)
+		if decode == nil {
+			return nil, intp.e(eInvalidfont, "%s: unsupported FontType %d", op, fontType)
+		}
+		fid.glyphs, fid.err = decode(font)
+		fid.decoded = true
+	}
+	if fid.err != nil {
+		return nil, intp.e(eInvalidfont, "%s: %v", op, fid.err)
+	}
+	return fid.glyphs, nil
+}
+
+func bSetfont(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "setfont: not enough arguments")
+	}
+	font, ok := intp.Stack[len(intp.Stack)-1].(Dict)
+	if !ok {
+		return intp.e(eTypecheck, "setfont: needs a font dictionary, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	if err := intp.checkFID("setfont", font); err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	intp.graphicsState().Font = font
+	return nil
+}
+
+// checkFID raises invalidfont, unless font has an FID entry.  Only fonts
+// returned by `definefont`, `makefont` or `scalefont` can be used as the
+// current font.
+func (intp *Interpreter) checkFID(op string, font Dict) error {
+	if _, ok := font["FID"].(*fontID); !ok {
+		return intp.e(eInvalidfont, "%s: font was not defined using definefont", op)
+	}
+	return nil
+}
+
+func bCurrentfont(intp *Interpreter) error {
+	font := intp.graphicsState().Font
+	if font == nil {
+		// Initially, the current font is an invalid font dictionary, which
+		// causes an invalidfont error when used by the text operators.
+		font = Dict{}
+	}
+	intp.Stack = append(intp.Stack, font)
+	return nil
+}
+
+func bRootfont(intp *Interpreter) error {
+	// Without composite fonts, the root font is always the current font.
+	return bCurrentfont(intp)
+}
+
+func bScalefont(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "scalefont: not enough arguments")
+	}
+	font, ok := intp.Stack[len(intp.Stack)-2].(Dict)
+	if !ok {
+		return intp.e(eTypecheck, "scalefont: needs a font dictionary, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	scale, ok := getNumber(intp.Stack[len(intp.Stack)-1])
+	if !ok {
+		return intp.e(eTypecheck, "scalefont: needs a number, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	res, err := intp.transformFont("scalefont", font, matrix.Scale(scale, scale))
+	if err != nil {
+		return err
+	}
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], res)
+	return nil
+}
+
+func bMakefont(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "makefont: not enough arguments")
+	}
+	font, ok := intp.Stack[len(intp.Stack)-2].(Dict)
+	if !ok {
+		return intp.e(eTypecheck, "makefont: needs a font dictionary, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	M, err := intp.getMatrix("makefont", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	res, err := intp.transformFont("makefont", font, M)
+	if err != nil {
+		return err
+	}
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], res)
+	return nil
+}
+
+func bSelectfont(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "selectfont: not enough arguments")
+	}
+	key, ok := intp.Stack[len(intp.Stack)-2].(Name)
+	if !ok {
+		return intp.e(eTypecheck, "selectfont: needs a name, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	var M matrix.Matrix
+	switch arg := intp.Stack[len(intp.Stack)-1].(type) {
+	case Integer, Real:
+		scale, _ := getNumber(arg)
+		M = matrix.Scale(scale, scale)
+	default:
+		var err error
+		M, err = intp.getMatrix("selectfont", arg)
+		if err != nil {
+			return err
+		}
+	}
+	obj, err := intp.findFont("selectfont", key)
+	if err != nil {
+		return err
+	}
+	font, ok := obj.(Dict)
+	if !ok {
+		return intp.e(eInvalidfont, "selectfont: font %q is not a dictionary", key)
+	}
+	if err := intp.checkFID("selectfont", font); err != nil {
+		return err
+	}
+	res, err := intp.transformFont("selectfont", font, M)
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+	intp.graphicsState().Font = res
+	return nil
+}
+
+// transformFont returns a copy of the font dictionary, where the FontMatrix
+// is replaced by the FontMatrix of the original font, followed by M.  The
+// copy shares the FID of the original font, and thus the cached glyphs.
+// Like in other implementations, the ScaleMatrix entry of the copy holds
+// the product of all matrices applied to the original font.
+func (intp *Interpreter) transformFont(op string, font Dict, M matrix.Matrix) (Dict, error) {
+	fm, err := intp.getMatrix(op, font["FontMatrix"])
+	if err != nil {
+		return nil, intp.e(eInvalidfont, "%s: invalid FontMatrix", op)
+	}
+	sm := matrix.Identity
+	if font["ScaleMatrix"] != nil {
+		sm, err = intp.getMatrix(op, font["ScaleMatrix"])
+		if err != nil {
+			return nil, intp.e(eInvalidfont, "%s: invalid ScaleMatrix", op)
+		}
+	}
+	if err := intp.charge((len(font)+1)*dictEntrySize + 12*objectSize); err != nil {
+		return nil, err
+	}
+	res := maps.Clone(font)
+	fontMatrix := make(Array, 6)
+	setMatrixArray(fontMatrix, fm.Mul(M))
+	res["FontMatrix"] = fontMatrix
+	scaleMatrix := make(Array, 6)
+	setMatrixArray(scaleMatrix, sm.Mul(M))
+	res["ScaleMatrix"] = scaleMatrix
+	return res, nil
+}
+
+// currentFont returns the current font, together with its font matrix.
+func (intp *Interpreter) currentFont(op string) (Dict, matrix.Matrix, error) {
+	font := intp.graphicsState().Font
+	if font == nil {
+		return nil, matrix.Matrix{}, intp.e(eInvalidfont, "%s: no current font", op)
+	}
+	M, err := intp.getMatrix(op, font["FontMatrix"])
+	if err != nil {
+		return nil, M, intp.e(eInvalidfont, "%s: invalid FontMatrix", op)
+	}
+	return font, M, nil
+}
+
+// glyphName maps a character code to a glyph name, using the Encoding
+// array of the font.
+func glyphName(font Dict, code byte) Name {
+	enc, _ := font["Encoding"].(Array)
+	if int(code) < len(enc) {
+		if name, ok := enc[code].(Name); ok {
+			return name
+		}
+	}
+	return ".notdef"
+}
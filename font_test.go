@@ -0,0 +1,115 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScalefont(t *testing.T) {
+	intp := NewInterpreter()
+	err := intp.ExecuteString(type3Font + `
+		/T3 findfont dup 12 scalefont
+		2 copy /FontMatrix get exch /FontMatrix get
+		3 index /FID get 3 index /FID get eq`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intp.Stack) != 5 {
+		t.Fatalf("got %d stack entries, expected 5", len(intp.Stack))
+	}
+	checkNumbers(t, intp.Stack[2].(Array), 0.012, 0, 0, 0.012, 0, 0)
+	checkNumbers(t, intp.Stack[3].(Array), 0.001, 0, 0, 0.001, 0, 0)
+	if intp.Stack[4] != Boolean(true) {
+		t.Error("scaled font does not share the FID")
+	}
+}
+
+func TestMakefont(t *testing.T) {
+	// The font matrix is applied first, followed by the matrix operand.
+	intp, err := run(type3Font+`
+		/T3 findfont [2 0 0 3 4 5] makefont [1 0 0 1 10 20] makefont
+		/FontMatrix get`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack[0].(Array), 0.002, 0, 0, 0.003, 14, 25)
+}
+
+func TestSelectfont(t *testing.T) {
+	for _, code := range []string{
+		"/T3 10 selectfont",
+		"/T3 10.0 selectfont",
+		"/T3 [10 0 0 10 0 0] selectfont",
+		"/T3 findfont 10 scalefont setfont",
+	} {
+		intp, err := run(type3Font+code+" 0 0 moveto (a) show currentpoint", 2)
+		if err != nil {
+			t.Errorf("%s: %v", code, err)
+			continue
+		}
+		checkNumbers(t, intp.Stack, 6, 0)
+	}
+}
+
+func TestCurrentfont(t *testing.T) {
+	intp, err := run(type3Font+`
+		/T3 findfont 5 scalefont setfont
+		currentfont rootfont eq currentfont /FontMatrix get 0 get`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Boolean(true) {
+		t.Error("rootfont differs from currentfont")
+	}
+	checkNumbers(t, intp.Stack[1:], 0.005)
+
+	// initgraphics does not change the current font
+	intp, err = run(type3Font+`
+		/T3 findfont setfont initgraphics currentfont /FontType get`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Integer(3) {
+		t.Errorf("wrong font type %v", intp.Stack[0])
+	}
+}
+
+func TestFontErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"/T3 findfont (x) scalefont", eTypecheck},
+		{"1 2 scalefont", eTypecheck},
+		{"/T3 findfont [1 2 3] makefont", eRangecheck},
+		{"<< >> 2 scalefont", eInvalidfont},
+		{"/Missing 12 selectfont", eInvalidfont},
+		{"(T3) 12 selectfont", eTypecheck},
+		{"1 setfont", eTypecheck},
+		{"<< /FontType 3 /FontMatrix [1 0 0 1 0 0] >> setfont", eInvalidfont},
+		{"FontDirectory /X << /FontType 3 /FontMatrix [1 0 0 1 0 0] >> put /X 12 selectfont", eInvalidfont},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(type3Font + test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
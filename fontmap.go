@@ -0,0 +1,246 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// maxFontAliases limits the length of chains of aliases in a Fontmap.
+const maxFontAliases = 16
+
+// A Fontmap maps font names to font files, using the format of
+// Ghostscript's Fontmap files.  Each entry in a Fontmap file has one of
+// the following two forms:
+//
+//	/Name (file) ;
+//	/Name /Other ;
+//
+// The first form gives the file which defines the font Name.  The second
+// form makes Name an alias for the font Other.  If a name is listed more
+// than once, the last entry is used.
+type Fontmap struct {
+	files fs.FS
+
+	// entries maps font names to either a file name (string) or to the
+	// name of another font (Name).
+	entries map[Name]any
+}
+
+// NewFontmap returns an empty Fontmap.  The font files named in the map
+// are opened from files.
+func NewFontmap(files fs.FS) *Fontmap {
+	return &Fontmap{
+		files:   files,
+		entries: make(map[Name]any),
+	}
+}
+
+// ReadFontmap reads a Fontmap file.  The font files named in the map are
+// opened from files.
+func ReadFontmap(r io.Reader, files fs.FS) (*Fontmap, error) {
+	m := NewFontmap(files)
+	err := m.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Parse reads entries from a Fontmap file and adds them to m.
+func (m *Fontmap) Parse(r io.Reader) error {
+	s := newScanner(r)
+	for {
+		obj, err := s.ScanToken()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("fontmap: %w", err)
+		}
+		name, ok := obj.(Name)
+		if !ok {
+			return fmt.Errorf("fontmap: expected font name, got %s", Format(obj))
+		}
+
+		obj, err = s.ScanToken()
+		if err == io.EOF {
+			return fmt.Errorf("fontmap: missing entry for font %q", name)
+		} else if err != nil {
+			return fmt.Errorf("fontmap: %w", err)
+		}
+		switch val := obj.(type) {
+		case String:
+			m.AddFile(name, string(val))
+		case Name:
+			m.AddAlias(name, val)
+		default:
+			return fmt.Errorf("fontmap: invalid entry %s for font %q", Format(obj), name)
+		}
+
+		obj, err = s.ScanToken()
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("fontmap: %w", err)
+		}
+		if obj != Operator(";") {
+			return fmt.Errorf("fontmap: missing \";\" after entry for font %q", name)
+		}
+	}
+}
+
+// AddFile records that the font name is defined in the given file.
+// The file name is interpreted relative to the file system of the
+// Fontmap.  A leading slash is ignored, so that absolute file names can
+// be used together with os.DirFS("/").
+func (m *Fontmap) AddFile(name Name, file string) {
+	m.entries[name] = file
+}
+
+// AddAlias makes name an alias for the font other.
+func (m *Fontmap) AddAlias(name, other Name) {
+	m.entries[name] = other
+}
+
+// open opens a font file listed in the Fontmap.
+func (m *Fontmap) open(file string) (fs.File, error) {
+	name := strings.TrimPrefix(path.Clean(file), "/")
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: file, Err: fs.ErrNotExist}
+	}
+	return m.files.Open(name)
+}
+
+// FontSubstitution records that a font was used in place of a requested
+// font.
+type FontSubstitution struct {
+	// Requested is the name of the font asked for by the PostScript code.
+	Requested Name
+
+	// Used is the name of the font which was used instead.
+	Used Name
+}
+
+// findFont returns the font with the given name.  Fonts which are not
+// defined are loaded using intp.ResourceProvider and intp.Fontmap.  If
+// the font cannot be found, intp.DefaultFont is used instead.
+func (intp *Interpreter) findFont(op string, name Name) (Object, error) {
+	font, used, err := intp.lookupFont(op, name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Substitutes for missing fonts are not stored in FontDirectory, so
+	// that the PostScript code can still detect that the font is missing.
+	if font == nil && intp.DefaultFont != "" && intp.DefaultFont != name {
+		font, used, err = intp.lookupFont(op, intp.DefaultFont, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if font == nil {
+		return nil, intp.e(eInvalidfont, "%s: font %q not found", op, name)
+	}
+
+	// Aliases are cached in FontDirectory under their own name, so that
+	// later lookups cannot see the substitution.  Recording every pair only
+	// once makes the list independent of this caching.
+	subst := FontSubstitution{Requested: name, Used: used}
+	if used != name && !slices.Contains(intp.FontSubstitutions, subst) {
+		intp.FontSubstitutions = append(intp.FontSubstitutions, subst)
+	}
+	return font, nil
+}
+
+// lookupFont tries to find or load the font with the given name.  It
+// returns the font together with the name of the font which was actually
+// loaded.  If the font cannot be found, nil is returned.
+func (intp *Interpreter) lookupFont(op string, name Name, depth int) (Object, Name, error) {
+	if font, ok := intp.FontDirectory[name]; ok {
+		return font, name, nil
+	}
+	err := intp.loadResource(op, "Font", name)
+	if err != nil {
+		return nil, "", err
+	}
+	if font, ok := intp.FontDirectory[name]; ok {
+		return font, name, nil
+	}
+
+	if intp.Fontmap == nil {
+		return nil, "", nil
+	}
+	var font Object
+	var used Name
+	switch entry := intp.Fontmap.entries[name].(type) {
+	case Name:
+		if depth >= maxFontAliases {
+			return nil, "", nil
+		}
+		font, used, err = intp.lookupFont(op, entry, depth+1)
+	case string:
+		font, used, err = intp.loadFontFile(op, name, entry)
+	}
+	if font == nil || err != nil {
+		return nil, "", err
+	}
+	intp.saveDictEntry(intp.FontDirectory, name)
+	intp.FontDirectory[name] = font
+	return font, used, nil
+}
+
+// loadFontFile executes a font file listed in the Fontmap.  If the file
+// does not define the font name, but defines exactly one other font, this
+// font is returned instead.
+func (intp *Interpreter) loadFontFile(op string, name Name, file string) (Object, Name, error) {
+	r, err := intp.Fontmap.open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", intp.e(eIoerror, "%s: %v", op, err)
+	}
+	defer r.Close()
+
+	before := make(map[Name]bool, len(intp.FontDirectory))
+	for key := range intp.FontDirectory {
+		before[key] = true
+	}
+	err = intp.runResource(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if font, ok := intp.FontDirectory[name]; ok {
+		return font, name, nil
+	}
+
+	var font Object
+	var used Name
+	for key, val := range intp.FontDirectory {
+		if before[key] {
+			continue
+		}
+		if font != nil {
+			return nil, "", nil
+		}
+		font, used = val, key
+	}
+	return font, used, nil
+}
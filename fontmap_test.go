@@ -0,0 +1,169 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fontFile returns PostScript code which defines a Type 3 font with the
+// given name.
+func fontFile(name string) *fstest.MapFile {
+	code := "/" + name + ` <<
+		/FontType 3
+		/FontMatrix [0.001 0 0 0.001 0 0]
+		/Encoding StandardEncoding
+		/BuildGlyph { pop pop 500 0 setcharwidth }
+	>> definefont pop`
+	return &fstest.MapFile{Data: []byte(code)}
+}
+
+const testFontmap = `
+% Fontmap for testing
+/Serif-Regular       (fonts/serif.ps) ;
+/Times-Roman         /Serif-Regular ;
+/Times               /Times-Roman ;
+/Sans-Regular        (/fonts/sans.ps);
+/Helvetica           (fonts/other-name.ps) ;
+/Missing             (fonts/missing.ps) ;
+/Loop1               /Loop2 ;
+/Loop2               /Loop1 ;
+`
+
+var testFontFiles = fstest.MapFS{
+	"fonts/serif.ps":      fontFile("Serif-Regular"),
+	"fonts/sans.ps":       fontFile("Sans-Regular"),
+	"fonts/other-name.ps": fontFile("Sans-Bold"),
+}
+
+func newFontmapInterpreter(t *testing.T) *Interpreter {
+	t.Helper()
+	fontmap, err := ReadFontmap(strings.NewReader(testFontmap), testFontFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intp := NewInterpreter()
+	intp.Fontmap = fontmap
+	return intp
+}
+
+func TestFontmap(t *testing.T) {
+	intp := newFontmapInterpreter(t)
+	err := intp.ExecuteString(`
+		/Serif-Regular findfont /FontType get
+		/Times findfont /Serif-Regular findfont eq
+		/Times findfont pop
+		/Sans-Regular 12 selectfont
+		/Helvetica findfont /FontType get
+		FontDirectory /Times-Roman known`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Integer(3), Boolean(true), Integer(3), Boolean(true)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+
+	expectedSubst := []FontSubstitution{
+		{Requested: "Times", Used: "Serif-Regular"},
+		{Requested: "Helvetica", Used: "Sans-Bold"},
+	}
+	if d := cmp.Diff(expectedSubst, intp.FontSubstitutions); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFontmapDefaultFont(t *testing.T) {
+	intp := newFontmapInterpreter(t)
+	intp.DefaultFont = "Sans-Regular"
+	err := intp.ExecuteString(`
+		/Unknown findfont pop
+		/Missing findfont pop
+		/Loop1 findfont pop
+		/Unknown findfont pop
+		FontDirectory /Unknown known`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]Object{Boolean(false)}, intp.Stack); d != "" {
+		t.Error(d)
+	}
+
+	expectedSubst := []FontSubstitution{
+		{Requested: "Unknown", Used: "Sans-Regular"},
+		{Requested: "Missing", Used: "Sans-Regular"},
+		{Requested: "Loop1", Used: "Sans-Regular"},
+	}
+	if d := cmp.Diff(expectedSubst, intp.FontSubstitutions); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestFontmapErrors(t *testing.T) {
+	for _, code := range []string{
+		"/Unknown findfont",
+		"/Missing findfont",
+		"/Loop1 findfont",
+		"/Loop2 10 selectfont",
+	} {
+		intp := newFontmapInterpreter(t)
+		err := intp.ExecuteString(code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eInvalidfont {
+			t.Errorf("%q: expected invalidfont, got %v", code, err)
+		}
+	}
+}
+
+func TestReadFontmap(t *testing.T) {
+	fontmap, err := ReadFontmap(strings.NewReader(testFontmap), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[Name]any{
+		"Serif-Regular": "fonts/serif.ps",
+		"Times-Roman":   Name("Serif-Regular"),
+		"Times":         Name("Times-Roman"),
+		"Sans-Regular":  "/fonts/sans.ps",
+		"Helvetica":     "fonts/other-name.ps",
+		"Missing":       "fonts/missing.ps",
+		"Loop1":         Name("Loop2"),
+		"Loop2":         Name("Loop1"),
+	}
+	if d := cmp.Diff(expected, fontmap.entries); d != "" {
+		t.Error(d)
+	}
+
+	for _, bad := range []string{
+		"(file) /Name ;",
+		"/Name (file)",
+		"/Name (file) /Other ;",
+		"/Name 12 ;",
+		"/Name",
+		"/Name (file",
+	} {
+		_, err := ReadFontmap(strings.NewReader(bad), nil)
+		if err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,1272 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"math"
+	"slices"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// A Device receives the output of the PostScript painting operators.
+//
+// All paths passed to a Device are in device space, i.e. the current
+// transformation matrix has already been applied.
+type Device interface {
+	// DefaultMatrix returns the transformation from default user space to
+	// device space.  This is the initial value of the CTM.
+	DefaultMatrix() matrix.Matrix
+
+	// Fill paints the area enclosed by gs.Path using gs.Color.  If evenOdd
+	// is true, the even-odd rule is used to determine the inside of the
+	// path, otherwise the nonzero winding number rule is used.
+	Fill(gs *GraphicsState, evenOdd bool) error
+
+	// Stroke paints a line along gs.Path, using the line parameters from
+	// gs.  The line width and dash pattern are given in user space, and
+	// gs.CTM must be used to map the pen shape to device space.
+	Stroke(gs *GraphicsState) error
+
+	// ShowPage is called by the `showpage` operator, after all marks of the
+	// current page have been painted.
+	ShowPage() error
+}
+
+// A TextDevice is a Device which is also informed about the glyphs shown by
+// the text operators, for example to extract the text of a document.
+type TextDevice interface {
+	Device
+
+	// ShowGlyph is called by `show` and the related operators for every
+	// glyph, after the glyph has been painted.  The current font is
+	// gs.Font.  Glyphs shown by the glyph procedures of Type 3 fonts are
+	// not reported.
+	ShowGlyph(gs *GraphicsState, g *Glyph) error
+}
+
+// Glyph describes a glyph shown by the text operators.
+type Glyph struct {
+	// Code is the character code, and Name is the glyph name given by the
+	// Encoding of the font.
+	Code byte
+	Name Name
+
+	// Origin is the position of the glyph origin, Width is the glyph
+	// width given by the font, and Advance is the displacement of the
+	// current point caused by the glyph.  Width and Advance differ for
+	// operators like `ashow` or `xshow`, which adjust the glyph spacing.
+	// All three are given in device space.
+	Origin  vec.Vec2
+	Width   vec.Vec2
+	Advance vec.Vec2
+}
+
+// GraphicsState holds the parameters of the PostScript graphics state.
+// The state is described in section 4.2 of the PostScript Language
+// Reference Manual.
+type GraphicsState struct {
+	// CTM is the current transformation matrix, which maps user space to
+	// device space.
+	CTM matrix.Matrix
+
+	// Path is the current path, in device space.
+	Path *path.Data
+
+	// Clip is the list of paths, in device space, whose intersection forms
+	// the current clipping path.  If the list is empty, the whole page is
+	// visible.
+	Clip []ClipPath
+
+	// Color is the current color.
+	Color Color
+
+	// LineWidth is the line width for stroking, in user space units.
+	LineWidth float64
+
+	// LineCap is the shape at the open ends of stroked lines.
+	LineCap LineCap
+
+	// LineJoin is the shape at the corners of stroked lines.
+	LineJoin LineJoin
+
+	// MiterLimit limits the length of mitered line joins.
+	MiterLimit float64
+
+	// Dash is the dash array for stroking, in user space units.
+	// An empty array indicates solid lines.
+	Dash []float64
+
+	// DashPhase is the offset into the dash pattern at which stroking
+	// starts.
+	DashPhase float64
+
+	// Flatness is the accuracy with which curves are rendered, in device
+	// pixels.
+	Flatness float64
+
+	// Font is the current font dictionary, as set by `setfont`.
+	// This is nil if no font has been selected.
+	Font Dict
+
+	// current point and start of the current subpath, in device space
+	current      vec.Vec2
+	subpathStart vec.Vec2
+	hasCurrent   bool
+
+	// fromSave is set for graphics states pushed onto the graphics state
+	// stack by `save`.  Such states are not popped by `grestore`.
+	fromSave bool
+}
+
+// ClipPath is one of the paths which together form the clipping path.
+type ClipPath struct {
+	Path    *path.Data
+	EvenOdd bool
+}
+
+// LineCap describes the shape at the open ends of stroked lines.
+type LineCap int
+
+// These are the line cap styles supported by PostScript.
+const (
+	LineCapButt LineCap = iota
+	LineCapRound
+	LineCapSquare
+)
+
+// LineJoin describes the shape at the corners of stroked lines.
+type LineJoin int
+
+// These are the line join styles supported by PostScript.
+const (
+	LineJoinMiter LineJoin = iota
+	LineJoinRound
+	LineJoinBevel
+)
+
+// ColorSpace identifies one of the PostScript device color spaces.
+type ColorSpace int
+
+// These are the color spaces supported for the current color.
+const (
+	DeviceGray ColorSpace = iota
+	DeviceRGB
+	DeviceCMYK
+)
+
+// Color is a color in one of the device color spaces.
+// Only the first 1, 3 or 4 entries of Values are used, depending on the
+// color space.  All values are in the range [0, 1].
+type Color struct {
+	Space  ColorSpace
+	Values [4]float64
+}
+
+// RGB returns the red, green and blue components of the color, using the
+// conversions from section 7.2 of the PostScript Language Reference Manual.
+func (c Color) RGB() (r, g, b float64) {
+	switch c.Space {
+	case DeviceRGB:
+		return c.Values[0], c.Values[1], c.Values[2]
+	case DeviceCMYK:
+		k := c.Values[3]
+		r = 1 - min(1, c.Values[0]+k)
+		g = 1 - min(1, c.Values[1]+k)
+		b = 1 - min(1, c.Values[2]+k)
+		return r, g, b
+	default:
+		return c.Values[0], c.Values[0], c.Values[0]
+	}
+}
+
+// Gray returns the gray level corresponding to the color.
+func (c Color) Gray() float64 {
+	switch c.Space {
+	case DeviceGray:
+		return c.Values[0]
+	case DeviceCMYK:
+		return 1 - min(1, 0.3*c.Values[0]+0.59*c.Values[1]+0.11*c.Values[2]+c.Values[3])
+	default:
+		r, g, b := c.RGB()
+		return 0.3*r + 0.59*g + 0.11*b
+	}
+}
+
+// RGBA implements the [image/color.Color] interface.
+func (c Color) RGBA() (r, g, b, a uint32) {
+	rf, gf, bf := c.RGB()
+	conv := func(x float64) uint32 {
+		return uint32(math.Round(min(max(x, 0), 1) * 0xffff))
+	}
+	return conv(rf), conv(gf), conv(bf), 0xffff
+}
+
+func newGraphicsState(dev Device) *GraphicsState {
+	gs := &GraphicsState{}
+	gs.init(dev)
+	return gs
+}
+
+// init resets the graphics state to the values set by `initgraphics`.
+// The current font is not changed.
+func (gs *GraphicsState) init(dev Device) {
+	ctm := matrix.Identity
+	if dev != nil {
+		ctm = dev.DefaultMatrix()
+	}
+	*gs = GraphicsState{
+		Font:       gs.Font,
+		CTM:        ctm,
+		Path:       &path.Data{},
+		Color:      Color{Space: DeviceGray},
+		LineWidth:  1,
+		MiterLimit: 10,
+		Flatness:   1,
+	}
+}
+
+// clone returns a copy of the graphics state which can be modified without
+// affecting gs.
+func (gs *GraphicsState) clone() *GraphicsState {
+	res := *gs
+	res.Path = clonePath(gs.Path)
+	res.Clip = slices.Clip(gs.Clip)
+	return &res
+}
+
+func clonePath(p *path.Data) *path.Data {
+	return &path.Data{
+		Cmds:   slices.Clone(p.Cmds),
+		Coords: slices.Clone(p.Coords),
+	}
+}
+
+// graphicsState returns the current graphics state, creating it if needed.
+func (intp *Interpreter) graphicsState() *GraphicsState {
+	if intp.gstate == nil {
+		intp.gstate = newGraphicsState(intp.Device)
+	}
+	return intp.gstate
+}
+
+// pathPointSize is the accounting weight of one point in the current path.
+const pathPointSize = 16
+
+// maxGsaveDepth is the maximal nesting depth of `gsave`.
+const maxGsaveDepth = 100
+
+func bGsave(intp *Interpreter) error {
+	if len(intp.gstack) >= maxGsaveDepth {
+		return intp.e(eLimitcheck, "gsave: too many nested gsaves")
+	}
+	gs := intp.graphicsState()
+	intp.gstack = append(intp.gstack, gs.clone())
+	return nil
+}
+
+func bGrestore(intp *Interpreter) error {
+	if len(intp.gstack) == 0 {
+		return nil
+	}
+	top := intp.gstack[len(intp.gstack)-1]
+	if top.fromSave {
+		// The state saved by `save` is restored, but stays on the stack.
+		intp.gstate = top.clone()
+		intp.gstate.fromSave = false
+		return nil
+	}
+	intp.gstate = top
+	intp.gstack = intp.gstack[:len(intp.gstack)-1]
+	return nil
+}
+
+func bGrestoreall(intp *Interpreter) error {
+	if len(intp.gstack) == 0 {
+		return nil
+	}
+	for i := len(intp.gstack) - 1; i >= 0; i-- {
+		if intp.gstack[i].fromSave {
+			intp.gstate = intp.gstack[i].clone()
+			intp.gstate.fromSave = false
+			intp.gstack = intp.gstack[:i+1]
+			return nil
+		}
+	}
+	intp.gstate = intp.gstack[0]
+	intp.gstack = intp.gstack[:0]
+	return nil
+}
+
+func bInitgraphics(intp *Interpreter) error {
+	intp.graphicsState().init(intp.Device)
+	return nil
+}
+
+func bSetlinewidth(intp *Interpreter) error {
+	x, err := intp.popNumbers("setlinewidth", 1)
+	if err != nil {
+		return err
+	}
+	intp.graphicsState().LineWidth = math.Abs(x[0])
+	return nil
+}
+
+func bCurrentlinewidth(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Real(intp.graphicsState().LineWidth))
+	return nil
+}
+
+func bSetlinecap(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "setlinecap: not enough arguments")
+	}
+	x, ok := intp.Stack[len(intp.Stack)-1].(Integer)
+	if !ok {
+		return intp.e(eTypecheck, "setlinecap: needs an integer")
+	} else if x < 0 || x > 2 {
+		return intp.e(eRangecheck, "setlinecap: invalid line cap %d", x)
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	intp.graphicsState().LineCap = LineCap(x)
+	return nil
+}
+
+func bCurrentlinecap(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Integer(intp.graphicsState().LineCap))
+	return nil
+}
+
+func bSetlinejoin(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "setlinejoin: not enough arguments")
+	}
+	x, ok := intp.Stack[len(intp.Stack)-1].(Integer)
+	if !ok {
+		return intp.e(eTypecheck, "setlinejoin: needs an integer")
+	} else if x < 0 || x > 2 {
+		return intp.e(eRangecheck, "setlinejoin: invalid line join %d", x)
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	intp.graphicsState().LineJoin = LineJoin(x)
+	return nil
+}
+
+func bCurrentlinejoin(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Integer(intp.graphicsState().LineJoin))
+	return nil
+}
+
+func bSetmiterlimit(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "setmiterlimit: not enough arguments")
+	}
+	if x, ok := getNumber(intp.Stack[len(intp.Stack)-1]); ok && x < 1 {
+		return intp.e(eRangecheck, "setmiterlimit: invalid limit %g", x)
+	}
+	x, err := intp.popNumbers("setmiterlimit", 1)
+	if err != nil {
+		return err
+	}
+	intp.graphicsState().MiterLimit = x[0]
+	return nil
+}
+
+func bCurrentmiterlimit(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Real(intp.graphicsState().MiterLimit))
+	return nil
+}
+
+func bSetdash(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "setdash: not enough arguments")
+	}
+	a, ok := intp.Stack[len(intp.Stack)-2].(Array)
+	if !ok {
+		return intp.e(eTypecheck, "setdash: needs an array, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	offset, ok := getNumber(intp.Stack[len(intp.Stack)-1])
+	if !ok {
+		return intp.e(eTypecheck, "setdash: invalid offset")
+	}
+	dash := make([]float64, len(a))
+	allZero := true
+	for i, obj := range a {
+		x, ok := getNumber(obj)
+		if !ok {
+			return intp.e(eTypecheck, "setdash: invalid dash array element %T", obj)
+		} else if x < 0 {
+			return intp.e(eRangecheck, "setdash: negative dash length")
+		}
+		if x != 0 {
+			allZero = false
+		}
+		dash[i] = x
+	}
+	if len(dash) > 0 && allZero {
+		return intp.e(eRangecheck, "setdash: all dash lengths are zero")
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+	gs := intp.graphicsState()
+	gs.Dash = dash
+	gs.DashPhase = offset
+	return nil
+}
+
+func bCurrentdash(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if err := intp.charge(len(gs.Dash) * objectSize); err != nil {
+		return err
+	}
+	a := make(Array, len(gs.Dash))
+	for i, x := range gs.Dash {
+		a[i] = Real(x)
+	}
+	intp.Stack = append(intp.Stack, a, Real(gs.DashPhase))
+	return nil
+}
+
+func bSetflat(intp *Interpreter) error {
+	x, err := intp.popNumbers("setflat", 1)
+	if err != nil {
+		return err
+	}
+	intp.graphicsState().Flatness = min(max(x[0], 0.2), 100)
+	return nil
+}
+
+func bCurrentflat(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Real(intp.graphicsState().Flatness))
+	return nil
+}
+
+func bSetgray(intp *Interpreter) error {
+	x, err := intp.popNumbers("setgray", 1)
+	if err != nil {
+		return err
+	}
+	intp.setColor(DeviceGray, x)
+	return nil
+}
+
+func bCurrentgray(intp *Interpreter) error {
+	intp.Stack = append(intp.Stack, Real(intp.graphicsState().Color.Gray()))
+	return nil
+}
+
+func bSetrgbcolor(intp *Interpreter) error {
+	x, err := intp.popNumbers("setrgbcolor", 3)
+	if err != nil {
+		return err
+	}
+	intp.setColor(DeviceRGB, x)
+	return nil
+}
+
+func bCurrentrgbcolor(intp *Interpreter) error {
+	r, g, b := intp.graphicsState().Color.RGB()
+	intp.Stack = append(intp.Stack, Real(r), Real(g), Real(b))
+	return nil
+}
+
+func bSetcmykcolor(intp *Interpreter) error {
+	x, err := intp.popNumbers("setcmykcolor", 4)
+	if err != nil {
+		return err
+	}
+	intp.setColor(DeviceCMYK, x)
+	return nil
+}
+
+func bCurrentcmykcolor(intp *Interpreter) error {
+	col := intp.graphicsState().Color
+	var c, m, y, k float64
+	switch col.Space {
+	case DeviceCMYK:
+		c, m, y, k = col.Values[0], col.Values[1], col.Values[2], col.Values[3]
+	case DeviceGray:
+		k = 1 - col.Values[0]
+	default:
+		// PLRM section 7.2.4: conversion with no undercolor removal
+		r, g, b := col.RGB()
+		c, m, y = 1-r, 1-g, 1-b
+		k = min(c, m, y)
+		c, m, y = c-k, m-k, y-k
+	}
+	intp.Stack = append(intp.Stack, Real(c), Real(m), Real(y), Real(k))
+	return nil
+}
+
+func bSethsbcolor(intp *Interpreter) error {
+	x, err := intp.popNumbers("sethsbcolor", 3)
+	if err != nil {
+		return err
+	}
+	h, s, v := clamp01(x[0]), clamp01(x[1]), clamp01(x[2])
+	h6 := h * 6
+	i := math.Floor(h6)
+	f := h6 - i
+	p := v * (1 - s)
+	q := v * (1 - s*f)
+	t := v * (1 - s*(1-f))
+	var r, g, b float64
+	switch int(i) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	intp.setColor(DeviceRGB, []float64{r, g, b})
+	return nil
+}
+
+func bCurrenthsbcolor(intp *Interpreter) error {
+	r, g, b := intp.graphicsState().Color.RGB()
+	v := max(r, g, b)
+	delta := v - min(r, g, b)
+	var h, s float64
+	if v > 0 {
+		s = delta / v
+	}
+	if delta > 0 {
+		switch v {
+		case r:
+			h = (g - b) / delta
+		case g:
+			h = 2 + (b-r)/delta
+		default:
+			h = 4 + (r-g)/delta
+		}
+		h /= 6
+		if h < 0 {
+			h += 1
+		}
+	}
+	intp.Stack = append(intp.Stack, Real(h), Real(s), Real(v))
+	return nil
+}
+
+func (intp *Interpreter) setColor(space ColorSpace, x []float64) {
+	col := Color{Space: space}
+	for i, v := range x {
+		col.Values[i] = clamp01(v)
+	}
+	intp.graphicsState().Color = col
+}
+
+func clamp01(x float64) float64 {
+	return min(max(x, 0), 1)
+}
+
+func bTranslate(intp *Interpreter) error {
+	x, err := intp.popNumbers("translate", 2)
+	if err != nil {
+		return err
+	}
+	gs := intp.graphicsState()
+	gs.CTM = matrix.Translate(x[0], x[1]).Mul(gs.CTM)
+	return nil
+}
+
+func bScale(intp *Interpreter) error {
+	x, err := intp.popNumbers("scale", 2)
+	if err != nil {
+		return err
+	}
+	gs := intp.graphicsState()
+	gs.CTM = matrix.Scale(x[0], x[1]).Mul(gs.CTM)
+	return nil
+}
+
+func bRotate(intp *Interpreter) error {
+	x, err := intp.popNumbers("rotate", 1)
+	if err != nil {
+		return err
+	}
+	gs := intp.graphicsState()
+	gs.CTM = matrix.RotateDeg(x[0]).Mul(gs.CTM)
+	return nil
+}
+
+func bConcat(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "concat: not enough arguments")
+	}
+	M, err := intp.getMatrix("concat", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	gs := intp.graphicsState()
+	gs.CTM = M.Mul(gs.CTM)
+	return nil
+}
+
+func bCurrentmatrix(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "currentmatrix: not enough arguments")
+	}
+	a, err := intp.getMatrixArray("currentmatrix", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	if err := intp.checkWrite("currentmatrix", a); err != nil {
+		return err
+	}
+	intp.saveElems(a)
+	setMatrixArray(a, intp.graphicsState().CTM)
+	return nil
+}
+
+func bSetmatrix(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "setmatrix: not enough arguments")
+	}
+	M, err := intp.getMatrix("setmatrix", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	intp.graphicsState().CTM = M
+	return nil
+}
+
+func bInitmatrix(intp *Interpreter) error {
+	intp.graphicsState().CTM = intp.defaultMatrix()
+	return nil
+}
+
+func bDefaultmatrix(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "defaultmatrix: not enough arguments")
+	}
+	a, err := intp.getMatrixArray("defaultmatrix", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	if err := intp.checkWrite("defaultmatrix", a); err != nil {
+		return err
+	}
+	intp.saveElems(a)
+	setMatrixArray(a, intp.defaultMatrix())
+	return nil
+}
+
+func (intp *Interpreter) defaultMatrix() matrix.Matrix {
+	if intp.Device == nil {
+		return matrix.Identity
+	}
+	return intp.Device.DefaultMatrix()
+}
+
+func bIdentmatrix(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "identmatrix: not enough arguments")
+	}
+	a, err := intp.getMatrixArray("identmatrix", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	if err := intp.checkWrite("identmatrix", a); err != nil {
+		return err
+	}
+	intp.saveElems(a)
+	setMatrixArray(a, matrix.Identity)
+	return nil
+}
+
+func bConcatmatrix(intp *Interpreter) error {
+	if len(intp.Stack) < 3 {
+		return intp.e(eStackunderflow, "concatmatrix: not enough arguments")
+	}
+	M1, err := intp.getMatrix("concatmatrix", intp.Stack[len(intp.Stack)-3])
+	if err != nil {
+		return err
+	}
+	M2, err := intp.getMatrix("concatmatrix", intp.Stack[len(intp.Stack)-2])
+	if err != nil {
+		return err
+	}
+	a, err := intp.getMatrixArray("concatmatrix", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	if err := intp.checkWrite("concatmatrix", a); err != nil {
+		return err
+	}
+	intp.saveElems(a)
+	setMatrixArray(a, M1.Mul(M2))
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-3], a)
+	return nil
+}
+
+func bInvertmatrix(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "invertmatrix: not enough arguments")
+	}
+	M, err := intp.getMatrix("invertmatrix", intp.Stack[len(intp.Stack)-2])
+	if err != nil {
+		return err
+	}
+	a, err := intp.getMatrixArray("invertmatrix", intp.Stack[len(intp.Stack)-1])
+	if err != nil {
+		return err
+	}
+	if err := intp.checkWrite("invertmatrix", a); err != nil {
+		return err
+	}
+	if !invertible(M) {
+		return intp.e(eUndefinedresult, "invertmatrix: singular matrix")
+	}
+	intp.saveElems(a)
+	setMatrixArray(a, M.Inv())
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], a)
+	return nil
+}
+
+func bTransform(intp *Interpreter) error {
+	return intp.transform("transform", false, false)
+}
+
+func bItransform(intp *Interpreter) error {
+	return intp.transform("itransform", true, false)
+}
+
+func bDtransform(intp *Interpreter) error {
+	return intp.transform("dtransform", false, true)
+}
+
+func bIdtransform(intp *Interpreter) error {
+	return intp.transform("idtransform", true, true)
+}
+
+// transform implements `transform`, `itransform`, `dtransform` and
+// `idtransform`.  The operands are two numbers, optionally followed by a
+// matrix.  If no matrix is given, the CTM is used.  If inverse is set, the
+// inverse of the matrix is applied.  If vector is set, the translation
+// part of the matrix is ignored.
+func (intp *Interpreter) transform(op string, inverse, vector bool) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	n := 2
+	M := intp.graphicsState().CTM
+	if a, isArray := intp.Stack[len(intp.Stack)-1].(Array); isArray {
+		var err error
+		M, err = intp.getMatrix(op, a)
+		if err != nil {
+			return err
+		}
+		n = 3
+	}
+	if len(intp.Stack) < n {
+		return intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	base := len(intp.Stack) - n
+	x, ok1 := getNumber(intp.Stack[base])
+	y, ok2 := getNumber(intp.Stack[base+1])
+	if !ok1 || !ok2 {
+		return intp.e(eTypecheck, "%s: needs two numbers", op)
+	}
+
+	if inverse {
+		if !invertible(M) {
+			return intp.e(eUndefinedresult, "%s: singular matrix", op)
+		}
+		M = M.Inv()
+	}
+	var p vec.Vec2
+	if vector {
+		p = linear(M).Apply(vec.Vec2{X: x, Y: y})
+	} else {
+		p = M.Apply(vec.Vec2{X: x, Y: y})
+	}
+	intp.Stack = append(intp.Stack[:base], Real(p.X), Real(p.Y))
+	return nil
+}
+
+func bNewpath(intp *Interpreter) error {
+	intp.graphicsState().newPath()
+	return nil
+}
+
+func (gs *GraphicsState) newPath() {
+	gs.Path = &path.Data{}
+	gs.hasCurrent = false
+}
+
+func bCurrentpoint(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "currentpoint: no current point")
+	}
+	if !invertible(gs.CTM) {
+		return intp.e(eUndefinedresult, "currentpoint: singular CTM")
+	}
+	inv := gs.CTM.Inv()
+	p := inv.Apply(gs.current)
+	intp.Stack = append(intp.Stack, Real(p.X), Real(p.Y))
+	return nil
+}
+
+func bMoveto(intp *Interpreter) error {
+	x, err := intp.popNumbers("moveto", 2)
+	if err != nil {
+		return err
+	}
+	gs := intp.graphicsState()
+	return intp.moveTo(gs.CTM.Apply(vec.Vec2{X: x[0], Y: x[1]}))
+}
+
+func bRmoveto(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "rmoveto: no current point")
+	}
+	x, err := intp.popNumbers("rmoveto", 2)
+	if err != nil {
+		return err
+	}
+	d := linear(gs.CTM).Apply(vec.Vec2{X: x[0], Y: x[1]})
+	return intp.moveTo(gs.current.Add(d))
+}
+
+func bLineto(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "lineto: no current point")
+	}
+	x, err := intp.popNumbers("lineto", 2)
+	if err != nil {
+		return err
+	}
+	return intp.lineTo(gs.CTM.Apply(vec.Vec2{X: x[0], Y: x[1]}))
+}
+
+func bRlineto(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "rlineto: no current point")
+	}
+	x, err := intp.popNumbers("rlineto", 2)
+	if err != nil {
+		return err
+	}
+	d := linear(gs.CTM).Apply(vec.Vec2{X: x[0], Y: x[1]})
+	return intp.lineTo(gs.current.Add(d))
+}
+
+func bCurveto(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "curveto: no current point")
+	}
+	x, err := intp.popNumbers("curveto", 6)
+	if err != nil {
+		return err
+	}
+	return intp.curveTo(
+		gs.CTM.Apply(vec.Vec2{X: x[0], Y: x[1]}),
+		gs.CTM.Apply(vec.Vec2{X: x[2], Y: x[3]}),
+		gs.CTM.Apply(vec.Vec2{X: x[4], Y: x[5]}))
+}
+
+func bRcurveto(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "rcurveto: no current point")
+	}
+	x, err := intp.popNumbers("rcurveto", 6)
+	if err != nil {
+		return err
+	}
+	p := gs.current
+	L := linear(gs.CTM)
+	return intp.curveTo(
+		p.Add(L.Apply(vec.Vec2{X: x[0], Y: x[1]})),
+		p.Add(L.Apply(vec.Vec2{X: x[2], Y: x[3]})),
+		p.Add(L.Apply(vec.Vec2{X: x[4], Y: x[5]})))
+}
+
+func bArc(intp *Interpreter) error {
+	return intp.arc("arc", false)
+}
+
+func bArcn(intp *Interpreter) error {
+	return intp.arc("arcn", true)
+}
+
+// arc implements the `arc` and `arcn` operators.  The arc is approximated
+// by cubic Bézier curves, each spanning at most 90 degrees.
+func (intp *Interpreter) arc(op string, clockwise bool) error {
+	x, err := intp.popNumbers(op, 5)
+	if err != nil {
+		return err
+	}
+	cx, cy, r, ang1, ang2 := x[0], x[1], x[2], x[3], x[4]
+	sweep := ang2 - ang1
+	if math.IsInf(sweep, 0) || math.IsNaN(sweep) {
+		return intp.e(eRangecheck, "%s: invalid angle", op)
+	}
+	// For `arc`, ang2 is increased by multiples of 360 until it is at least
+	// ang1; for `arcn` it is decreased until it is at most ang1.  Arcs
+	// spanning more than a full circle are truncated to one full circle.
+	if clockwise {
+		if sweep > 0 {
+			sweep = math.Mod(sweep, 360) - 360
+		}
+		sweep = max(sweep, -360)
+	} else {
+		if sweep < 0 {
+			sweep = math.Mod(sweep, 360) + 360
+		}
+		sweep = min(sweep, 360)
+	}
+
+	gs := intp.graphicsState()
+	M := gs.CTM
+	point := func(phi float64) vec.Vec2 {
+		sin, cos := math.Sincos(phi * math.Pi / 180)
+		return vec.Vec2{X: cx + r*cos, Y: cy + r*sin}
+	}
+
+	start := M.Apply(point(ang1))
+	if gs.hasCurrent {
+		err = intp.lineTo(start)
+	} else {
+		err = intp.moveTo(start)
+	}
+	if err != nil {
+		return err
+	}
+
+	n := int(math.Ceil(math.Abs(sweep)/90 - 1e-9))
+	for i := range n {
+		a := ang1 + sweep*float64(i)/float64(n)
+		b := ang1 + sweep*float64(i+1)/float64(n)
+		k := 4.0 / 3.0 * math.Tan((b-a)*math.Pi/720)
+		sinA, cosA := math.Sincos(a * math.Pi / 180)
+		sinB, cosB := math.Sincos(b * math.Pi / 180)
+		p0 := point(a)
+		p3 := point(b)
+		p1 := vec.Vec2{X: p0.X - k*r*sinA, Y: p0.Y + k*r*cosA}
+		p2 := vec.Vec2{X: p3.X + k*r*sinB, Y: p3.Y - k*r*cosB}
+		err := intp.curveTo(M.Apply(p1), M.Apply(p2), M.Apply(p3))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bClosepath(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return nil
+	}
+	gs.Path.Close()
+	gs.current = gs.subpathStart
+	return nil
+}
+
+func bPathbbox(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if !gs.hasCurrent {
+		return intp.e(eNocurrentpoint, "pathbbox: no current point")
+	}
+	if !invertible(gs.CTM) {
+		return intp.e(eUndefinedresult, "pathbbox: singular CTM")
+	}
+	inv := gs.CTM.Inv()
+	bbox := gs.Path.Iter().Transform(inv).BBox()
+	intp.Stack = append(intp.Stack,
+		Real(bbox.LLx), Real(bbox.LLy), Real(bbox.URx), Real(bbox.URy))
+	return nil
+}
+
+func (intp *Interpreter) moveTo(p vec.Vec2) error {
+	gs := intp.graphicsState()
+	n := len(gs.Path.Cmds)
+	if n > 0 && gs.Path.Cmds[n-1] == path.CmdMoveTo {
+		// consecutive moveto operations replace each other
+		gs.Path.Coords[len(gs.Path.Coords)-1] = p
+	} else {
+		if err := intp.charge(pathPointSize); err != nil {
+			return err
+		}
+		gs.Path.MoveTo(p)
+	}
+	gs.current = p
+	gs.subpathStart = p
+	gs.hasCurrent = true
+	return nil
+}
+
+func (intp *Interpreter) lineTo(p vec.Vec2) error {
+	if err := intp.charge(pathPointSize); err != nil {
+		return err
+	}
+	gs := intp.graphicsState()
+	gs.beginSubpath()
+	gs.Path.LineTo(p)
+	gs.current = p
+	return nil
+}
+
+func (intp *Interpreter) curveTo(p1, p2, p3 vec.Vec2) error {
+	if err := intp.charge(3 * pathPointSize); err != nil {
+		return err
+	}
+	gs := intp.graphicsState()
+	gs.beginSubpath()
+	gs.Path.CubeTo(p1, p2, p3)
+	gs.current = p3
+	return nil
+}
+
+// beginSubpath makes sure that a new subpath is started after a closepath,
+// as described in the PLRM entry for `closepath`.
+func (gs *GraphicsState) beginSubpath() {
+	n := len(gs.Path.Cmds)
+	if n > 0 && gs.Path.Cmds[n-1] == path.CmdClose {
+		gs.Path.MoveTo(gs.current)
+		gs.subpathStart = gs.current
+	}
+}
+
+func bFill(intp *Interpreter) error {
+	return intp.fill(false)
+}
+
+func bEofill(intp *Interpreter) error {
+	return intp.fill(true)
+}
+
+func (intp *Interpreter) fill(evenOdd bool) error {
+	gs := intp.graphicsState()
+	if intp.Device != nil && len(gs.Path.Cmds) > 0 {
+		if err := intp.Device.Fill(gs, evenOdd); err != nil {
+			return err
+		}
+	}
+	gs.newPath()
+	return nil
+}
+
+func bStroke(intp *Interpreter) error {
+	gs := intp.graphicsState()
+	if intp.Device != nil && len(gs.Path.Cmds) > 0 {
+		if err := intp.Device.Stroke(gs); err != nil {
+			return err
+		}
+	}
+	gs.newPath()
+	return nil
+}
+
+func bRectfill(intp *Interpreter) error {
+	return intp.rectOp("rectfill", func() error { return intp.fill(false) })
+}
+
+func bRectstroke(intp *Interpreter) error {
+	return intp.rectOp("rectstroke", func() error { return bStroke(intp) })
+}
+
+// rectOp implements the `rectfill` and `rectstroke` operators.  The
+// rectangles are painted using a temporary path, leaving the current path
+// unchanged.
+func (intp *Interpreter) rectOp(op string, paint func() error) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	var rects []float64
+	if a, ok := intp.Stack[len(intp.Stack)-1].(Array); ok {
+		if len(a)%4 != 0 {
+			return intp.e(eRangecheck, "%s: array length %d is not a multiple of 4", op, len(a))
+		}
+		rects = make([]float64, len(a))
+		for i, obj := range a {
+			x, ok := getNumber(obj)
+			if !ok {
+				return intp.e(eTypecheck, "%s: invalid array element %T", op, obj)
+			}
+			rects[i] = x
+		}
+		intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	} else {
+		x, err := intp.popNumbers(op, 4)
+		if err != nil {
+			return err
+		}
+		rects = x
+	}
+
+	gs := intp.graphicsState()
+	savedPath, current, subpathStart, hasCurrent := gs.Path, gs.current, gs.subpathStart, gs.hasCurrent
+	defer func() {
+		gs.Path = savedPath
+		gs.current = current
+		gs.subpathStart = subpathStart
+		gs.hasCurrent = hasCurrent
+	}()
+	gs.newPath()
+	for i := 0; i < len(rects); i += 4 {
+		x, y, w, h := rects[i], rects[i+1], rects[i+2], rects[i+3]
+		corners := []vec.Vec2{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}}
+		for j, c := range corners {
+			var err error
+			if j == 0 {
+				err = intp.moveTo(gs.CTM.Apply(c))
+			} else {
+				err = intp.lineTo(gs.CTM.Apply(c))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		gs.Path.Close()
+		gs.current = gs.subpathStart
+	}
+	return paint()
+}
+
+func bClip(intp *Interpreter) error {
+	intp.clip(false)
+	return nil
+}
+
+func bEoclip(intp *Interpreter) error {
+	intp.clip(true)
+	return nil
+}
+
+// clip intersects the clipping path with the current path.  As described
+// in the PLRM, the current path is not cleared.
+func (intp *Interpreter) clip(evenOdd bool) {
+	gs := intp.graphicsState()
+	gs.Clip = append(gs.Clip, ClipPath{
+		Path:    clonePath(gs.Path),
+		EvenOdd: evenOdd,
+	})
+}
+
+func bInitclip(intp *Interpreter) error {
+	intp.graphicsState().Clip = nil
+	return nil
+}
+
+func bShowpage(intp *Interpreter) error {
+	if intp.Device != nil {
+		if err := intp.Device.ShowPage(); err != nil {
+			return err
+		}
+	}
+	intp.graphicsState().init(intp.Device)
+	return nil
+}
+
+// popNumbers removes n numeric operands from the top of the operand stack
+// and returns them in the order they were pushed.  If an error occurs, the
+// stack is left unchanged.
+func (intp *Interpreter) popNumbers(op string, n int) ([]float64, error) {
+	if len(intp.Stack) < n {
+		return nil, intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	res := make([]float64, n)
+	base := len(intp.Stack) - n
+	for i := range n {
+		x, ok := getNumber(intp.Stack[base+i])
+		if !ok {
+			return nil, intp.e(eTypecheck, "%s: needs a number, not %T", op, intp.Stack[base+i])
+		}
+		res[i] = x
+	}
+	intp.Stack = intp.Stack[:base]
+	return res, nil
+}
+
+// getNumber converts an Integer or Real to float64.
+func getNumber(obj Object) (float64, bool) {
+	switch x := obj.(type) {
+	case Integer:
+		return float64(x), true
+	case Real:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
+
+// getMatrixArray checks that obj is a matrix, i.e. an array of six numbers.
+func (intp *Interpreter) getMatrixArray(op string, obj Object) (Array, error) {
+	a, ok := obj.(Array)
+	if !ok {
+		return nil, intp.e(eTypecheck, "%s: needs a matrix, not %T", op, obj)
+	} else if len(a) != 6 {
+		return nil, intp.e(eRangecheck, "%s: matrix must have 6 elements, not %d", op, len(a))
+	}
+	return a, nil
+}
+
+// getMatrix converts a PostScript matrix to a [matrix.Matrix].
+func (intp *Interpreter) getMatrix(op string, obj Object) (matrix.Matrix, error) {
+	var M matrix.Matrix
+	a, err := intp.getMatrixArray(op, obj)
+	if err != nil {
+		return M, err
+	}
+	for i, elem := range a {
+		x, ok := getNumber(elem)
+		if !ok {
+			return M, intp.e(eTypecheck, "%s: invalid matrix element %T", op, elem)
+		}
+		M[i] = x
+	}
+	return M, nil
+}
+
+// setMatrixArray stores the coefficients of M in the six-element array a.
+func setMatrixArray(a Array, M matrix.Matrix) {
+	for i, x := range M {
+		a[i] = Real(x)
+	}
+}
+
+// linear returns the linear part of M, i.e. M without its translation.
+// Applying the result to a vector transforms a displacement rather than a
+// point.
+func linear(M matrix.Matrix) matrix.Matrix {
+	M[4], M[5] = 0, 0
+	return M
+}
+
+// invertible reports whether M can be inverted.  This must be checked before
+// calling M.Inv(), which panics for singular matrices.
+func invertible(M matrix.Matrix) bool {
+	det := M[0]*M[3] - M[1]*M[2]
+	return det != 0 && !math.IsNaN(det) && !math.IsInf(det, 0)
+}
@@ -0,0 +1,331 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// recordingDevice is a Device which records all painting operations.
+type recordingDevice struct {
+	fills   []recordedFill
+	strokes []*GraphicsState
+	pages   int
+}
+
+type recordedFill struct {
+	path    *path.Data
+	color   Color
+	evenOdd bool
+	clip    []ClipPath
+}
+
+func (d *recordingDevice) DefaultMatrix() matrix.Matrix {
+	return matrix.Identity
+}
+
+func (d *recordingDevice) Fill(gs *GraphicsState, evenOdd bool) error {
+	d.fills = append(d.fills, recordedFill{
+		path:    clonePath(gs.Path),
+		color:   gs.Color,
+		evenOdd: evenOdd,
+		clip:    gs.Clip,
+	})
+	return nil
+}
+
+func (d *recordingDevice) Stroke(gs *GraphicsState) error {
+	d.strokes = append(d.strokes, gs.clone())
+	return nil
+}
+
+func (d *recordingDevice) ShowPage() error {
+	d.pages++
+	return nil
+}
+
+func TestPathConstruction(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(`
+		2 3 scale 10 10 translate
+		newpath 0 0 moveto 10 0 rlineto 0 10 rlineto closepath
+		0.5 setgray fill`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dev.fills) != 1 {
+		t.Fatalf("got %d fills, expected 1", len(dev.fills))
+	}
+	fill := dev.fills[0]
+	expected := &path.Data{}
+	expected.MoveTo(vec.Vec2{X: 20, Y: 30})
+	expected.LineTo(vec.Vec2{X: 40, Y: 30})
+	expected.LineTo(vec.Vec2{X: 40, Y: 60})
+	expected.Close()
+	if d := cmp.Diff(fill.path, expected); d != "" {
+		t.Error(d)
+	}
+	if fill.color != (Color{Space: DeviceGray, Values: [4]float64{0.5}}) {
+		t.Errorf("wrong fill color %v", fill.color)
+	}
+	if fill.evenOdd {
+		t.Error("fill used the even-odd rule")
+	}
+	if len(intp.graphicsState().Path.Cmds) != 0 {
+		t.Error("fill did not clear the current path")
+	}
+}
+
+func TestGsaveGrestore(t *testing.T) {
+	intp, err := run(`
+		3 setlinewidth 1 0 0 setrgbcolor
+		gsave
+		7 setlinewidth 0 setgray 45 rotate
+		grestore
+		currentlinewidth currentrgbcolor`, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(intp.Stack, []Object{Real(3), Real(1), Real(0), Real(0)}); d != "" {
+		t.Error(d)
+	}
+	if intp.graphicsState().CTM != matrix.Identity {
+		t.Errorf("CTM not restored: %v", intp.graphicsState().CTM)
+	}
+}
+
+func TestCurrentpoint(t *testing.T) {
+	intp, err := run("2 2 scale 5 5 translate 3 4 moveto currentpoint", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{3, 4} {
+		got, _ := getNumber(intp.Stack[i])
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("currentpoint[%d] = %g, expected %g", i, got, want)
+		}
+	}
+}
+
+func TestNoCurrentPoint(t *testing.T) {
+	for _, code := range []string{
+		"newpath 1 2 lineto",
+		"newpath 1 2 rmoveto",
+		"newpath 1 2 3 4 5 6 curveto",
+		"newpath currentpoint",
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eNocurrentpoint {
+			t.Errorf("%q: expected nocurrentpoint, got %v", code, err)
+		}
+	}
+}
+
+func TestArc(t *testing.T) {
+	intp, err := run("newpath 0 0 10 0 180 arc currentpoint", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, _ := getNumber(intp.Stack[0])
+	y, _ := getNumber(intp.Stack[1])
+	if math.Abs(x+10) > 1e-9 || math.Abs(y) > 1e-9 {
+		t.Errorf("arc ends at (%g, %g), expected (-10, 0)", x, y)
+	}
+	p := intp.graphicsState().Path
+	if d := cmp.Diff(p.Cmds, []path.Command{path.CmdMoveTo, path.CmdCubeTo, path.CmdCubeTo}); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestStrokeParameters(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(`
+		4 setlinewidth 1 setlinecap 2 setlinejoin [3 1] 2 setdash
+		0 0 moveto 100 0 lineto stroke`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dev.strokes) != 1 {
+		t.Fatalf("got %d strokes, expected 1", len(dev.strokes))
+	}
+	gs := dev.strokes[0]
+	if gs.LineWidth != 4 || gs.LineCap != LineCapRound || gs.LineJoin != LineJoinBevel {
+		t.Errorf("wrong line parameters: %g %d %d", gs.LineWidth, gs.LineCap, gs.LineJoin)
+	}
+	if d := cmp.Diff(gs.Dash, []float64{3, 1}); d != "" || gs.DashPhase != 2 {
+		t.Errorf("wrong dash pattern: %v %g", gs.Dash, gs.DashPhase)
+	}
+}
+
+func TestClip(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(`
+		gsave
+		0 0 moveto 10 0 lineto 10 10 lineto closepath eoclip newpath
+		0 0 moveto 20 0 lineto 20 20 lineto fill
+		grestore
+		0 0 moveto 20 0 lineto 20 20 lineto fill`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dev.fills) != 2 {
+		t.Fatalf("got %d fills, expected 2", len(dev.fills))
+	}
+	if len(dev.fills[0].clip) != 1 || !dev.fills[0].clip[0].EvenOdd {
+		t.Errorf("wrong clip path for first fill: %v", dev.fills[0].clip)
+	}
+	if len(dev.fills[1].clip) != 0 {
+		t.Errorf("clip path not restored by grestore")
+	}
+}
+
+// TestRectfillError checks that the current path is left unchanged if the
+// temporary path used by `rectfill` cannot be constructed.
+func TestRectfillError(t *testing.T) {
+	intp := NewInterpreter()
+	err := intp.ExecuteString("1 2 moveto 3 4 lineto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := intp.graphicsState()
+	path := gs.Path
+	current := gs.current
+
+	rects := make(Array, 4*1000)
+	for i := range rects {
+		rects[i] = Integer(1)
+	}
+	intp.Stack = append(intp.Stack, rects)
+	intp.MaxMemory = 100 * pathPointSize
+	err = intp.ExecuteString("rectfill")
+	var psErr *postScriptError
+	if !errors.As(err, &psErr) || psErr.tp != eLimitcheck {
+		t.Fatalf("expected limitcheck, got %v", err)
+	}
+
+	gs = intp.graphicsState()
+	if gs.Path != path || gs.current != current || !gs.hasCurrent {
+		t.Error("current path not restored")
+	}
+}
+
+func TestShowpage(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString("5 setlinewidth showpage showpage currentlinewidth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.pages != 2 {
+		t.Errorf("got %d pages, expected 2", dev.pages)
+	}
+	if d := cmp.Diff(intp.Stack, []Object{Real(1)}); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestInvertible(t *testing.T) {
+	for _, test := range []struct {
+		M        matrix.Matrix
+		expected bool
+	}{
+		{matrix.Matrix{2, 1, -1, 3, 5, 7}, true},
+		{matrix.Matrix{1, 2, 2, 4, 0, 0}, false},
+		{matrix.Matrix{0, 0, 0, 0, 1, 1}, false},
+		{matrix.Matrix{math.NaN(), 0, 0, 1, 0, 0}, false},
+		{matrix.Matrix{math.Inf(1), 0, 0, 1, 0, 0}, false},
+	} {
+		if got := invertible(test.M); got != test.expected {
+			t.Errorf("invertible(%v) = %t, expected %t", test.M, got, test.expected)
+		}
+	}
+}
+
+func TestMatrixOperators(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected []float64
+	}{
+		{"[2 0 0 2 1 1] identmatrix {} forall", []float64{1, 0, 0, 1, 0, 0}},
+		{"[2 0 0 3 0 0] [1 0 0 1 5 7] 6 array concatmatrix {} forall", []float64{2, 0, 0, 3, 5, 7}},
+		{"[1 0 0 1 5 7] [2 0 0 3 0 0] 6 array concatmatrix {} forall", []float64{2, 0, 0, 3, 10, 21}},
+		{"[2 0 0 4 6 8] 6 array invertmatrix {} forall", []float64{0.5, 0, 0, 0.25, -3, -2}},
+		{"1 2 [2 0 0 3 5 7] transform", []float64{7, 13}},
+		{"7 13 [2 0 0 3 5 7] itransform", []float64{1, 2}},
+		{"1 2 [2 0 0 3 5 7] dtransform", []float64{2, 6}},
+		{"2 6 [2 0 0 3 5 7] idtransform", []float64{1, 2}},
+		{"2 2 scale 10 20 translate 1 1 transform", []float64{22, 42}},
+		{"2 2 scale 10 20 translate 22 42 itransform", []float64{1, 1}},
+		{"2 2 scale 10 20 translate 1 1 dtransform", []float64{2, 2}},
+		{"2 2 scale 10 20 translate 2 2 idtransform", []float64{1, 1}},
+	} {
+		intp, err := run(test.code, len(test.expected))
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		for i, want := range test.expected {
+			got, _ := getNumber(intp.Stack[i])
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("%q: result %d is %g, expected %g", test.code, i, got, want)
+			}
+		}
+	}
+}
+
+func TestMatrixOperatorErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"[1 2 2 4 0 0] 6 array invertmatrix", eUndefinedresult},
+		{"1 1 [0 0 0 0 0 0] itransform", eUndefinedresult},
+		{"0 0 scale 1 1 idtransform", eUndefinedresult},
+		{"1 1 [1 0 0 1] transform", eRangecheck},
+		{"(a) 1 transform", eTypecheck},
+		{"1 matrix transform", eStackunderflow},
+		{"matrix matrix 5 array concatmatrix", eRangecheck},
+		{"matrix [1 0 0 1 0 (x)] matrix concatmatrix", eTypecheck},
+		{"matrix readonly identmatrix", eInvalidaccess},
+		{"matrix readonly currentmatrix", eInvalidaccess},
+		{"matrix readonly defaultmatrix", eInvalidaccess},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
@@ -17,10 +17,13 @@
 package postscript
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"maps"
 	"strings"
 
+	"seehuhn.de/go/geom/vec"
 	"seehuhn.de/go/membudget"
 )
 
@@ -36,6 +39,12 @@ type Interpreter struct {
 	// returned.
 	MaxOps int
 
+	// MaxExecStack can be set to a positive value to limit the depth of
+	// the execution stack.  If this limit is exceeded, an
+	// execstackoverflow error is raised.  If MaxExecStack is zero, a
+	// default limit of 5000 is used.
+	MaxExecStack int
+
 	// MaxMemory can be set to a positive value to limit the total number of
 	// bytes allocated by object-creating operators.  If this limit is
 	// exceeded, a limitcheck error is returned.
@@ -71,6 +80,24 @@ type Interpreter struct {
 	// for each category.
 	Resources Dict
 
+	// ResourceProvider, if not nil, is used by `findresource`, `findfont`
+	// and `selectfont` to load resources which are not yet defined.
+	ResourceProvider ResourceProvider
+
+	// Fontmap, if not nil, is used by `findfont` and `selectfont` to locate
+	// fonts which are neither defined nor available from ResourceProvider.
+	Fontmap *Fontmap
+
+	// DefaultFont, if not empty, is the name of the font which `findfont`
+	// and `selectfont` use in place of fonts which cannot be found.
+	DefaultFont Name
+
+	// FontSubstitutions lists the fonts which were used in place of
+	// requested fonts, either because of an alias in Fontmap, or because
+	// DefaultFont was used.  Each pair of requested and used font is listed
+	// once, in the order of first use.
+	FontSubstitutions []FontSubstitution
+
 	// FontDirectory is the PostScript font directory.
 	// The `definefont` PostScript operator adds fonts to this dictionary.
 	FontDirectory Dict
@@ -88,15 +115,65 @@ type Interpreter struct {
 	// object, which contains the mapping data.
 	CMapDirectory Dict
 
+	// DefineResource, if not nil, is called by `definefont` and
+	// `defineresource` for every resource instance which is defined.  The
+	// arguments are the resource category, for example "Font" or "CMap",
+	// the key, and the instance.  Since the function sees every instance,
+	// this can be used to capture resources which are later removed again,
+	// for example by `restore`.
+	DefineResource func(category, key Name, instance Object)
+
 	// DSC contains all DSC comments found in the input so far.
 	// These are comments of the form "%%key: value" or "%%key".
 	DSC []Comment
 
+	// Stdout, if not nil, receives the output of `print`, `=`, `==`,
+	// `stack` and `pstack`, as well as the error reports written by
+	// `handleerror`.  If Stdout has a Flush method, this is called by
+	// `flush`.  If Stdout is nil, all output is discarded.
+	Stdout io.Writer
+
+	// Device receives the output of the painting operators.  If this is
+	// nil, painting operators only update the graphics state.
+	// This field must be set before the first call to Execute.
+	Device Device
+
+	// gstate is the current graphics state, and gstack holds the states
+	// saved by `gsave`.
+	gstate *GraphicsState
+	gstack []*GraphicsState
+
+	// access holds the access attributes of composite objects with
+	// restricted access.  When the map reaches accessPrune entries, the
+	// entries for objects which have been garbage collected are removed.
+	access      map[accessKey]accessEntry
+	accessPrune int
+
+	// saves holds the active snapshots created by `save`, innermost last.
+	saves []*vmSnapshot
+
+	// glyphWidth receives the glyph width while the BuildGlyph or BuildChar
+	// procedure of a Type 3 font is executed, and is nil otherwise.
+	glyphWidth *vec.Vec2
+
+	// errors holds the errors whose handlers are currently executing, and
+	// lastError is the error most recently recorded in $error.
 	errors    []*postScriptError
+	lastError *postScriptError
+
+	// estack is the execution stack, and runDepth is the number of
+	// active calls to run.
+	estack   []execFrame
+	runDepth int
+
+	// freeProcs holds unused frames for executing procedures.
+	freeProcs []*procFrame
+
 	scanners  []*scanner
 	procStart []int
 
-	execStackDepth int
+	// opCall describes the Go operator which is currently executing, if any.
+	opCall *operatorCall
 
 	// These variables hold temporary data while a `begincmap` ... `endcmap`
 	// block is being executed.
@@ -117,6 +194,9 @@ func NewInterpreter() *Interpreter {
 		"Font":    fontDirectory,
 		"CIDFont": Dict{},
 		"CMap":    cmapDirectory,
+		"Encoding": Dict{
+			"StandardEncoding": systemDict["StandardEncoding"],
+		},
 		"ProcSet": Dict{
 			"CIDInit": maps.Clone(cidInit),
 		},
@@ -139,6 +219,10 @@ func NewInterpreter() *Interpreter {
 	for _, name := range allErrors {
 		intp.ErrorDict[name] = defaultErrorHandler
 	}
+	intp.ErrorDict[eHandleerror] = builtin(defaultHandleerror)
+
+	intp.restrictAccess("", systemDict, accessReadOnly)
+	intp.restrictAccess("", systemDict["StandardEncoding"], accessReadOnly)
 
 	return intp
 }
@@ -155,23 +239,6 @@ func (intp *Interpreter) Execute(r io.Reader) error {
 	}
 
 	s := newScanner(r)
-	err := intp.executeScanner(s)
-	switch err {
-	case errExit:
-		err = intp.e(eInvalidexit, "exit outside loop")
-	case errStop:
-		err = nil
-	}
-	if err != nil {
-		return err
-	}
-
-	intp.DSC = append(intp.DSC, s.DSC...)
-
-	return nil
-}
-
-func (intp *Interpreter) executeScanner(s *scanner) error {
 	if intp.CheckStart {
 		head := s.PeekN(2)
 		if string(head) != "%!" {
@@ -184,120 +251,91 @@ func (intp *Interpreter) executeScanner(s *scanner) error {
 		intp.CheckStart = false
 	}
 
-	intp.scanners = append(intp.scanners, s)
-	defer func() {
-		intp.scanners = intp.scanners[:len(intp.scanners)-1]
-	}()
-
-	for {
-		o, err := s.ScanToken()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return err
-		}
-		err = intp.executeOne(o, false)
-		if err != nil {
-			return err
-		}
+	base := len(intp.estack)
+	err := intp.pushFile(s, nil)
+	if err == nil {
+		err = intp.run(base)
 	}
-	return nil
-}
-
-func (intp *Interpreter) executeOne(obj Object, execProc bool) error {
-	if execProc {
-		if intp.execStackDepth >= 100 {
-			return intp.e(eExecstackoverflow, "exec stack overflow")
+	switch err {
+	case errExit:
+		err = intp.e(eInvalidexit, "exit outside loop")
+	case errStop:
+		// If the `stop` was caused by an error, the error is reported
+		// using `handleerror`, and returned to the caller.
+		err = nil
+		errorState := intp.SystemDict["$error"].(Dict)
+		if errorState["newerror"] == Boolean(true) {
+			err = intp.lastError
+			if handler, ok := intp.ErrorDict[eHandleerror]; ok {
+				if herr := intp.handlerError(intp.execute(handler), err); herr != nil {
+					err = fmt.Errorf("%w (handleerror failed: %w)", err, herr)
+				}
+			}
+			intp.saveDictEntry(errorState, "newerror")
+			errorState["newerror"] = Boolean(false)
 		}
-		intp.execStackDepth++
-		defer func() { intp.execStackDepth-- }()
 	}
-
-	if len(intp.Stack) > maxOperandStackDepth {
-		return intp.e(eStackoverflow, "operand stack overflow")
+	if err != nil {
+		return err
 	}
 
-	if obj == Operator("}") {
-		if len(intp.procStart) == 0 {
-			return intp.e(eSyntaxerror, "unmatched '}'")
-		}
-		a := intp.procStart[len(intp.procStart)-1]
-		intp.procStart = intp.procStart[:len(intp.procStart)-1]
-		b := len(intp.Stack)
-		if err := intp.charge((b - a) * objectSize); err != nil {
-			return err
-		}
-		proc := make(Procedure, b-a)
-		copy(proc, intp.Stack[a:])
-		intp.Stack = append(intp.Stack[:a], proc)
-		return nil
-	} else if obj == Operator("{") {
-		intp.procStart = append(intp.procStart, len(intp.Stack))
-		return nil
-	} else if len(intp.procStart) > 0 {
-		intp.Stack = append(intp.Stack, obj)
-		return nil
-	}
+	intp.DSC = append(intp.DSC, s.DSC...)
 
-	// quax := true
-recurseTail:
-	// if !quax {
-	// 	fmt.Println("|-", intp.stackString(), "|", intp.objectString(obj))
-	// }
-	// quax = false
+	return nil
+}
 
-	intp.NumOps++
-	if intp.MaxOps > 0 && intp.NumOps > intp.MaxOps {
-		return ErrExecutionLimitExceeded
+// handlerError converts the result of running `handleerror` into an error
+// which can be returned to the caller of Execute.  If the handler raised an
+// error itself, this error replaces intp.lastError, which is different from
+// the error being reported, orig.
+func (intp *Interpreter) handlerError(err, orig error) error {
+	switch err {
+	case errStop:
+		if intp.lastError != orig {
+			return intp.lastError
+		}
+		return errors.New("stop outside stopped context")
+	case errExit:
+		return intp.e(eInvalidexit, "exit outside loop")
 	}
+	return err
+}
 
-	switch o := obj.(type) {
-	case Operator:
-		val, err := intp.load(o)
+// resolveImmediate replaces the immediately evaluated names in a token
+// returned by the scanner by their values.  If a name is not defined, the
+// `undefined` error handler is called with the name as the offending
+// command.  If the handler returns normally, ok is false and the token
+// is discarded.
+func (intp *Interpreter) resolveImmediate(obj Object) (res Object, ok bool, err error) {
+	switch obj := obj.(type) {
+	case immediateName:
+		val, err := intp.load(Name(obj))
 		if err != nil {
-			return err
+			return nil, false, intp.handleError(err, Name(obj))
 		}
-		obj = val
-		execProc = true
-		goto recurseTail
-
-	case builtin:
-		err := o(intp)
-		if e2, ok := err.(*postScriptError); ok {
-			level := len(intp.errors)
-			if level < 5 {
-				intp.errors = append(intp.errors, e2)
-				if proc, ok := intp.ErrorDict[e2.tp]; ok {
-					err = intp.executeOne(proc, true)
-				}
-				intp.errors = intp.errors[:level]
-			}
-		}
-		return err
-
+		return val, true, nil
+	case binarySequence:
+		return intp.resolveImmediateElems(obj, []Object(obj))
+	case Array:
+		return intp.resolveImmediateElems(obj, []Object(obj))
 	case Procedure:
-		if execProc {
-			if len(o) == 0 {
-				return nil
-			}
+		return intp.resolveImmediateElems(obj, []Object(obj))
+	default:
+		return obj, true, nil
+	}
+}
 
-			// use tail recursion
-			for _, token := range o[:len(o)-1] {
-				err := intp.executeOne(token, false)
-				if err != nil {
-					return err
-				}
-			}
-			obj = o[len(o)-1]
-			goto recurseTail
-		} else {
-			intp.Stack = append(intp.Stack, o)
+// resolveImmediateElems resolves the immediately evaluated names in the
+// elements of an array which was decoded from a binary object sequence.
+func (intp *Interpreter) resolveImmediateElems(obj Object, elems []Object) (Object, bool, error) {
+	for i, elem := range elems {
+		val, ok, err := intp.resolveImmediate(elem)
+		if err != nil || !ok {
+			return nil, ok, err
 		}
-
-	default:
-		intp.Stack = append(intp.Stack, o)
+		elems[i] = val
 	}
-	return nil
+	return obj, true, nil
 }
 
 func (intp *Interpreter) load(key Object) (Object, error) {
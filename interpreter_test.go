@@ -17,6 +17,7 @@
 package postscript
 
 import (
+	"errors"
 	"io"
 	"maps"
 	"slices"
@@ -116,6 +117,17 @@ func FuzzInterpreter(f *testing.F) {
 	f.Add("/a {a} def a")
 	f.Add("/a {{}} def userdict /a get dup 0 exch put a")
 	f.Add("/a {{} {}} def userdict /a get dup 0 exch put userdict /a get dup 1 exch put a")
+	f.Add("/F << /FontType 3 /FontMatrix [1 0 0 1 0 0] /Encoding [/a] /BuildGlyph {pop pop 1 0 setcharwidth 0 0 moveto 1 1 lineto fill} >> definefont setfont 0 0 moveto (\000\000) show")
+	f.Add("/a [1 2] def save a 0 3 put /b 2 def restore a")
+	f.Add("errordict /typecheck {pop} put {1 (a) add} stopped $error /ostack get")
+	f.Add("/x 1 def {//x //add} //systemdict /x known")
+	f.Add("currentfile /ASCII85Decode filter cvx exec\n1B~> (4142>) /ASCIIHexDecode filter 10 string readstring")
+	f.Add("/f {(f cvx exec)} 0 () /SubFileDecode filter def f cvx exec")
+	f.Add("(a{b}c) token pop (b) search pop 1.5 10 string cvs 255 16 8 string cvrs cvn")
+	f.Add("/a [0 (x\\n)] def a 0 a put a == 1 2 pstack stack (done) print flush")
+	f.Add("/f { dup 0 gt { 1 sub f 0 } if } def 300 f countexecstack 10 array execstack")
+	f.Add("[ (*) { dup length string copy } 20 string /Font resourceforall ] /a /ProcSet resourcestatus /a 1 /ProcSet defineresource /a /ProcSet undefineresource")
+	f.Add("3 4 \x81\x01\x0c\x00\x83\x00\x00\x00\x01\x00\x00\x00 \x95\x20\x00\x02\x00\x01\x00\x02")
 	builtins := slices.Sorted(maps.Keys(makeSystemDict()))
 	for _, name := range builtins {
 		f.Add("1 [2 3] (four) " + string(name))
@@ -136,3 +148,44 @@ func FuzzInterpreter(f *testing.F) {
 		t.Errorf("%#v", err)
 	})
 }
+
+func TestImmediateNames(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected []Object
+	}{
+		{"1 2 //add", []Object{Integer(3)}},
+		{"/x 5 def {//x} /x 6 def exec", []Object{Integer(5)}},
+		{"/x 5 def {/x //x} 1 get", []Object{Integer(5)}},
+		{"//systemdict /add known", []Object{Boolean(true)}},
+		{"/p {1} def {//p} 0 get xcheck", []Object{Boolean(true)}},
+		{"errordict /undefined {pop /handled} put 1 //nope 2", []Object{Integer(1), Name("handled"), Integer(2)}},
+		// binary object sequence with an immediately evaluated name
+		{"/x 5 def \x81\x01\x0d\x00\x06\x00\x01\x00\x08\x00\x00\x00x", []Object{Integer(5)}},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if d := cmp.Diff(intp.Stack, test.expected); d != "" {
+			t.Errorf("%q: %s", test.code, d)
+		}
+	}
+}
+
+func TestImmediateNameUndefined(t *testing.T) {
+	for _, code := range []string{
+		"//nope",
+		"{ //nope } pop",
+		"/x 5 def \x81\x01\x0d\x00\x06\x00\x01\x00\x08\x00\x00\x00y",
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eUndefined {
+			t.Errorf("%q: expected undefined, got %v", code, err)
+		}
+	}
+}
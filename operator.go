@@ -0,0 +1,216 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import "fmt"
+
+// An OperatorFunc implements a PostScript operator in Go.  The function
+// takes its operands from the operand stack, for example using
+// [Interpreter.Pop] or [Interpreter.PopInteger], and stores its results
+// using [Interpreter.Push].
+//
+// PostScript errors are signalled by returning an error created by
+// [Errorf].  In this case, the operands removed by the Pop methods are
+// restored before the error handler from errordict is called, so that
+// PostScript code sees the operand stack as it was before the operator
+// was invoked.  Any other error aborts execution and is returned by
+// [Interpreter.Execute].
+type OperatorFunc func(intp *Interpreter) error
+
+// A GoOperator is a PostScript operator which is implemented in Go.
+// GoOperators are created using [NewOperator].
+type GoOperator struct {
+	name Name
+	fn   OperatorFunc
+}
+
+// NewOperator returns a new PostScript operator which executes fn.
+// The name is used by `==` and in error reports.
+//
+// The operator can be stored in any dictionary.  When executed by name,
+// it behaves like the built-in operators.
+func NewOperator(name Name, fn OperatorFunc) *GoOperator {
+	return &GoOperator{name: name, fn: fn}
+}
+
+// Name returns the name of the operator.
+func (op *GoOperator) Name() Name {
+	return op.name
+}
+
+func (op *GoOperator) String() string {
+	return "--" + string(op.name) + "--"
+}
+
+// RegisterOperator adds a new operator to the system dictionary.
+// An existing entry with the same name is replaced.
+//
+// This bypasses the read-only attribute of systemdict, which only applies
+// to PostScript code.  Like other changes to the VM, the new entry is
+// removed again by a `restore` to a save made before the call.
+func (intp *Interpreter) RegisterOperator(name Name, fn OperatorFunc) {
+	intp.saveDictEntry(intp.SystemDict, name)
+	intp.SystemDict[name] = NewOperator(name, fn)
+}
+
+// Errorf returns a new PostScript error of the given type.  The type is
+// the name of the corresponding handler in errordict, for example
+// "typecheck" or "rangecheck".
+func Errorf(tp Name, format string, a ...any) error {
+	return &postScriptError{tp, fmt.Sprintf(format, a...)}
+}
+
+// operatorCall records the operands removed by an OperatorFunc, so that
+// these can be restored if the function fails.
+type operatorCall struct {
+	op *GoOperator
+
+	// low is the smallest height of the operand stack seen so far, and
+	// popped holds the operands removed below the original height, topmost
+	// first.
+	low    int
+	popped []Object
+}
+
+// callOperator executes a Go operator.
+func (intp *Interpreter) callOperator(op *GoOperator) error {
+	call := &operatorCall{op: op, low: len(intp.Stack)}
+	outer := intp.opCall
+	intp.opCall = call
+	err := op.fn(intp)
+	intp.opCall = outer
+
+	if _, isPSError := err.(*postScriptError); isPSError && len(intp.Stack) >= call.low {
+		intp.Stack = intp.Stack[:call.low]
+		for i := len(call.popped) - 1; i >= 0; i-- {
+			intp.Stack = append(intp.Stack, call.popped[i])
+		}
+	}
+	return err
+}
+
+// opName returns the name of the Go operator which is currently executing,
+// for use in error messages.  Outside of Go operators, the neutral name
+// "operator" is used.
+func (intp *Interpreter) opName() string {
+	if intp.opCall == nil {
+		return "operator"
+	}
+	return string(intp.opCall.op.name)
+}
+
+// Push pushes objects onto the operand stack.
+func (intp *Interpreter) Push(objs ...Object) {
+	intp.Stack = append(intp.Stack, objs...)
+}
+
+// Pop removes the topmost object from the operand stack.
+// If the stack is empty, a stackunderflow error is returned.
+func (intp *Interpreter) Pop() (Object, error) {
+	n := len(intp.Stack)
+	if n == 0 {
+		return nil, intp.e(eStackunderflow, "%s: not enough arguments", intp.opName())
+	}
+	obj := intp.Stack[n-1]
+	intp.Stack = intp.Stack[:n-1]
+
+	if call := intp.opCall; call != nil && n == call.low {
+		call.popped = append(call.popped, obj)
+		call.low--
+	}
+	return obj, nil
+}
+
+// popAs removes the topmost object from the operand stack, if it has type
+// T.  Otherwise a typecheck error is returned and the stack is left
+// unchanged.  If readable is true, the object must also be readable.
+func popAs[T Object](intp *Interpreter, what string, readable bool) (T, error) {
+	var zero T
+	if len(intp.Stack) == 0 {
+		return zero, intp.e(eStackunderflow, "%s: not enough arguments", intp.opName())
+	}
+	val, ok := intp.Stack[len(intp.Stack)-1].(T)
+	if !ok {
+		return zero, intp.e(eTypecheck, "%s: expected %s, got %T",
+			intp.opName(), what, intp.Stack[len(intp.Stack)-1])
+	}
+	if readable {
+		if err := intp.checkRead(intp.opName(), val); err != nil {
+			return zero, err
+		}
+	}
+	intp.Pop()
+	return val, nil
+}
+
+// PopInteger removes an integer from the top of the operand stack.
+func (intp *Interpreter) PopInteger() (Integer, error) {
+	return popAs[Integer](intp, "integer", false)
+}
+
+// PopNumber removes an integer or a real number from the top of the
+// operand stack.
+func (intp *Interpreter) PopNumber() (float64, error) {
+	if len(intp.Stack) > 0 {
+		if x, ok := intp.Stack[len(intp.Stack)-1].(Integer); ok {
+			intp.Pop()
+			return float64(x), nil
+		}
+	}
+	x, err := popAs[Real](intp, "number", false)
+	return float64(x), err
+}
+
+// PopBoolean removes a boolean from the top of the operand stack.
+func (intp *Interpreter) PopBoolean() (Boolean, error) {
+	return popAs[Boolean](intp, "boolean", false)
+}
+
+// PopName removes a name from the top of the operand stack.
+// Both literal and executable names are accepted.
+func (intp *Interpreter) PopName() (Name, error) {
+	if len(intp.Stack) > 0 {
+		if op, ok := intp.Stack[len(intp.Stack)-1].(Operator); ok {
+			intp.Pop()
+			return Name(op), nil
+		}
+	}
+	return popAs[Name](intp, "name", false)
+}
+
+// PopString removes a string from the top of the operand stack.
+// An invalidaccess error is returned if the string is not readable.
+func (intp *Interpreter) PopString() (String, error) {
+	return popAs[String](intp, "string", true)
+}
+
+// PopArray removes an array from the top of the operand stack.
+// An invalidaccess error is returned if the array is not readable.
+func (intp *Interpreter) PopArray() (Array, error) {
+	return popAs[Array](intp, "array", true)
+}
+
+// PopProcedure removes a procedure from the top of the operand stack.
+func (intp *Interpreter) PopProcedure() (Procedure, error) {
+	return popAs[Procedure](intp, "procedure", false)
+}
+
+// PopDict removes a dictionary from the top of the operand stack.
+// An invalidaccess error is returned if the dictionary is not readable.
+func (intp *Interpreter) PopDict() (Dict, error) {
+	return popAs[Dict](intp, "dictionary", true)
+}
@@ -0,0 +1,166 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// repeatString implements `string n repeatstring string`.
+func repeatString(intp *Interpreter) error {
+	n, err := intp.PopInteger()
+	if err != nil {
+		return err
+	}
+	s, err := intp.PopString()
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return Errorf("rangecheck", "repeatstring: negative count %d", n)
+	}
+	intp.Push(String(bytes.Repeat(s, int(n))))
+	return nil
+}
+
+func TestRegisterOperator(t *testing.T) {
+	intp := NewInterpreter()
+	intp.RegisterOperator("repeatstring", repeatString)
+	err := intp.ExecuteString(`
+		(ab) 3 repeatstring
+		/repeatstring load type
+		/repeatstring load xcheck
+		{ (x) 2 repeatstring } bind exec
+		/repeatstring load /repeatstring load eq`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{
+		String("ababab"),
+		Name("operatortype"),
+		Boolean(true),
+		String("xx"),
+		Boolean(true),
+	}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestOperatorInDict(t *testing.T) {
+	intp := NewInterpreter()
+	hooks := Dict{
+		"answer": NewOperator("answer", func(intp *Interpreter) error {
+			intp.Push(Integer(42))
+			return nil
+		}),
+	}
+	intp.UserDict["hooks"] = hooks
+	buf := &bytes.Buffer{}
+	intp.Stdout = buf
+	err := intp.ExecuteString("hooks begin answer /answer load == end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 42)
+	if got := buf.String(); got != "--answer--\n" {
+		t.Errorf("wrong output %q", got)
+	}
+}
+
+func TestOperatorErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"(ab) (3) repeatstring", eTypecheck},
+		{"3 3 repeatstring", eTypecheck},
+		{"(ab) -1 repeatstring", eRangecheck},
+		{"3 repeatstring", eStackunderflow},
+		{"(ab) noaccess 2 repeatstring", eInvalidaccess},
+	} {
+		intp := NewInterpreter()
+		intp.RegisterOperator("repeatstring", repeatString)
+		err := intp.ExecuteString(test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
+
+func TestOperatorErrorRestoresOperands(t *testing.T) {
+	intp := NewInterpreter()
+	intp.RegisterOperator("repeatstring", repeatString)
+	err := intp.ExecuteString("(ab) -1 { repeatstring } stopped $error /errorname get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{String("ab"), Integer(-1), Boolean(true), Name(eRangecheck)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+
+	buf := &bytes.Buffer{}
+	intp.Stdout = buf
+	intp.ExecuteString("repeatstring")
+	if !strings.Contains(buf.String(), "OffendingCommand: repeatstring") {
+		t.Errorf("wrong error report %q", buf.String())
+	}
+}
+
+func TestOperatorGoError(t *testing.T) {
+	// Errors which are not PostScript errors cannot be caught by `stopped`.
+	errHook := errors.New("hook failed")
+	intp := NewInterpreter()
+	intp.RegisterOperator("fail", func(intp *Interpreter) error {
+		return errHook
+	})
+	err := intp.ExecuteString("{ fail } stopped")
+	if err != errHook {
+		t.Errorf("expected %v, got %v", errHook, err)
+	}
+}
+
+func TestPopOutsideOperator(t *testing.T) {
+	intp := NewInterpreter()
+	_, err := intp.PopInteger()
+	if err == nil || !strings.HasPrefix(err.Error(), "stackunderflow: operator:") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRegisterOperatorRestore(t *testing.T) {
+	intp := NewInterpreter()
+	err := intp.ExecuteString("save")
+	if err != nil {
+		t.Fatal(err)
+	}
+	intp.RegisterOperator("repeatstring", repeatString)
+	err = intp.ExecuteString("systemdict /repeatstring known exch restore systemdict /repeatstring known")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]Object{Boolean(true), Boolean(false)}, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
@@ -0,0 +1,205 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"strconv"
+	"strings"
+)
+
+// write writes data to intp.Stdout.  If Stdout is nil, the data is
+// discarded.
+func (intp *Interpreter) write(op string, data []byte) error {
+	if intp.Stdout == nil {
+		return nil
+	}
+	_, err := intp.Stdout.Write(data)
+	if err != nil {
+		return intp.e(eIoerror, "%s: %v", op, err)
+	}
+	return nil
+}
+
+func bPrint(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "print: not enough arguments")
+	}
+	s, ok := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "print: needs a string, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	if err := intp.checkRead("print", s); err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	return intp.write("print", s)
+}
+
+// bEqual implements the `=` operator.
+func bEqual(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "=: not enough arguments")
+	}
+	obj := intp.Stack[len(intp.Stack)-1]
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	return intp.write("=", []byte(cvsText(obj)+"\n"))
+}
+
+// bEqualEqual implements the `==` operator.
+func bEqualEqual(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "==: not enough arguments")
+	}
+	obj := intp.Stack[len(intp.Stack)-1]
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	return intp.write("==", []byte(Format(obj)+"\n"))
+}
+
+func bStack(intp *Interpreter) error {
+	var buf strings.Builder
+	for i := len(intp.Stack) - 1; i >= 0; i-- {
+		buf.WriteString(cvsText(intp.Stack[i]))
+		buf.WriteByte('\n')
+	}
+	return intp.write("stack", []byte(buf.String()))
+}
+
+func bPstack(intp *Interpreter) error {
+	var buf strings.Builder
+	for i := len(intp.Stack) - 1; i >= 0; i-- {
+		buf.WriteString(Format(intp.Stack[i]))
+		buf.WriteByte('\n')
+	}
+	return intp.write("pstack", []byte(buf.String()))
+}
+
+func bFlush(intp *Interpreter) error {
+	if f, ok := intp.Stdout.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return intp.e(eIoerror, "flush: %v", err)
+		}
+	}
+	return nil
+}
+
+// maxSyntaxText is the approximate maximal length of the representation
+// of a single object produced by `==` and `pstack`.  Longer output is
+// truncated.
+const maxSyntaxText = 65536
+
+// Format returns the representation of obj used by the `==` and `pstack`
+// operators.  Where possible, this is the PostScript syntax for the object.
+// Deeply nested arrays and very long output are truncated.
+func Format(obj Object) string {
+	var buf strings.Builder
+	writeSyntax(&buf, obj, 0)
+	return buf.String()
+}
+
+func writeSyntax(buf *strings.Builder, obj Object, depth int) {
+	if buf.Len() > maxSyntaxText {
+		return
+	}
+
+	switch obj := obj.(type) {
+	case nil:
+		buf.WriteString("null")
+	case Integer, Real, Boolean:
+		buf.WriteString(cvsText(obj))
+	case String:
+		writeStringSyntax(buf, obj)
+	case Name:
+		buf.WriteByte('/')
+		buf.WriteString(string(obj))
+	case Operator:
+		buf.WriteString(string(obj))
+	case Array:
+		writeArraySyntax(buf, "[", obj, "]", depth)
+	case Procedure:
+		writeArraySyntax(buf, "{", obj, "}", depth)
+	case builtin, *GoOperator:
+		buf.WriteString("--" + commandName(obj) + "--")
+	case Dict:
+		buf.WriteString("-dict-")
+	case mark:
+		buf.WriteString("-mark-")
+	case *file:
+		buf.WriteString("-file-")
+	case *fontID:
+		buf.WriteString("-fontID-")
+	case *vmSnapshot:
+		buf.WriteString("-save-")
+	default:
+		buf.WriteString("--nostringval--")
+	}
+}
+
+// maxSyntaxDepth is the maximal nesting depth of arrays shown by `==`.
+// This also protects against arrays which contain themselves.
+const maxSyntaxDepth = 20
+
+func writeArraySyntax(buf *strings.Builder, open string, elems []Object, close string, depth int) {
+	buf.WriteString(open)
+	if depth >= maxSyntaxDepth {
+		buf.WriteString("...")
+	} else {
+		for i, elem := range elems {
+			if buf.Len() > maxSyntaxText {
+				buf.WriteString(" ...")
+				break
+			}
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			writeSyntax(buf, elem, depth+1)
+		}
+	}
+	buf.WriteString(close)
+}
+
+// writeStringSyntax writes s as a PostScript string literal.  Parentheses
+// and backslashes are escaped, and non-printable characters are written
+// as escape sequences.
+func writeStringSyntax(buf *strings.Builder, s String) {
+	buf.WriteByte('(')
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			if c < 32 || c >= 127 {
+				buf.WriteByte('\\')
+				oct := strconv.FormatUint(uint64(c), 8)
+				buf.WriteString(strings.Repeat("0", 3-len(oct)) + oct)
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	}
+	buf.WriteByte(')')
+}
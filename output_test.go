@@ -0,0 +1,107 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected string
+	}{
+		{"(hello) print", "hello"},
+		{"(a\\nb) =", "a\nb\n"},
+		{"1 = 2.0 = /x = /add load =", "1\n2.0\nx\nadd\n"},
+		{"[1 2] =", "--nostringval--\n"},
+		{"(a\\(b\\)\\\\) ==", "(a\\(b\\)\\\\)\n"},
+		{"(\\n\\000\\377) ==", "(\\n\\000\\377)\n"},
+		{"/x == {x} 0 get ==", "/x\nx\n"},
+		{"[1 (a) /b [true] {add}] ==", "[1 (a) /b [true] {add}]\n"},
+		{"/add load == mark == 1 dict ==", "--add--\n-mark-\n-dict-\n"},
+		{"1.5 == 1.0e20 ==", "1.5\n1.0e+20\n"},
+		{"1 (x) /y pstack", "/y\n(x)\n1\n"},
+		{"1 (x) /y stack", "y\nx\n1\n"},
+		{"/a [0] def a 0 a put a ==", strings.Repeat("[", maxSyntaxDepth+1) + "..." + strings.Repeat("]", maxSyntaxDepth+1) + "\n"},
+	} {
+		buf := &bytes.Buffer{}
+		intp := NewInterpreter()
+		intp.Stdout = buf
+		err := intp.ExecuteString(test.code)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if got := buf.String(); got != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.code, got, test.expected)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, test := range []struct {
+		obj      Object
+		expected string
+	}{
+		{nil, "null"},
+		{Integer(-3), "-3"},
+		{Real(2), "2.0"},
+		{String("a)"), "(a\\))"},
+		{Name("x"), "/x"},
+		{Array{Integer(1), Procedure{Operator("add")}}, "[1 {add}]"},
+		{Dict{}, "-dict-"},
+		{NewOperator("op", func(*Interpreter) error { return nil }), "--op--"},
+	} {
+		if got := Format(test.obj); got != test.expected {
+			t.Errorf("Format(%#v) = %q, expected %q", test.obj, got, test.expected)
+		}
+	}
+}
+
+func TestOutputStack(t *testing.T) {
+	// pstack and stack leave the operand stack unchanged
+	intp, err := run("1 2 pstack stack", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 1, 2)
+}
+
+func TestFlush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	intp := NewInterpreter()
+	intp.Stdout = w
+	err := intp.ExecuteString("(abc) print")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatal("output not buffered")
+	}
+	err = intp.ExecuteString("flush")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "abc" {
+		t.Errorf("got %q after flush", buf.String())
+	}
+}
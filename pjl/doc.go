@@ -0,0 +1,28 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pjl removes the Printer Job Language (PJL) wrapper from print jobs.
+//
+// Print jobs written by printer drivers often start with a Universal Exit
+// Language (UEL) sequence, followed by PJL commands which configure the
+// printer and select the language of the job data.  Jobs are separated by
+// further UEL sequences, and PostScript jobs are often terminated by a
+// Ctrl-D character.  The Reader in this package skips these wrappers and
+// returns the PostScript code of each job in turn.
+//
+// PJL is documented in HP's "Printer Job Language Technical Reference
+// Manual".
+package pjl
@@ -0,0 +1,236 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pjl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// UEL is the Universal Exit Language sequence, which terminates the
+// current job and returns control to PJL.
+const UEL = "\x1b%-12345X"
+
+// ctrlD is the end-of-job character used by PostScript printers.
+const ctrlD = 0x04
+
+// maxLineLength is the maximal length of a PJL command line.  Longer lines
+// are truncated.
+const maxLineLength = 4096
+
+// Reader returns the data of the jobs in a print job file.
+//
+// The Reader returns the data of one job at a time.  Jobs end at a Ctrl-D
+// character, at a UEL sequence, or at the end of the input.  PJL commands
+// between jobs are collected in Commands.  Jobs which consist only of white
+// space are skipped.
+//
+// Ctrl-D characters are recognised anywhere in the input, so binary data
+// which contains the byte 0x04 cannot be read correctly.
+type Reader struct {
+	// Commands lists the PJL commands read so far, in the order in which
+	// they appear in the input.  Each command is given as a complete line,
+	// including the "@PJL" prefix and excluding the line terminator.
+	Commands []string
+
+	r     *bufio.Reader
+	state int
+}
+
+// Values for Reader.state.
+const (
+	stateStart = iota // no job has been started yet
+	stateJob          // inside a job
+	stateEnd          // at the end of a job
+)
+
+// NewReader returns a new Reader which reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Next advances to the next job.  If no job has been read yet, this is the
+// first job in the input.  Any unread data of the current job is skipped.
+// At the end of the input, Next returns io.EOF.
+func (r *Reader) Next() error {
+	if r.state == stateJob {
+		_, err := io.Copy(io.Discard, r)
+		if err != nil {
+			return err
+		}
+	}
+	return r.startJob()
+}
+
+// Read reads data of the current job.  At the end of the job, Read
+// returns io.EOF; Next must then be called to read the following job.
+// If Read is called before Next, the first job is started automatically.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.state == stateStart {
+		err := r.startJob()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if r.state != stateJob {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) {
+		if n > 0 && r.r.Buffered() == 0 {
+			break
+		}
+		buf, err := r.r.Peek(1)
+		if err == io.EOF {
+			r.state = stateEnd
+			if n > 0 {
+				break
+			}
+			return 0, io.EOF
+		} else if err != nil {
+			return n, err
+		}
+
+		buf, _ = r.r.Peek(r.r.Buffered())
+		if len(buf) > len(p)-n {
+			buf = buf[:len(p)-n]
+		}
+		i := bytes.IndexAny(buf, "\x04\x1b")
+		if i < 0 {
+			i = len(buf)
+		}
+		n += copy(p[n:], buf[:i])
+		r.r.Discard(i)
+		if i == len(buf) {
+			continue
+		}
+
+		if buf[i] == ctrlD {
+			r.r.Discard(1)
+			r.state = stateEnd
+			break
+		}
+		if r.atUEL() {
+			r.state = stateEnd
+			break
+		}
+		// An escape character which does not start a UEL sequence is
+		// part of the job data.
+		p[n] = 0x1b
+		n++
+		r.r.Discard(1)
+	}
+	if n == 0 && r.state == stateEnd {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// atUEL reports whether the input continues with a UEL sequence.
+func (r *Reader) atUEL() bool {
+	buf, _ := r.r.Peek(len(UEL))
+	return string(buf) == UEL
+}
+
+// startJob skips job separators and PJL commands, up to the start of the
+// next job.
+func (r *Reader) startJob() error {
+	r.state = stateEnd
+	for {
+		buf, err := r.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		switch c := buf[0]; {
+		case c == ctrlD || isSpace(c):
+			r.r.Discard(1)
+		case r.atUEL():
+			r.r.Discard(len(UEL))
+			err = r.readCommands()
+			if err != nil {
+				return err
+			}
+		default:
+			r.state = stateJob
+			return nil
+		}
+	}
+}
+
+// readCommands reads the PJL commands following a UEL sequence.
+// This stops after an ENTER LANGUAGE command, since the job data
+// follows immediately, or before the first line which is not a PJL
+// command.
+func (r *Reader) readCommands() error {
+	for {
+		buf, err := r.r.Peek(4)
+		if string(buf) != "@PJL" {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		cmd, err := r.readLine()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		r.Commands = append(r.Commands, cmd)
+		if isEnterLanguage(cmd) || err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// readLine reads a line terminated by a line feed, and returns it
+// without the line terminator.
+func (r *Reader) readLine() (string, error) {
+	var line []byte
+	for {
+		c, err := r.r.ReadByte()
+		if err != nil {
+			return string(line), err
+		}
+		if c == '\n' {
+			break
+		}
+		if len(line) < maxLineLength {
+			line = append(line, c)
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// isEnterLanguage reports whether cmd is a PJL ENTER LANGUAGE command.
+func isEnterLanguage(cmd string) bool {
+	ff := strings.Fields(strings.ToUpper(cmd[4:]))
+	return len(ff) >= 2 && ff[0] == "ENTER" && strings.HasPrefix(ff[1], "LANGUAGE")
+}
+
+// isSpace reports whether c is a PostScript white-space character.
+func isSpace(c byte) bool {
+	switch c {
+	case 0, ' ', '\t', '\r', '\n', '\f':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pjl
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"seehuhn.de/go/postscript"
+)
+
+func TestReader(t *testing.T) {
+	type testCase struct {
+		in       string
+		jobs     []string
+		commands []string
+	}
+	cases := []testCase{
+		{
+			in:   "%!PS\n1 2 add\n",
+			jobs: []string{"%!PS\n1 2 add\n"},
+		},
+		{
+			in:   "\x04%!PS\n1\n\x04\r\n",
+			jobs: []string{"%!PS\n1\n"},
+		},
+		{
+			in:   "%!PS\n1\n\x04%!PS\n2\n\x04\n\n\x04",
+			jobs: []string{"%!PS\n1\n", "%!PS\n2\n"},
+		},
+		{
+			in: UEL + "@PJL JOB NAME=\"test\"\r\n" +
+				"@PJL SET RESOLUTION=600\r\n" +
+				"@PJL ENTER LANGUAGE=POSTSCRIPT\r\n" +
+				"%!PS-Adobe-3.0\n(a\x1bb) show\n\x04" +
+				UEL + "@PJL EOJ\r\n" + UEL,
+			jobs: []string{"%!PS-Adobe-3.0\n(a\x1bb) show\n"},
+			commands: []string{
+				"@PJL JOB NAME=\"test\"",
+				"@PJL SET RESOLUTION=600",
+				"@PJL ENTER LANGUAGE=POSTSCRIPT",
+				"@PJL EOJ",
+			},
+		},
+		{
+			// A UEL sequence ends the job, even without Ctrl-D.
+			in:       UEL + "@PJL ENTER LANGUAGE = POSTSCRIPT\n%!PS\n1\n" + UEL + "%!PS\n2\n",
+			jobs:     []string{"%!PS\n1\n", "%!PS\n2\n"},
+			commands: []string{"@PJL ENTER LANGUAGE = POSTSCRIPT"},
+		},
+		{
+			in:       UEL + "@PJL\n@PJL COMMENT no newline",
+			commands: []string{"@PJL", "@PJL COMMENT no newline"},
+		},
+		{
+			in:   "\x1b%-123",
+			jobs: []string{"\x1b%-123"},
+		},
+		{
+			in: "",
+		},
+	}
+	for i, c := range cases {
+		for _, oneByte := range []bool{false, true} {
+			var in io.Reader = strings.NewReader(c.in)
+			if oneByte {
+				in = iotest.OneByteReader(in)
+			}
+			r := NewReader(in)
+
+			var jobs []string
+			for {
+				err := r.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				data, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				jobs = append(jobs, string(data))
+			}
+
+			if !slices.Equal(jobs, c.jobs) {
+				t.Errorf("%d: wrong jobs %q", i, jobs)
+			}
+			if !slices.Equal(r.Commands, c.commands) {
+				t.Errorf("%d: wrong commands %q", i, r.Commands)
+			}
+		}
+	}
+}
+
+func TestReaderImplicitStart(t *testing.T) {
+	in := UEL + "@PJL ENTER LANGUAGE=POSTSCRIPT\n%!PS\n1\n\x04%!PS\n2\n\x04%!PS\n3\n"
+	r := NewReader(strings.NewReader(in))
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "%!PS\n1\n" {
+		t.Errorf("wrong first job %q", data)
+	}
+
+	// Next skips the unread part of the second job.
+	err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(r, buf)
+	if err != nil || string(buf) != "%!" {
+		t.Fatalf("wrong start of second job %q, %v", buf, err)
+	}
+	err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "%!PS\n3\n" {
+		t.Errorf("wrong third job %q", data)
+	}
+	if err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+// TestInterpreter checks that print jobs can be run with CheckStart set.
+func TestInterpreter(t *testing.T) {
+	in := UEL + "@PJL ENTER LANGUAGE=POSTSCRIPT\r\n%!PS\n1 2 add\n\x04%!PS\n3 mul\n\x04" + UEL
+	r := NewReader(strings.NewReader(in))
+
+	intp := postscript.NewInterpreter()
+	intp.CheckStart = true
+	for {
+		err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		err = intp.Execute(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(intp.Stack) != 1 || intp.Stack[0] != postscript.Integer(9) {
+		t.Errorf("wrong stack %v", intp.Stack)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	in := io.MultiReader(strings.NewReader("%!PS\n"), iotest.ErrReader(io.ErrUnexpectedEOF))
+	r := NewReader(in)
+	_, err := io.ReadAll(r)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	job := bytes.Repeat([]byte("0 0 moveto 100 100 lineto stroke\n"), 1000)
+	in := slices.Concat([]byte(UEL+"@PJL ENTER LANGUAGE=POSTSCRIPT\n"), job, []byte("\x04"+UEL))
+	b.SetBytes(int64(len(in)))
+	for b.Loop() {
+		r := NewReader(bytes.NewReader(in))
+		_, err := io.Copy(io.Discard, r)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
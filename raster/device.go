@@ -0,0 +1,239 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+
+	"seehuhn.de/go/postscript"
+)
+
+// Device is a PostScript output device which renders pages into images.
+//
+// The origin of default user space is the bottom left corner of the page,
+// and one unit in default user space corresponds to 1/72 inch.
+type Device struct {
+	// Pages holds the completed pages, in the order in which they were
+	// shown.  Pages are only collected if OnPage is nil.
+	Pages []*image.RGBA
+
+	// OnPage, if not nil, is called by the `showpage` operator with the
+	// completed page.  The image is not used by the Device afterwards.
+	OnPage func(img *image.RGBA) error
+
+	// Background is the color used for new pages.
+	// If this is nil, pages start out white.
+	Background color.Color
+
+	width, height int
+	scale         float64
+
+	page *image.RGBA
+	r    *rasterizer
+
+	// clipMask caches the coverage of the clipping path used last.
+	// A nil mask indicates that the whole page is visible.
+	clipKey  []postscript.ClipPath
+	clipMask []float32
+}
+
+// NewDevice returns a new raster device.  The page size is given in
+// PostScript points (1/72 inch), the resolution in pixels per inch.
+func NewDevice(pageWidth, pageHeight, dpi float64) *Device {
+	scale := dpi / 72
+	width := max(int(math.Ceil(pageWidth*scale)), 1)
+	height := max(int(math.Ceil(pageHeight*scale)), 1)
+	return &Device{
+		width:  width,
+		height: height,
+		scale:  scale,
+		r:      newRasterizer(width, height),
+	}
+}
+
+// Bounds returns the size of the generated images, in pixels.
+func (d *Device) Bounds() image.Rectangle {
+	return image.Rect(0, 0, d.width, d.height)
+}
+
+// Page returns the current, incomplete page.
+func (d *Device) Page() *image.RGBA {
+	if d.page == nil {
+		d.page = image.NewRGBA(d.Bounds())
+		var bg color.Color = color.White
+		if d.Background != nil {
+			bg = d.Background
+		}
+		c := color.RGBAModel.Convert(bg).(color.RGBA)
+		pix := d.page.Pix
+		for i := 0; i < len(pix); i += 4 {
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = c.R, c.G, c.B, c.A
+		}
+	}
+	return d.page
+}
+
+// DefaultMatrix implements the [postscript.Device] interface.
+func (d *Device) DefaultMatrix() matrix.Matrix {
+	return matrix.Matrix{d.scale, 0, 0, -d.scale, 0, float64(d.height)}
+}
+
+// Fill implements the [postscript.Device] interface.
+func (d *Device) Fill(gs *postscript.GraphicsState, evenOdd bool) error {
+	d.fill(gs, gs.Path, evenOdd)
+	return nil
+}
+
+// Stroke implements the [postscript.Device] interface.
+func (d *Device) Stroke(gs *postscript.GraphicsState) error {
+	outline := strokeOutline(gs)
+	d.fill(gs, outline, false)
+	return nil
+}
+
+// ShowPage implements the [postscript.Device] interface.
+func (d *Device) ShowPage() error {
+	img := d.Page()
+	d.page = nil
+	if d.OnPage != nil {
+		return d.OnPage(img)
+	}
+	d.Pages = append(d.Pages, img)
+	return nil
+}
+
+// fill paints the area inside p, using the color and the clipping path from
+// gs.
+func (d *Device) fill(gs *postscript.GraphicsState, p *path.Data, evenOdd bool) {
+	d.r.flatness = flatness(gs)
+	clip := d.getClipMask(gs.Clip)
+
+	r, g, b := gs.Color.RGB()
+	src := [3]float32{
+		float32(clamp01(r)) * 255,
+		float32(clamp01(g)) * 255,
+		float32(clamp01(b)) * 255,
+	}
+
+	img := d.Page()
+	d.r.addPath(p)
+	d.r.render(evenOdd, func(y, x0 int, cov []float32) {
+		pix := img.Pix[y*img.Stride+4*x0:]
+		var mask []float32
+		if clip != nil {
+			mask = clip[y*d.width+x0:]
+		}
+		for i, c := range cov {
+			if mask != nil {
+				c *= mask[i]
+			}
+			if c <= 0 {
+				continue
+			}
+			px := pix[4*i : 4*i+4]
+			for j := range 3 {
+				dst := float32(px[j])
+				px[j] = uint8(dst + (src[j]-dst)*c + 0.5)
+			}
+			px[3] = uint8(float32(px[3]) + (255-float32(px[3]))*c + 0.5)
+		}
+	})
+}
+
+// getClipMask returns the coverage of the clipping path described by clip.
+// The result is nil if the whole page is visible.
+func (d *Device) getClipMask(clip []postscript.ClipPath) []float32 {
+	if len(clip) == 0 {
+		return nil
+	}
+	if sameClip(clip, d.clipKey) {
+		return d.clipMask
+	}
+
+	mask := make([]float32, d.width*d.height)
+	for i, cp := range clip {
+		if i == 0 {
+			d.r.addPath(cp.Path)
+			d.r.render(cp.EvenOdd, func(y, x0 int, cov []float32) {
+				copy(mask[y*d.width+x0:], cov)
+			})
+			continue
+		}
+
+		// Pixels outside the area touched by this path are no longer visible,
+		// so the mask is updated row by row.
+		yNext := 0
+		d.r.addPath(cp.Path)
+		d.r.render(cp.EvenOdd, func(y, x0 int, cov []float32) {
+			clear(mask[yNext*d.width : y*d.width])
+			row := mask[y*d.width : (y+1)*d.width]
+			clear(row[:x0])
+			for j, c := range cov {
+				row[x0+j] *= c
+			}
+			clear(row[x0+len(cov):])
+			yNext = y + 1
+		})
+		clear(mask[yNext*d.width:])
+	}
+
+	d.clipKey = slices.Clone(clip)
+	d.clipMask = mask
+	return mask
+}
+
+// sameClip reports whether a and b describe the same clipping path.
+// Since the PostScript interpreter never modifies a path once it has been
+// added to the clipping path, it is sufficient to compare pointers.
+func sameClip(a, b []postscript.ClipPath) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// flatness returns the curve approximation accuracy for gs, in pixels.
+// Since approximation errors are clearly visible in anti-aliased output,
+// the value from the graphics state is only used if it requests a higher
+// accuracy than maxFlatness.
+func flatness(gs *postscript.GraphicsState) float64 {
+	f := gs.Flatness
+	if !(f >= minFlatness) {
+		f = minFlatness
+	}
+	return min(f, maxFlatness)
+}
+
+const (
+	minFlatness = 0.05
+	maxFlatness = 0.25
+)
+
+func clamp01(x float64) float64 {
+	return min(max(x, 0), 1)
+}
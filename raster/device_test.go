@@ -0,0 +1,180 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package raster
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"seehuhn.de/go/postscript"
+)
+
+// render executes the PostScript code on a 20x20 pixel page and returns the
+// page produced by the first `showpage`.
+func render(t *testing.T, code string) *image.RGBA {
+	t.Helper()
+	dev := NewDevice(20, 20, 72)
+	intp := postscript.NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dev.Pages) == 0 {
+		t.Fatal("no page produced")
+	}
+	return dev.Pages[0]
+}
+
+// gray returns the red channel of the pixel at the given position in
+// default user space.
+func gray(img *image.RGBA, x, y int) uint8 {
+	return img.RGBAAt(x, img.Bounds().Dy()-1-y).R
+}
+
+func TestFill(t *testing.T) {
+	img := render(t, `
+		2 2 moveto 10 2 lineto 10 10 lineto 2 10 lineto closepath
+		0 setgray fill showpage`)
+	if g := gray(img, 5, 5); g != 0 {
+		t.Errorf("inside: got %d, expected 0", g)
+	}
+	if g := gray(img, 15, 5); g != 255 {
+		t.Errorf("outside: got %d, expected 255", g)
+	}
+}
+
+func TestAntiAliasing(t *testing.T) {
+	img := render(t, `
+		2.5 2 moveto 10 2 lineto 10 10 lineto 2.5 10 lineto closepath
+		0 setgray fill showpage`)
+	if g := gray(img, 2, 5); g < 120 || g > 135 {
+		t.Errorf("half covered pixel: got %d, expected 128", g)
+	}
+}
+
+func TestColor(t *testing.T) {
+	img := render(t, `0 0 20 20 rectfill 1 0 0 setrgbcolor 5 5 10 10 rectfill showpage`)
+	got := img.RGBAAt(10, 10)
+	if got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("got %v, expected red", got)
+	}
+}
+
+func TestEofill(t *testing.T) {
+	img := render(t, `
+		0 0 moveto 20 0 lineto 20 20 lineto 0 20 lineto closepath
+		5 5 moveto 15 5 lineto 15 15 lineto 5 15 lineto closepath
+		eofill showpage`)
+	if g := gray(img, 10, 10); g != 255 {
+		t.Errorf("inner square: got %d, expected 255", g)
+	}
+	if g := gray(img, 2, 2); g != 0 {
+		t.Errorf("outer ring: got %d, expected 0", g)
+	}
+}
+
+func TestStroke(t *testing.T) {
+	img := render(t, `2 setlinewidth 0 10 moveto 20 10 lineto stroke showpage`)
+	for y, want := range map[int]uint8{8: 255, 9: 0, 10: 0, 11: 255} {
+		if g := gray(img, 10, y); g != want {
+			t.Errorf("row %d: got %d, expected %d", y, g, want)
+		}
+	}
+}
+
+func TestLineCaps(t *testing.T) {
+	for _, c := range []struct {
+		cap  int
+		want uint8
+	}{{0, 255}, {2, 0}} {
+		img := render(t, ""+
+			"4 setlinewidth "+string(rune('0'+c.cap))+" setlinecap "+
+			"5 10 moveto 15 10 lineto stroke showpage")
+		if g := gray(img, 3, 10); g != c.want {
+			t.Errorf("cap %d: got %d, expected %d", c.cap, g, c.want)
+		}
+	}
+}
+
+func TestDash(t *testing.T) {
+	img := render(t, `[4] 0 setdash 2 setlinewidth 0 10 moveto 20 10 lineto stroke showpage`)
+	if g := gray(img, 1, 10); g != 0 {
+		t.Errorf("dash: got %d, expected 0", g)
+	}
+	if g := gray(img, 5, 10); g != 255 {
+		t.Errorf("gap: got %d, expected 255", g)
+	}
+}
+
+func TestClip(t *testing.T) {
+	img := render(t, `
+		0 0 moveto 10 0 lineto 10 20 lineto 0 20 lineto closepath clip newpath
+		0 0 20 20 rectfill showpage`)
+	if g := gray(img, 5, 10); g != 0 {
+		t.Errorf("inside clip: got %d, expected 0", g)
+	}
+	if g := gray(img, 15, 10); g != 255 {
+		t.Errorf("outside clip: got %d, expected 255", g)
+	}
+}
+
+func TestPages(t *testing.T) {
+	dev := NewDevice(10, 10, 144)
+	var pages []*image.RGBA
+	dev.OnPage = func(img *image.RGBA) error {
+		pages = append(pages, img)
+		return nil
+	}
+	intp := postscript.NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString("showpage 0 0 5 5 rectfill showpage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || len(dev.Pages) != 0 {
+		t.Fatalf("got %d pages, expected 2", len(pages))
+	}
+	if b := pages[0].Bounds(); b.Dx() != 20 || b.Dy() != 20 {
+		t.Errorf("wrong page size %v", b)
+	}
+	if g := gray(pages[0], 2, 2); g != 255 {
+		t.Errorf("first page not blank")
+	}
+	if g := gray(pages[1], 2, 2); g != 0 {
+		t.Errorf("second page not painted")
+	}
+}
+
+func FuzzDevice(f *testing.F) {
+	f.Add("0 0 moveto 10 0 lineto 5 8 lineto fill")
+	f.Add("0 0 10 0 360 arc 5 5 3 0 360 arc eofill")
+	f.Add("[2 1] 0 setdash 1 setlinejoin 0 0 moveto 10 10 lineto 20 0 lineto stroke")
+	f.Add("2 setlinecap 0 setlinejoin 3 setlinewidth 0 0 moveto 10 0 lineto 0 1 lineto closepath stroke")
+	f.Add("0 0 10 10 rectfill 0 0 moveto 5 0 lineto 5 5 lineto clip 1 0 0 setrgbcolor 0 0 20 20 rectfill")
+	f.Add("-1e30 -1e30 moveto 1e30 1e30 lineto 1e20 setlinewidth stroke")
+
+	f.Fuzz(func(t *testing.T, code string) {
+		// Just check that rendering does not crash or hang.
+		dev := NewDevice(20, 20, 72)
+		intp := postscript.NewInterpreter()
+		intp.Device = dev
+		intp.MaxOps = 100000
+		_ = intp.ExecuteString(code + " showpage")
+	})
+}
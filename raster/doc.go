@@ -0,0 +1,35 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package raster implements a PostScript output device which renders pages
+// into images.
+//
+// The [Device] type implements the [postscript.Device] interface.  Filled
+// and stroked paths are rendered with anti-aliasing into an [image.RGBA],
+// and a new image is started every time the `showpage` operator is
+// executed.
+//
+// Example:
+//
+//	dev := raster.NewDevice(612, 792, 150) // US Letter at 150 dpi
+//	intp := postscript.NewInterpreter()
+//	intp.Device = dev
+//	err := intp.Execute(r)
+//	...
+//	for _, img := range dev.Pages {
+//		...
+//	}
+package raster
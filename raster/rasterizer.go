@@ -0,0 +1,314 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package raster
+
+import (
+	"math"
+
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// rasterizer computes the pixel coverage of a path.
+//
+// The algorithm accumulates signed areas: every line segment adds, for each
+// pixel row it crosses, the area between the segment and the right edge of
+// the row to the cells of an accumulation buffer.  Summing the buffer from
+// left to right then gives the signed coverage (the anti-aliased winding
+// number) of every pixel.
+type rasterizer struct {
+	width, height int
+
+	// acc holds one cell per pixel, row by row.
+	acc []float32
+
+	// row is used to return the coverage of one pixel row.
+	row []float32
+
+	// the range of cells which have been modified since the last call to
+	// render
+	xMin, xMax int
+	yMin, yMax int
+
+	// flatness is the maximal distance, in pixels, between a curve and the
+	// polygon used to approximate the curve.
+	flatness float64
+
+	start, current vec.Vec2
+	isOpen         bool
+}
+
+func newRasterizer(width, height int) *rasterizer {
+	r := &rasterizer{
+		width:    width,
+		height:   height,
+		acc:      make([]float32, width*height),
+		row:      make([]float32, width),
+		flatness: 1,
+	}
+	r.reset()
+	return r
+}
+
+// reset marks the accumulation buffer as empty.
+func (r *rasterizer) reset() {
+	r.xMin, r.xMax = r.width, -1
+	r.yMin, r.yMax = r.height, -1
+	r.isOpen = false
+}
+
+// addPath adds the outline of p to the accumulation buffer.
+// All subpaths are implicitly closed.
+func (r *rasterizer) addPath(p *path.Data) {
+	for cmd, pts := range p.Iter() {
+		switch cmd {
+		case path.CmdMoveTo:
+			r.moveTo(pts[0])
+		case path.CmdLineTo:
+			r.lineTo(pts[0])
+		case path.CmdCubeTo:
+			r.cubeTo(pts[0], pts[1], pts[2])
+		case path.CmdClose:
+			r.closePath()
+		default:
+			if len(pts) > 0 {
+				r.lineTo(pts[len(pts)-1])
+			}
+		}
+	}
+	r.closePath()
+}
+
+func (r *rasterizer) moveTo(p vec.Vec2) {
+	r.closePath()
+	r.start = p
+	r.current = p
+	r.isOpen = true
+}
+
+func (r *rasterizer) lineTo(p vec.Vec2) {
+	r.line(r.current, p)
+	r.current = p
+}
+
+func (r *rasterizer) cubeTo(p1, p2, p3 vec.Vec2) {
+	flattenCubic(r.current, p1, p2, p3, r.flatness, r.lineTo)
+}
+
+func (r *rasterizer) closePath() {
+	if r.isOpen {
+		r.line(r.current, r.start)
+		r.current = r.start
+	}
+}
+
+// flattenCubic approximates the cubic Bézier curve with control points p0,
+// p1, p2, p3 by a polygon.  The function lineTo is called for all vertices
+// of the polygon except for p0.  The parameter tol gives the maximal
+// distance between the curve and the polygon.
+func flattenCubic(p0, p1, p2, p3 vec.Vec2, tol float64, lineTo func(vec.Vec2)) {
+	// The distance between a cubic Bézier curve and the polygon through n+1
+	// equally spaced points on the curve is at most 3/4 dd / n^2, where dd
+	// bounds the second differences of the control points.
+	dd := max(
+		math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y),
+		math.Hypot(p1.X-2*p2.X+p3.X, p1.Y-2*p2.Y+p3.Y))
+	n := 1
+	if x := math.Ceil(math.Sqrt(0.75 * dd / tol)); x > 1 {
+		n = int(min(x, maxCurveSegments))
+	}
+
+	for i := 1; i < n; i++ {
+		t := float64(i) / float64(n)
+		s := 1 - t
+		a, b, c, d := s*s*s, 3*s*s*t, 3*s*t*t, t*t*t
+		lineTo(vec.Vec2{
+			X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		})
+	}
+	lineTo(p3)
+}
+
+// maxCurveSegments is the maximal number of line segments used to
+// approximate a single Bézier curve.
+const maxCurveSegments = 1000
+
+// line adds the line segment from a to b to the accumulation buffer.
+func (r *rasterizer) line(a, b vec.Vec2) {
+	if !isFinite(a) || !isFinite(b) {
+		return
+	}
+
+	// Parts of the segment which lie to the left of the image are replaced by
+	// vertical segments at x=0, and parts to the right of the image are
+	// replaced by vertical segments at x=width.  This does not change the
+	// coverage of any pixel.
+	w := float64(r.width)
+	if a.X < 0 && b.X > 0 || a.X > 0 && b.X < 0 {
+		m := split(a, b, 0)
+		r.line(a, m)
+		r.line(m, b)
+		return
+	}
+	if a.X < w && b.X > w || a.X > w && b.X < w {
+		m := split(a, b, w)
+		r.line(a, m)
+		r.line(m, b)
+		return
+	}
+	a.X = min(max(a.X, 0), w)
+	b.X = min(max(b.X, 0), w)
+
+	dir := float32(1)
+	if a.Y > b.Y {
+		a, b = b, a
+		dir = -1
+	}
+	if b.Y-a.Y < 1e-9 {
+		return
+	}
+	dxdy := (b.X - a.X) / (b.Y - a.Y)
+
+	h := float64(r.height)
+	yStart := int(min(max(math.Floor(a.Y), 0), h))
+	yEnd := int(min(max(math.Ceil(b.Y), 0), h))
+	for y := yStart; y < yEnd; y++ {
+		top := max(float64(y), a.Y)
+		bottom := min(float64(y+1), b.Y)
+		dy := bottom - top
+		if dy <= 0 {
+			continue
+		}
+		x0 := a.X + (top-a.Y)*dxdy
+		x1 := a.X + (bottom-a.Y)*dxdy
+		r.addRowSegment(y, x0, x1, dir*float32(dy))
+	}
+}
+
+// addRowSegment adds the contribution of a line segment within pixel row y,
+// which runs from x0 to x1 and has signed height d.  The coordinates x0 and
+// x1 must be in the range [0, width].
+func (r *rasterizer) addRowSegment(y int, x0, x1 float64, d float32) {
+	r.yMin = min(r.yMin, y)
+	r.yMax = max(r.yMax, y)
+
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	w := float64(r.width)
+	x0 = min(max(x0, 0), w) // guard against rounding errors
+	x1 = min(max(x1, 0), w)
+	x0i := int(math.Floor(x0))
+	x1i := int(math.Ceil(x1))
+
+	if x1i <= x0i+1 {
+		// The segment is contained in a single column of pixels.
+		xm := float32(0.5*(x0+x1) - float64(x0i))
+		r.add(y, x0i, d*(1-xm))
+		r.add(y, x0i+1, d*xm)
+		return
+	}
+
+	// The segment crosses several columns.  The area to the right of the
+	// segment grows quadratically in the first and the last pixel crossed,
+	// and linearly in between.
+	s := float32(1 / (x1 - x0))
+	x0f := float32(x0 - float64(x0i))
+	a0 := 0.5 * s * (1 - x0f) * (1 - x0f)
+	x1f := float32(x1 - float64(x1i) + 1)
+	am := 0.5 * s * x1f * x1f
+
+	r.add(y, x0i, d*a0)
+	if x1i == x0i+2 {
+		r.add(y, x0i+1, d*(1-a0-am))
+	} else {
+		a1 := s * (1.5 - x0f)
+		r.add(y, x0i+1, d*(a1-a0))
+		for x := x0i + 2; x < x1i-1; x++ {
+			r.add(y, x, d*s)
+		}
+		a2 := a1 + s*float32(x1i-x0i-3)
+		r.add(y, x1i-1, d*(1-a2-am))
+	}
+	r.add(y, x1i, d*am)
+}
+
+// add adds v to the accumulation cell for pixel (x, y).
+func (r *rasterizer) add(y, x int, v float32) {
+	if x >= r.width {
+		// Cells to the right of the image never contribute to the coverage,
+		// but all pixels from here to the end of the row may be affected.
+		r.xMax = r.width - 1
+		return
+	}
+	r.acc[y*r.width+x] += v
+	r.xMin = min(r.xMin, x)
+	r.xMax = max(r.xMax, x)
+}
+
+// render calls fn for every pixel row touched by the path, with the
+// coverage values of the pixels from column x0 onwards.  The slice cov is
+// only valid until fn returns.  After render returns, the accumulation buffer
+// is empty again.
+func (r *rasterizer) render(evenOdd bool, fn func(y, x0 int, cov []float32)) {
+	r.closePath()
+	r.isOpen = false
+	if r.xMin > r.xMax || r.yMin > r.yMax {
+		r.reset()
+		return
+	}
+
+	x0, x1 := r.xMin, r.xMax+1
+	for y := r.yMin; y <= r.yMax; y++ {
+		acc := r.acc[y*r.width+x0 : y*r.width+x1]
+		cov := r.row[:len(acc)]
+		var sum float32
+		for i, v := range acc {
+			sum += v
+			acc[i] = 0
+			c := sum
+			if c < 0 {
+				c = -c
+			}
+			if evenOdd {
+				// fold the winding number into the range [0, 1]
+				c = float32(math.Mod(float64(c), 2))
+				if c > 1 {
+					c = 2 - c
+				}
+			} else if c > 1 {
+				c = 1
+			}
+			cov[i] = c
+		}
+		fn(y, x0, cov)
+	}
+	r.reset()
+}
+
+// split returns the point where the line through a and b crosses the
+// vertical line at x.
+func split(a, b vec.Vec2, x float64) vec.Vec2 {
+	t := (x - a.X) / (b.X - a.X)
+	return vec.Vec2{X: x, Y: a.Y + t*(b.Y-a.Y)}
+}
+
+func isFinite(p vec.Vec2) bool {
+	return !math.IsNaN(p.X) && !math.IsInf(p.X, 0) &&
+		!math.IsNaN(p.Y) && !math.IsInf(p.Y, 0)
+}
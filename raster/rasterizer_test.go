@@ -0,0 +1,105 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package raster
+
+import (
+	"math"
+	"testing"
+
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// TestCoverageArea checks that the total coverage of a polygon equals its
+// area.
+func TestCoverageArea(t *testing.T) {
+	type testCase struct {
+		pts  []vec.Vec2
+		area float64
+	}
+	cases := []testCase{
+		{ // axis-aligned rectangle with fractional coordinates
+			pts:  []vec.Vec2{{X: 1.25, Y: 2.5}, {X: 7.75, Y: 2.5}, {X: 7.75, Y: 9.1}, {X: 1.25, Y: 9.1}},
+			area: 6.5 * 6.6,
+		},
+		{ // triangle with steep and shallow edges
+			pts:  []vec.Vec2{{X: 0.3, Y: 0.7}, {X: 15.2, Y: 3.1}, {X: 4.4, Y: 12.9}},
+			area: 0.5 * math.Abs((15.2-0.3)*(12.9-0.7)-(4.4-0.3)*(3.1-0.7)),
+		},
+		{ // rectangle extending beyond the left and right image edges
+			pts:  []vec.Vec2{{X: -5, Y: 1}, {X: 25, Y: 1}, {X: 25, Y: 3}, {X: -5, Y: 3}},
+			area: 16 * 2,
+		},
+	}
+
+	r := newRasterizer(16, 16)
+	for i, c := range cases {
+		p := &path.Data{}
+		p.MoveTo(c.pts[0])
+		for _, q := range c.pts[1:] {
+			p.LineTo(q)
+		}
+		r.addPath(p)
+
+		var total float64
+		r.render(false, func(y, x0 int, cov []float32) {
+			for _, v := range cov {
+				total += float64(v)
+			}
+		})
+		if math.Abs(total-c.area) > 1e-3 {
+			t.Errorf("%d: total coverage %g, expected %g", i, total, c.area)
+		}
+	}
+
+	for i, v := range r.acc {
+		if v != 0 {
+			t.Fatalf("accumulation buffer not cleared at index %d", i)
+		}
+	}
+}
+
+func TestEvenOdd(t *testing.T) {
+	square := func(p *path.Data, a, b float64) {
+		p.MoveTo(vec.Vec2{X: a, Y: a})
+		p.LineTo(vec.Vec2{X: b, Y: a})
+		p.LineTo(vec.Vec2{X: b, Y: b})
+		p.LineTo(vec.Vec2{X: a, Y: b})
+		p.Close()
+	}
+	p := &path.Data{}
+	square(p, 0, 10)
+	square(p, 2, 8)
+
+	for _, evenOdd := range []bool{false, true} {
+		r := newRasterizer(10, 10)
+		r.addPath(p)
+		var center float32
+		r.render(evenOdd, func(y, x0 int, cov []float32) {
+			if y == 5 {
+				center = cov[5-x0]
+			}
+		})
+		expected := float32(1)
+		if evenOdd {
+			expected = 0
+		}
+		if center != expected {
+			t.Errorf("evenOdd=%t: coverage %g, expected %g", evenOdd, center, expected)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package raster
+
+import (
+	"math"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/postscript"
+)
+
+// strokeOutline returns a path in device space, such that filling the path
+// with the nonzero winding number rule paints the same area as stroking
+// gs.Path.
+//
+// The outline is constructed in user space, so that the line width and the
+// dash pattern are interpreted correctly for arbitrary transformation
+// matrices.  The outline consists of one polygon for every line segment,
+// line join and line cap.  All polygons have the same orientation, so that
+// overlaps between them do not cancel.
+func strokeOutline(gs *postscript.GraphicsState) *path.Data {
+	out := &path.Data{}
+
+	ctm := gs.CTM
+	det := ctm[0]*ctm[3] - ctm[1]*ctm[2]
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return out
+	}
+	inv := ctm.Inv()
+
+	hw := gs.LineWidth / 2
+	if hw <= 0 {
+		// A line width of 0 selects the thinnest line which can be rendered;
+		// we use lines which are approximately one pixel wide.
+		hw = 0.5 / math.Sqrt(math.Abs(det))
+	}
+	if math.IsNaN(hw) || math.IsInf(hw, 0) {
+		return out
+	}
+
+	tol := flatness(gs)
+	s := &stroker{
+		ctm:        ctm,
+		hw:         hw,
+		cap:        gs.LineCap,
+		join:       gs.LineJoin,
+		miterLimit: gs.MiterLimit,
+		out:        out,
+	}
+
+	// Choose the number of vertices for round caps and joins, such that the
+	// error is less than tol device pixels.
+	rDev := hw * max(math.Hypot(ctm[0], ctm[1]), math.Hypot(ctm[2], ctm[3]))
+	n := 4.0
+	if tol < rDev {
+		n = math.Ceil(math.Pi / math.Acos(1-tol/rDev))
+	}
+	s.arcStep = 2 * math.Pi / min(max(n, 4), maxArcSegments)
+
+	for _, sp := range flatten(gs.Path, tol) {
+		for i, p := range sp.pts {
+			sp.pts[i] = inv.Apply(p)
+		}
+		if len(gs.Dash) > 0 {
+			s.dashed(sp.pts, sp.closed, gs.Dash, gs.DashPhase)
+		} else {
+			s.subpath(sp.pts, sp.closed)
+		}
+	}
+	return out
+}
+
+// maxArcSegments is the maximal number of vertices used to approximate a
+// full circle.
+const maxArcSegments = 256
+
+// maxDashes is the maximal number of dashes in a single subpath.
+// Subpaths which would need more dashes are stroked as solid lines.
+const maxDashes = 100_000
+
+type stroker struct {
+	ctm        matrix.Matrix
+	hw         float64 // half the line width
+	cap        postscript.LineCap
+	join       postscript.LineJoin
+	miterLimit float64
+	arcStep    float64 // angle between vertices of round caps and joins
+
+	out *path.Data
+	buf []vec.Vec2
+}
+
+// subpath adds the outline of one subpath, given in user space, to the
+// output.
+func (s *stroker) subpath(pts []vec.Vec2, closed bool) {
+	// remove repeated points
+	k := 0
+	for i, p := range pts {
+		if i == 0 || p != pts[k-1] {
+			pts[k] = p
+			k++
+		}
+	}
+	pts = pts[:k]
+	if closed && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+
+	n := len(pts)
+	switch {
+	case n == 0:
+		return
+	case n == 1:
+		// Degenerate subpaths are only painted if round line caps are used.
+		if s.cap == postscript.LineCapRound {
+			s.circle(pts[0])
+		}
+		return
+	}
+
+	numSegments := n - 1
+	if closed {
+		numSegments = n
+	}
+	for i := range numSegments {
+		s.segment(pts[i], pts[(i+1)%n])
+	}
+
+	if closed {
+		for i := range n {
+			s.lineJoin(pts[(i+n-1)%n], pts[i], pts[(i+1)%n])
+		}
+	} else {
+		for i := 1; i < n-1; i++ {
+			s.lineJoin(pts[i-1], pts[i], pts[i+1])
+		}
+		s.lineCap(pts[0], pts[1])
+		s.lineCap(pts[n-1], pts[n-2])
+	}
+}
+
+// dashed adds the outline of a dashed subpath to the output.
+func (s *stroker) dashed(pts []vec.Vec2, closed bool, dash []float64, phase float64) {
+	if len(dash)%2 != 0 {
+		// For an odd number of elements, the roles of dashes and gaps
+		// alternate between repetitions of the pattern.
+		dash = append(dash[:len(dash):len(dash)], dash...)
+	}
+	var period, length float64
+	for _, x := range dash {
+		period += x
+	}
+	for i := 1; i < len(pts); i++ {
+		length += dist(pts[i-1], pts[i])
+	}
+	if closed && len(pts) > 0 {
+		length += dist(pts[len(pts)-1], pts[0])
+	}
+	if !(period > 0) || !(length/period*float64(len(dash)) < maxDashes) {
+		s.subpath(pts, closed)
+		return
+	}
+
+	// Dashes do not join across the start of a closed subpath.
+	if closed && len(pts) > 0 {
+		pts = append(pts, pts[0])
+	}
+
+	// find the position within the dash pattern where the subpath starts
+	phase = math.Mod(phase, period)
+	if phase < 0 {
+		phase += period
+	}
+	idx := 0
+	for range len(dash) {
+		if phase < dash[idx] {
+			break
+		}
+		phase -= dash[idx]
+		idx = (idx + 1) % len(dash)
+	}
+	rem := dash[idx] - phase
+	on := idx%2 == 0
+
+	var cur []vec.Vec2
+	if on && len(pts) > 0 {
+		cur = append(cur, pts[0])
+	}
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		l := dist(a, b)
+		pos := 0.0
+		for l-pos > rem {
+			pos += rem
+			t := pos / l
+			q := vec.Vec2{X: a.X + t*(b.X-a.X), Y: a.Y + t*(b.Y-a.Y)}
+			if on {
+				s.subpath(append(cur, q), false)
+				cur = cur[:0]
+			} else {
+				cur = append(cur[:0], q)
+			}
+			on = !on
+			idx = (idx + 1) % len(dash)
+			rem = dash[idx]
+		}
+		rem -= l - pos
+		if on {
+			cur = append(cur, b)
+		}
+	}
+	if on && len(cur) > 0 {
+		s.subpath(cur, false)
+	}
+}
+
+// segment adds the rectangle covered by the line from a to b.
+func (s *stroker) segment(a, b vec.Vec2) {
+	n, ok := s.normal(a, b)
+	if !ok {
+		return
+	}
+	s.polygon(
+		vec.Vec2{X: a.X + n.X, Y: a.Y + n.Y},
+		vec.Vec2{X: b.X + n.X, Y: b.Y + n.Y},
+		vec.Vec2{X: b.X - n.X, Y: b.Y - n.Y},
+		vec.Vec2{X: a.X - n.X, Y: a.Y - n.Y},
+	)
+}
+
+// lineJoin adds the shape which joins the segments a-p and p-b.
+func (s *stroker) lineJoin(a, p, b vec.Vec2) {
+	n1, ok1 := s.normal(a, p)
+	n2, ok2 := s.normal(p, b)
+	if !ok1 || !ok2 {
+		return
+	}
+
+	cross := n1.X*n2.Y - n1.Y*n2.X
+	dot := (n1.X*n2.X + n1.Y*n2.Y) / (s.hw * s.hw)
+	if cross == 0 && dot > 0 {
+		return // the segments are collinear
+	}
+
+	// o1 and o2 are the corners on the outside of the turn
+	sign := 1.0
+	if cross > 0 {
+		sign = -1
+	}
+	o1 := vec.Vec2{X: p.X + sign*n1.X, Y: p.Y + sign*n1.Y}
+	o2 := vec.Vec2{X: p.X + sign*n2.X, Y: p.Y + sign*n2.Y}
+
+	switch s.join {
+	case postscript.LineJoinRound:
+		theta := math.Atan2(cross, dot*s.hw*s.hw)
+		start := math.Atan2(o1.Y-p.Y, o1.X-p.X)
+		m := int(math.Ceil(math.Abs(theta) / s.arcStep))
+		pts := append(s.buf[:0], p, o1)
+		for i := 1; i < m; i++ {
+			phi := start + theta*float64(i)/float64(m)
+			pts = append(pts, vec.Vec2{
+				X: p.X + s.hw*math.Cos(phi),
+				Y: p.Y + s.hw*math.Sin(phi),
+			})
+		}
+		pts = append(pts, o2)
+		s.buf = pts
+		s.polygon(pts...)
+	case postscript.LineJoinMiter:
+		// The ratio between miter length and line width is 1/sin(phi/2),
+		// where phi is the angle between the segments.
+		if 1+dot > 1e-12 && 2/(1+dot) <= s.miterLimit*s.miterLimit {
+			f := sign / (1 + dot)
+			tip := vec.Vec2{X: p.X + f*(n1.X+n2.X), Y: p.Y + f*(n1.Y+n2.Y)}
+			s.polygon(p, o1, tip, o2)
+			break
+		}
+		fallthrough
+	default: // LineJoinBevel
+		s.polygon(p, o1, o2)
+	}
+}
+
+// lineCap adds the line cap at the end p of the segment q-p.
+func (s *stroker) lineCap(p, q vec.Vec2) {
+	switch s.cap {
+	case postscript.LineCapRound:
+		s.circle(p)
+	case postscript.LineCapSquare:
+		n, ok := s.normal(q, p)
+		if !ok {
+			return
+		}
+		// (-n.Y, n.X) points away from the segment, with length hw
+		u := vec.Vec2{X: n.Y, Y: -n.X}
+		s.polygon(
+			vec.Vec2{X: p.X + n.X, Y: p.Y + n.Y},
+			vec.Vec2{X: p.X + n.X + u.X, Y: p.Y + n.Y + u.Y},
+			vec.Vec2{X: p.X - n.X + u.X, Y: p.Y - n.Y + u.Y},
+			vec.Vec2{X: p.X - n.X, Y: p.Y - n.Y},
+		)
+	}
+}
+
+// circle adds a disk of diameter equal to the line width, centered at p.
+func (s *stroker) circle(p vec.Vec2) {
+	m := int(math.Ceil(2 * math.Pi / s.arcStep))
+	pts := s.buf[:0]
+	for i := range m {
+		phi := 2 * math.Pi * float64(i) / float64(m)
+		pts = append(pts, vec.Vec2{
+			X: p.X + s.hw*math.Cos(phi),
+			Y: p.Y + s.hw*math.Sin(phi),
+		})
+	}
+	s.buf = pts
+	s.polygon(pts...)
+}
+
+// normal returns the left-hand normal vector of the segment a-b, scaled to
+// half the line width.  If a and b coincide, ok is false.
+func (s *stroker) normal(a, b vec.Vec2) (n vec.Vec2, ok bool) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 || math.IsInf(l, 0) || math.IsNaN(l) {
+		return vec.Vec2{}, false
+	}
+	return vec.Vec2{X: -dy / l * s.hw, Y: dx / l * s.hw}, true
+}
+
+// polygon transforms a polygon from user space to device space and appends
+// it to the output.  The orientation is normalized such that the signed
+// area in device space is non-negative.
+func (s *stroker) polygon(pts ...vec.Vec2) {
+	var area float64
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	det := s.ctm[0]*s.ctm[3] - s.ctm[1]*s.ctm[2]
+	reverse := (area < 0) != (det < 0)
+
+	for i := range pts {
+		p := pts[i]
+		if reverse {
+			p = pts[len(pts)-1-i]
+		}
+		p = s.ctm.Apply(p)
+		if i == 0 {
+			s.out.MoveTo(p)
+		} else {
+			s.out.LineTo(p)
+		}
+	}
+	s.out.Close()
+}
+
+type subpath struct {
+	pts    []vec.Vec2
+	closed bool
+}
+
+// flatten converts a path into a list of polygons, approximating curves
+// to within tol.  Subpaths which consist of a single moveto are omitted.
+func flatten(p *path.Data, tol float64) []subpath {
+	var res []subpath
+	var cur *subpath
+	var hasSegments bool
+	finish := func() {
+		if cur != nil && (hasSegments || cur.closed) {
+			res = append(res, *cur)
+		}
+		cur = nil
+		hasSegments = false
+	}
+	lineTo := func(q vec.Vec2) {
+		cur.pts = append(cur.pts, q)
+	}
+	for cmd, pts := range p.Iter() {
+		if cmd != path.CmdMoveTo && cur == nil {
+			continue
+		}
+		switch cmd {
+		case path.CmdMoveTo:
+			finish()
+			cur = &subpath{pts: []vec.Vec2{pts[0]}}
+		case path.CmdLineTo:
+			lineTo(pts[0])
+			hasSegments = true
+		case path.CmdCubeTo:
+			flattenCubic(cur.pts[len(cur.pts)-1], pts[0], pts[1], pts[2], tol, lineTo)
+			hasSegments = true
+		case path.CmdClose:
+			cur.closed = true
+		default:
+			if len(pts) > 0 {
+				lineTo(pts[len(pts)-1])
+				hasSegments = true
+			}
+		}
+	}
+	finish()
+	return res
+}
+
+func dist(a, b vec.Vec2) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
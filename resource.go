@@ -0,0 +1,362 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"seehuhn.de/go/postscript/pfb"
+)
+
+// A ResourceProvider supplies resources which are not defined in the
+// interpreter, for example fonts which are not embedded in a document.
+// When `findresource`, `findfont` or `selectfont` cannot find a resource,
+// the PostScript code returned by the provider is executed.  This code
+// must define the resource, for example using `definefont` or
+// `defineresource`.
+type ResourceProvider interface {
+	// OpenResource returns the PostScript code which defines the resource
+	// key in the given category.  If the resource is not available, an
+	// error wrapping fs.ErrNotExist is returned.
+	OpenResource(category, key Name) (io.ReadCloser, error)
+
+	// ListResources returns the keys of the resources available in the
+	// given category.  This is used by `resourceforall`.
+	ListResources(category Name) ([]Name, error)
+}
+
+// FSResources returns a ResourceProvider which reads resources from fsys.
+// Following the layout of Adobe's resource directories, the code for the
+// resource key in category is read from the file "category/key".  Fonts
+// in PFB format are recognised automatically.
+func FSResources(fsys fs.FS) ResourceProvider {
+	return fsResources{fsys}
+}
+
+type fsResources struct {
+	fsys fs.FS
+}
+
+func (r fsResources) OpenResource(category, key Name) (io.ReadCloser, error) {
+	name := string(category) + "/" + string(key)
+	if strings.Contains(string(category), "/") || strings.Contains(string(key), "/") || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return r.fsys.Open(name)
+}
+
+func (r fsResources) ListResources(category Name) ([]Name, error) {
+	if strings.Contains(string(category), "/") || !fs.ValidPath(string(category)) {
+		return nil, nil
+	}
+	entries, err := fs.ReadDir(r.fsys, string(category))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var res []Name
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			res = append(res, Name(entry.Name()))
+		}
+	}
+	return res, nil
+}
+
+// ResourceFunc adapts a function to the ResourceProvider interface.
+// The resulting provider does not list any resources for `resourceforall`.
+type ResourceFunc func(category, key Name) (io.ReadCloser, error)
+
+// OpenResource implements the [ResourceProvider] interface.
+func (f ResourceFunc) OpenResource(category, key Name) (io.ReadCloser, error) {
+	return f(category, key)
+}
+
+// ListResources implements the [ResourceProvider] interface.
+func (f ResourceFunc) ListResources(category Name) ([]Name, error) {
+	return nil, nil
+}
+
+// findResource returns the resource key in the given category.  If the
+// resource is not defined, it is loaded using intp.ResourceProvider.
+func (intp *Interpreter) findResource(op string, category, key Name) (Object, error) {
+	if _, ok := intp.Resources[category].(Dict); !ok {
+		return nil, intp.e(eUndefined, "%s: resource category %q not found", op, category)
+	}
+	if obj, ok := intp.Resources[category].(Dict)[key]; ok {
+		return obj, nil
+	}
+
+	err := intp.loadResource(op, category, key)
+	if err != nil {
+		return nil, err
+	}
+	// The code which defines the resource may replace the category
+	// dictionary, so the dictionary must be looked up again.
+	catDict, _ := intp.Resources[category].(Dict)
+	obj, ok := catDict[key]
+	if !ok {
+		return nil, intp.e(eUndefinedresource, "%s: resource %q not found in category %q", op, key, category)
+	}
+	return obj, nil
+}
+
+// loadResource executes the code supplied by intp.ResourceProvider for
+// the given resource.  If the resource is not available, nil is returned
+// without executing any code.
+func (intp *Interpreter) loadResource(op string, category, key Name) error {
+	if intp.ResourceProvider == nil {
+		return nil
+	}
+	r, err := intp.ResourceProvider.OpenResource(category, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return intp.e(eIoerror, "%s: %v", op, err)
+	}
+	defer r.Close()
+	return intp.runResource(r)
+}
+
+// runResource executes the PostScript code read from r.  Fonts in PFB
+// format are decoded automatically.  Results left on the operand stack are
+// discarded.
+func (intp *Interpreter) runResource(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if head, _ := br.Peek(1); len(head) > 0 && head[0] == 0x80 {
+		src = pfb.Decode(br)
+	}
+
+	height := len(intp.Stack)
+	base := len(intp.estack)
+	err := intp.pushFile(newScanner(src), nil)
+	if err != nil {
+		return err
+	}
+	err = intp.run(base)
+	if err != nil {
+		return err
+	}
+	if len(intp.Stack) > height {
+		intp.Stack = intp.Stack[:height]
+	}
+	return nil
+}
+
+// resourceArgs checks the operands `key category` of the resource
+// operators.
+func (intp *Interpreter) resourceArgs(op string) (key, category Name, err error) {
+	if len(intp.Stack) < 2 {
+		return "", "", intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	category, ok := intp.Stack[len(intp.Stack)-1].(Name)
+	if !ok {
+		return "", "", intp.e(eTypecheck, "%s: needs a name, not %T", op, intp.Stack[len(intp.Stack)-1])
+	}
+	switch obj := intp.Stack[len(intp.Stack)-2].(type) {
+	case Name:
+		key = obj
+	case String:
+		key = Name(obj)
+	default:
+		return "", "", intp.e(eTypecheck, "%s: needs a name or string, not %T", op, obj)
+	}
+	if _, ok := intp.Resources[category].(Dict); !ok {
+		return "", "", intp.e(eUndefined, "%s: resource category %q not found", op, category)
+	}
+	return key, category, nil
+}
+
+func bFindresource(intp *Interpreter) error {
+	key, category, err := intp.resourceArgs("findresource")
+	if err != nil {
+		return err
+	}
+	obj, err := intp.findResource("findresource", category, key)
+	if err != nil {
+		return err
+	}
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], obj)
+	return nil
+}
+
+func bUndefineresource(intp *Interpreter) error {
+	key, category, err := intp.resourceArgs("undefineresource")
+	if err != nil {
+		return err
+	}
+	catDict := intp.Resources[category].(Dict)
+	if err := intp.checkWrite("undefineresource", catDict); err != nil {
+		return err
+	}
+	intp.saveDictEntry(catDict, key)
+	delete(catDict, key)
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+	return nil
+}
+
+// bResourcestatus implements the `resourcestatus` operator.  The status is
+// 0 for resources defined in the interpreter and 2 for resources available
+// from intp.ResourceProvider.  The size is always reported as unknown.
+func bResourcestatus(intp *Interpreter) error {
+	key, category, err := intp.resourceArgs("resourcestatus")
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+
+	if _, ok := intp.Resources[category].(Dict)[key]; ok {
+		intp.Stack = append(intp.Stack, Integer(0), Integer(-1), Boolean(true))
+		return nil
+	}
+	if intp.ResourceProvider != nil {
+		r, err := intp.ResourceProvider.OpenResource(category, key)
+		if err == nil {
+			r.Close()
+			intp.Stack = append(intp.Stack, Integer(2), Integer(-1), Boolean(true))
+			return nil
+		}
+	}
+	intp.Stack = append(intp.Stack, Boolean(false))
+	return nil
+}
+
+func bResourceforall(intp *Interpreter) error {
+	if len(intp.Stack) < 4 {
+		return intp.e(eStackunderflow, "resourceforall: not enough arguments")
+	}
+	template, ok1 := intp.Stack[len(intp.Stack)-4].(String)
+	proc, ok2 := intp.Stack[len(intp.Stack)-3].(Procedure)
+	scratch, ok3 := intp.Stack[len(intp.Stack)-2].(String)
+	category, ok4 := intp.Stack[len(intp.Stack)-1].(Name)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return intp.e(eTypecheck, "resourceforall: invalid arguments")
+	}
+	if err := intp.checkRead("resourceforall", template); err != nil {
+		return err
+	}
+	if err := intp.checkWrite("resourceforall", scratch); err != nil {
+		return err
+	}
+	catDict, ok := intp.Resources[category].(Dict)
+	if !ok {
+		return intp.e(eUndefined, "resourceforall: resource category %q not found", category)
+	}
+
+	keys := slices.Collect(maps.Keys(catDict))
+	if intp.ResourceProvider != nil {
+		available, err := intp.ResourceProvider.ListResources(category)
+		if err != nil {
+			return intp.e(eIoerror, "resourceforall: %v", err)
+		}
+		keys = append(keys, available...)
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	var names []Name
+	for _, key := range keys {
+		if !matchTemplate(template, []byte(key)) {
+			continue
+		}
+		if len(key) > len(scratch) {
+			return intp.e(eRangecheck, "resourceforall: scratch string too short")
+		}
+		names = append(names, key)
+	}
+
+	err := intp.pushFrame(&resourceForallFrame{names: names, scratch: scratch, proc: proc})
+	if err != nil {
+		return err
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-4]
+	return nil
+}
+
+// resourceForallFrame implements the `resourceforall` operator.
+type resourceForallFrame struct {
+	names   []Name
+	scratch String
+	pos     int
+	proc    Object
+}
+
+func (f *resourceForallFrame) step(intp *Interpreter) error {
+	if f.pos >= len(f.names) {
+		intp.popFrame()
+		return nil
+	}
+	n := copy(f.scratch, f.names[f.pos])
+	f.pos++
+	intp.Stack = append(intp.Stack, f.scratch[:n])
+	return intp.execObject(f.proc, true)
+}
+
+func (f *resourceForallFrame) object() Object { return builtin(bResourceforall) }
+func (f *resourceForallFrame) isLoop()        {}
+
+// matchTemplate reports whether name matches a template for
+// `resourceforall`.  In the template, `*` matches any sequence of
+// characters, `?` matches a single character, and `\` quotes the
+// following character.
+func matchTemplate(template String, name []byte) bool {
+	t, n := 0, 0
+	// After a mismatch, matching resumes after the most recent `*`.
+	starT, starN := -1, 0
+	for n < len(name) {
+		if t < len(template) {
+			c := template[t]
+			switch {
+			case c == '*':
+				starT, starN = t+1, n
+				t++
+				continue
+			case c == '?':
+				t++
+				n++
+				continue
+			case c == '\\' && t+1 < len(template):
+				if template[t+1] == name[n] {
+					t += 2
+					n++
+					continue
+				}
+			case c == name[n]:
+				t++
+				n++
+				continue
+			}
+		}
+		if starT < 0 {
+			return false
+		}
+		starN++
+		t, n = starT, starN
+	}
+	for t < len(template) && template[t] == '*' {
+		t++
+	}
+	return t == len(template)
+}
@@ -0,0 +1,232 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testResources = fstest.MapFS{
+	"Font/Test-Font": {Data: []byte(`
+		/Test-Font <<
+			/FontType 3
+			/FontMatrix [0.001 0 0 0.001 0 0]
+			/Encoding StandardEncoding
+			/BuildGlyph { pop pop 500 0 setcharwidth }
+		>> definefont pop
+		1 2 3 % left-over results are discarded
+	`)},
+	"Font/Broken":             {Data: []byte(`% does not define a font`)},
+	"Encoding/TestEncoding":   {Data: []byte(`/TestEncoding [/a /b] /Encoding defineresource pop`)},
+	"ProcSet/TestProcs":       {Data: []byte(`/TestProcs << /double { 2 mul } >> /ProcSet defineresource pop`)},
+	"ProcSet/Other":           {Data: []byte(`/Other << >> /ProcSet defineresource pop`)},
+	"ProcSet/Fails":           {Data: []byte(`1 (a) add`)},
+	"ProcSet/subdir/Resource": {Data: []byte(`/Resource 1 /ProcSet defineresource pop`)},
+}
+
+func newResourceInterpreter() *Interpreter {
+	intp := NewInterpreter()
+	intp.ResourceProvider = FSResources(testResources)
+	return intp
+}
+
+func TestResourceProvider(t *testing.T) {
+	intp := newResourceInterpreter()
+	err := intp.ExecuteString(`
+		/Test-Font findfont /FontType get
+		/Test-Font 10 selectfont currentfont /FontMatrix get 0 get
+		/TestEncoding /Encoding findresource length
+		/TestProcs /ProcSet findresource begin 21 double end
+		(Test-Font) /Font findresource /FontType get`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 3, 0.01, 2, 42, 3)
+}
+
+func TestFindResourceErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"/Missing findfont", eInvalidfont},
+		{"/Broken findfont", eInvalidfont},
+		{"/Missing 10 selectfont", eInvalidfont},
+		{"/Missing /ProcSet findresource", eUndefinedresource},
+		{"/TestProcs /NoCategory findresource", eUndefined},
+		{"/Fails /ProcSet findresource", eTypecheck},
+		{"/subdir/Resource /ProcSet findresource", eUndefinedresource},
+		{"1 /ProcSet findresource", eTypecheck},
+		{"/ProcSet findresource", eStackunderflow},
+	} {
+		intp := newResourceInterpreter()
+		err := intp.ExecuteString(test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
+
+func TestResourceStatus(t *testing.T) {
+	intp := newResourceInterpreter()
+	err := intp.ExecuteString(`
+		/StandardEncoding /Encoding resourcestatus
+		/TestEncoding /Encoding resourcestatus
+		/Missing /Encoding resourcestatus
+		/TestEncoding /Encoding findresource pop
+		/TestEncoding /Encoding resourcestatus`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{
+		Integer(0), Integer(-1), Boolean(true),
+		Integer(2), Integer(-1), Boolean(true),
+		Boolean(false),
+		Integer(0), Integer(-1), Boolean(true),
+	}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestResourceForall(t *testing.T) {
+	intp := newResourceInterpreter()
+	err := intp.ExecuteString(`
+		/Local << >> /ProcSet defineresource pop
+		[ (*) { dup length string copy } 20 string /ProcSet resourceforall ]
+		[ (T*) { cvn } 20 string /ProcSet resourceforall ]
+		[ (*) { cvn exit } 20 string /ProcSet resourceforall ]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{
+		Array{String("CIDInit"), String("Fails"), String("Local"), String("Other"), String("TestProcs")},
+		Array{Name("TestProcs")},
+		Array{Name("CIDInit")},
+	}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestUndefineResource(t *testing.T) {
+	intp := newResourceInterpreter()
+	err := intp.ExecuteString(`
+		/TestProcs /ProcSet findresource pop
+		/TestProcs /ProcSet undefineresource
+		/TestProcs /ProcSet resourcestatus
+		/Local 1 /ProcSet defineresource pop
+		/Local /ProcSet undefineresource
+		/Local /ProcSet resourcestatus`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{
+		Integer(2), Integer(-1), Boolean(true),
+		Boolean(false),
+	}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestDefineResourceHook(t *testing.T) {
+	var defined []string
+	intp := NewInterpreter()
+	intp.DefineResource = func(category, key Name, instance Object) {
+		defined = append(defined, string(category)+"/"+string(key))
+	}
+	err := intp.ExecuteString(`
+		save
+		/F << /FontType 3 >> definefont pop
+		/P 1 /ProcSet defineresource pop
+		restore
+		/F /Font resourcestatus`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"Font/F", "ProcSet/P"}, defined); d != "" {
+		t.Error(d)
+	}
+	// The font was removed by restore, but the hook has seen it.
+	if d := cmp.Diff([]Object{Boolean(false)}, intp.Stack); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestResourceFunc(t *testing.T) {
+	var requests []string
+	intp := NewInterpreter()
+	intp.ResourceProvider = ResourceFunc(func(category, key Name) (io.ReadCloser, error) {
+		requests = append(requests, string(category)+"/"+string(key))
+		if key != "Answer" {
+			return nil, fs.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader("/Answer 42 /ProcSet defineresource pop")), nil
+	})
+	err := intp.ExecuteString(`
+		/Answer /ProcSet findresource
+		/Answer /ProcSet findresource
+		/Other /ProcSet resourcestatus`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Integer(42), Integer(42), Boolean(false)}
+	if d := cmp.Diff(expected, intp.Stack); d != "" {
+		t.Error(d)
+	}
+	// The resource is loaded only once.
+	if d := cmp.Diff([]string{"ProcSet/Answer", "ProcSet/Other"}, requests); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestMatchTemplate(t *testing.T) {
+	for _, test := range []struct {
+		template, name string
+		match          bool
+	}{
+		{"*", "", true},
+		{"*", "Times-Roman", true},
+		{"Times-*", "Times-Roman", true},
+		{"Times-*", "Helvetica", false},
+		{"*-Bold", "Times-Bold", true},
+		{"*-Bold", "Times-BoldItalic", false},
+		{"*Bold*", "Times-BoldItalic", true},
+		{"?", "a", true},
+		{"?", "ab", false},
+		{"a?c", "abc", true},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+		{"a*b*c", "axxbyybzc", true},
+		{"", "", true},
+		{"", "a", false},
+	} {
+		got := matchTemplate(String(test.template), []byte(test.name))
+		if got != test.match {
+			t.Errorf("matchTemplate(%q, %q) = %t", test.template, test.name, got)
+		}
+	}
+}
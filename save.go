@@ -0,0 +1,401 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+// vmSnapshot is the save object returned by the `save` operator.
+//
+// Snapshots use copy-on-write: `save` only records the roots of the VM.
+// When a dictionary entry or an array element is modified for the first
+// time after the save, the old value is logged in the innermost active
+// snapshot, and `restore` writes the logged values back.
+//
+// As described in section 3.7.3 of the PostScript Language Reference Manual,
+// the contents of strings are not restored.
+type vmSnapshot struct {
+	// valid is cleared when the snapshot is restored, or when an enclosing
+	// snapshot is restored.
+	valid bool
+
+	// dicts contains the old values of all dictionary entries which were
+	// modified since the save, indexed by compositeID.
+	dicts map[unsafe.Pointer]*dictLog
+
+	// elems contains the old values of all array elements which were
+	// modified since the save.
+	elems map[*Object]Object
+
+	// roots are the roots of the VM at the time of the save, see vmRoots.
+	// These are used to find composite objects created after the save.
+	roots []Object
+
+	// gstate is the copy of the graphics state pushed onto the graphics
+	// state stack by `save`.
+	gstate *GraphicsState
+}
+
+// dictLog records the old entries of a dictionary.
+type dictLog struct {
+	d   Dict
+	old map[Name]oldValue
+}
+
+// oldValue is the value of a dictionary entry before its first
+// modification.  If ok is false, the entry did not exist.
+type oldValue struct {
+	val Object
+	ok  bool
+}
+
+// maxSaveDepth is the maximal nesting depth of `save`.
+const maxSaveDepth = 15
+
+func bSave(intp *Interpreter) error {
+	if len(intp.saves) >= maxSaveDepth {
+		return intp.e(eLimitcheck, "save: too many nested saves")
+	}
+	if len(intp.gstack) >= maxGsaveDepth {
+		return intp.e(eLimitcheck, "save: too many nested gsaves")
+	}
+
+	roots := intp.vmRoots()
+	if err := intp.charge(len(roots) * objectSize); err != nil {
+		return err
+	}
+	snap := &vmSnapshot{
+		valid: true,
+		dicts: make(map[unsafe.Pointer]*dictLog),
+		elems: make(map[*Object]Object),
+		roots: roots,
+	}
+
+	gs := intp.graphicsState().clone()
+	gs.fromSave = true
+	intp.gstack = append(intp.gstack, gs)
+	snap.gstate = gs
+
+	intp.saves = append(intp.saves, snap)
+	intp.Stack = append(intp.Stack, snap)
+	return nil
+}
+
+func bRestore(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "restore: not enough arguments")
+	}
+	snap, ok := intp.Stack[len(intp.Stack)-1].(*vmSnapshot)
+	if !ok {
+		return intp.e(eTypecheck, "restore: needs a save object, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	level := slices.Index(intp.saves, snap)
+	if !snap.valid || level < 0 {
+		return intp.e(eInvalidrestore, "restore: save object is no longer valid")
+	}
+
+	// Composite objects created after the save must not survive on the
+	// operand stack or on the dictionary stack.
+	n := len(intp.Stack) - 1
+	check := slices.Clone(intp.Stack[:n])
+	for _, d := range intp.DictStack {
+		check = append(check, d)
+	}
+	if i := intp.findNew(level, check); i >= n {
+		return intp.e(eInvalidrestore, "restore: dict created after save is still on the dictionary stack")
+	} else if i >= 0 {
+		return intp.e(eInvalidrestore, "restore: %T created after save is still on the stack", check[i])
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+
+	// Undo the changes, innermost snapshot first, so that the values logged
+	// in outer snapshots take precedence.
+	for i := len(intp.saves) - 1; i >= level; i-- {
+		intp.saves[i].undo()
+	}
+
+	// Restoring a snapshot invalidates all later snapshots.
+	for _, s := range intp.saves[level:] {
+		s.valid = false
+	}
+	intp.saves = intp.saves[:level]
+
+	// `restore` implies a `grestoreall` back to the state saved by `save`.
+	if idx := slices.Index(intp.gstack, snap.gstate); idx >= 0 {
+		gs := intp.gstack[idx]
+		gs.fromSave = false
+		intp.gstate = gs
+		intp.gstack = intp.gstack[:idx]
+	}
+	return nil
+}
+
+// saveDictEntry must be called before d[key] is set or deleted.  If this is
+// the first modification of the entry since the innermost active save, the
+// old value is logged so that `restore` can undo the change.
+func (intp *Interpreter) saveDictEntry(d Dict, key Name) {
+	if len(intp.saves) == 0 {
+		return
+	}
+	snap := intp.saves[len(intp.saves)-1]
+	id := compositeID(d)
+	log := snap.dicts[id]
+	if log == nil {
+		log = &dictLog{d: d, old: make(map[Name]oldValue)}
+		snap.dicts[id] = log
+	}
+	if _, seen := log.old[key]; seen {
+		return
+	}
+	val, ok := d[key]
+	log.old[key] = oldValue{val: val, ok: ok}
+}
+
+// saveElems must be called before elements of a are modified.  Elements
+// which are modified for the first time since the innermost active save are
+// logged so that `restore` can undo the change.
+func (intp *Interpreter) saveElems(a []Object) {
+	if len(intp.saves) == 0 {
+		return
+	}
+	snap := intp.saves[len(intp.saves)-1]
+	for i := range a {
+		p := &a[i]
+		if _, seen := snap.elems[p]; !seen {
+			snap.elems[p] = *p
+		}
+	}
+}
+
+// undo writes back all values logged in the snapshot.
+func (snap *vmSnapshot) undo() {
+	for _, log := range snap.dicts {
+		for key, v := range log.old {
+			if v.ok {
+				log.d[key] = v.val
+			} else {
+				delete(log.d, key)
+			}
+		}
+	}
+	for p, val := range snap.elems {
+		*p = val
+	}
+}
+
+// findNew returns the index of an object in objs which was created after the
+// snapshot intp.saves[level] was taken.  If all composite objects in objs
+// existed at the time of the save, -1 is returned.
+//
+// An object is considered old, if it was reachable from the VM roots at the
+// time of the save.  Since the VM may have been modified since, the values
+// logged in the active snapshots are used in place of the current values.
+func (intp *Interpreter) findNew(level int, objs []Object) int {
+	snap := intp.saves[level]
+
+	// Fast path: most objects on the stacks are roots themselves.
+	rootIDs := make(map[unsafe.Pointer]bool, len(snap.roots))
+	for _, obj := range snap.roots {
+		rootIDs[compositeID(obj)] = true
+	}
+	var todo []int
+	for i, obj := range objs {
+		if id := compositeID(obj); id != nil && !rootIDs[id] {
+			todo = append(todo, i)
+		}
+	}
+	if len(todo) == 0 {
+		return -1
+	}
+
+	// Merge the logs, such that older values take precedence.
+	oldDicts := make(map[unsafe.Pointer]map[Name]oldValue)
+	oldElems := make(map[*Object]Object)
+	for _, s := range slices.Backward(intp.saves[level:]) {
+		for id, log := range s.dicts {
+			m := oldDicts[id]
+			if m == nil {
+				m = make(map[Name]oldValue)
+				oldDicts[id] = m
+			}
+			for key, v := range log.old {
+				m[key] = v
+			}
+		}
+		for p, val := range s.elems {
+			oldElems[p] = val
+		}
+	}
+
+	// Find all composite objects reachable at the time of the save.  For
+	// arrays and strings, the address ranges are recorded, so that
+	// intervals obtained by `getinterval` are recognised.
+	type addrRange struct{ start, end uintptr }
+	seen := make(map[accessKey]bool)
+	var ranges []addrRange
+	stack := slices.Clone(snap.roots)
+	for len(stack) > 0 {
+		obj := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		key, id := accessKeyOf(obj)
+		if id == nil || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var elems []Object
+		switch obj := obj.(type) {
+		case Dict:
+			old := oldDicts[id]
+			for k, v := range obj {
+				if _, logged := old[k]; !logged {
+					stack = append(stack, v)
+				}
+			}
+			for _, v := range old {
+				if v.ok {
+					stack = append(stack, v.val)
+				}
+			}
+			continue
+		case Array:
+			elems = obj
+		case Procedure:
+			elems = obj
+		case String:
+			ranges = append(ranges, addrRange{uintptr(id), uintptr(id) + uintptr(len(obj))})
+			continue
+		}
+		ranges = append(ranges, addrRange{uintptr(id), uintptr(id) + uintptr(len(elems))*unsafe.Sizeof(Object(nil))})
+		for i := range elems {
+			if val, logged := oldElems[&elems[i]]; logged {
+				stack = append(stack, val)
+			} else {
+				stack = append(stack, elems[i])
+			}
+		}
+	}
+
+	for _, i := range todo {
+		obj := objs[i]
+		key, id := accessKeyOf(obj)
+		if seen[key] {
+			continue
+		}
+		if _, isDict := obj.(Dict); !isDict {
+			addr := uintptr(id)
+			if slices.ContainsFunc(ranges, func(r addrRange) bool {
+				return addr >= r.start && addr < r.end
+			}) {
+				continue
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// vmRoots returns the objects from which all composite objects visible to
+// PostScript code can be reached.  This includes the procedures on the
+// execution stack, since these may contain objects which are not reachable
+// by other means.
+func (intp *Interpreter) vmRoots() []Object {
+	roots := []Object{
+		intp.SystemDict,
+		intp.UserDict,
+		intp.ErrorDict,
+		intp.InternalDict,
+		intp.FontDirectory,
+		intp.CMapDirectory,
+		intp.Resources,
+	}
+	for _, d := range intp.DictStack {
+		roots = append(roots, d)
+	}
+	roots = append(roots, intp.Stack...)
+	for _, f := range intp.estack {
+		roots = append(roots, frameObjects(f)...)
+	}
+	if intp.gstate != nil {
+		roots = append(roots, intp.gstate.Font)
+	}
+	for _, gs := range intp.gstack {
+		roots = append(roots, gs.Font)
+	}
+	return roots
+}
+
+// frameObjects returns the PostScript objects referenced by an execution
+// stack frame.
+func frameObjects(f execFrame) []Object {
+	switch f := f.(type) {
+	case *objectFrame:
+		return []Object{f.obj}
+	case *procFrame:
+		return []Object{f.proc}
+	case *forFrame:
+		return []Object{f.proc}
+	case *repeatFrame:
+		return []Object{f.proc}
+	case *loopFrame:
+		return []Object{f.proc}
+	case *forallFrame:
+		return []Object{f.obj, f.proc}
+	case *resourceForallFrame:
+		return []Object{f.scratch, f.proc}
+	default:
+		return nil
+	}
+}
+
+// compositeID returns a value which identifies the storage used by a
+// composite object.  For simple objects and for empty arrays and strings,
+// which have no storage, nil is returned.
+//
+// Arrays which share storage, for example because one was created from the
+// other using `getinterval`, only have the same identity if they start at
+// the same element.
+func compositeID(obj Object) unsafe.Pointer {
+	switch obj := obj.(type) {
+	case Dict:
+		if obj == nil {
+			return nil
+		}
+		return reflect.ValueOf(obj).UnsafePointer()
+	case Array:
+		if cap(obj) == 0 {
+			return nil
+		}
+		return unsafe.Pointer(unsafe.SliceData(obj))
+	case Procedure:
+		if cap(obj) == 0 {
+			return nil
+		}
+		return unsafe.Pointer(unsafe.SliceData(obj))
+	case String:
+		if cap(obj) == 0 {
+			return nil
+		}
+		return unsafe.Pointer(unsafe.SliceData(obj))
+	default:
+		return nil
+	}
+}
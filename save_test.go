@@ -0,0 +1,203 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveRestoreDefinitions(t *testing.T) {
+	intp, err := run(`
+		/a 1 def
+		/arr [1 2 3] def
+		save
+		/a 2 def /b 3 def
+		arr 0 99 put
+		userdict /d 5 dict put
+		restore
+		a arr 0 get userdict /b known userdict /d known`, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Object{Integer(1), Integer(1), Boolean(false), Boolean(false)}
+	if d := cmp.Diff(intp.Stack, expected); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestSaveRestoreStrings(t *testing.T) {
+	// The contents of strings are not restored.
+	intp, err := run(`
+		/s (abc) def
+		save s 0 (x) putinterval restore
+		s`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(intp.Stack[0], String("xbc")); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestSaveRestoreNested(t *testing.T) {
+	intp, err := run(`
+		/a 1 def
+		save /s1 exch def
+		/a 2 def
+		save pop
+		/a 3 def
+		s1 restore
+		a`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Integer(1) {
+		t.Errorf("a = %v, expected 1", intp.Stack[0])
+	}
+	if len(intp.saves) != 0 {
+		t.Errorf("%d saves remain active", len(intp.saves))
+	}
+}
+
+func TestSaveRestoreIntervals(t *testing.T) {
+	intp, err := run(`
+		/a [1 2 3 4] def
+		/d 1 dict def
+		save
+		a 1 2 getinterval 0 9 put
+		a 0 8 put
+		a 2 [7 7] putinterval
+		<< /x 1 /y 2 >> d copy pop
+		restore
+		a {} forall d length`, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 1, 2, 3, 4, 0)
+}
+
+func TestSaveCopyOnWrite(t *testing.T) {
+	intp, err := run(`
+		/a 1 def
+		/arr [1 2 3] def
+		save
+		/a 2 def /a 3 def
+		arr 1 5 put arr 1 6 put`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := intp.saves[0]
+
+	// only the first modification of each slot is logged
+	if len(snap.dicts) != 1 {
+		t.Fatalf("%d dicts logged, expected 1", len(snap.dicts))
+	}
+	for _, log := range snap.dicts {
+		expected := map[Name]oldValue{"a": {val: Integer(1), ok: true}}
+		if d := cmp.Diff(expected, log.old, cmp.AllowUnexported(oldValue{})); d != "" {
+			t.Error(d)
+		}
+	}
+	if len(snap.elems) != 1 {
+		t.Fatalf("%d array elements logged, expected 1", len(snap.elems))
+	}
+	for _, val := range snap.elems {
+		if val != Integer(2) {
+			t.Errorf("logged %v, expected 2", val)
+		}
+	}
+}
+
+func TestSaveRestoreGraphics(t *testing.T) {
+	intp, err := run(`
+		3 setlinewidth
+		save
+		5 setlinewidth gsave 7 setlinewidth gsave
+		grestoreall currentlinewidth
+		grestore currentlinewidth
+		9 setlinewidth
+		exch 3 -1 roll restore
+		currentlinewidth`, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// grestoreall and grestore stop at the state saved by save
+	checkNumbers(t, intp.Stack, 3, 3, 3)
+	if len(intp.gstack) != 0 {
+		t.Errorf("%d graphics states remain on the stack", len(intp.gstack))
+	}
+}
+
+func TestSaveType(t *testing.T) {
+	intp, err := run("save type", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Name("savetype") {
+		t.Errorf("wrong type %v", intp.Stack[0])
+	}
+}
+
+func TestInvalidRestore(t *testing.T) {
+	for _, code := range []string{
+		"save dup restore restore",
+		"save save exch restore restore",
+		"save [1 2] exch restore",
+		"save (new) exch restore",
+		"save 1 dict begin restore",
+		"save 5 dict exch restore",
+		"save /x [1] def x exch restore",
+		"/x [0] def save x 0 [1] put x 0 get exch restore",
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != eInvalidrestore {
+			t.Errorf("%q: expected invalidrestore, got %v", code, err)
+		}
+	}
+
+	for _, code := range []string{
+		"/x [1 2] def save x exch restore",
+		"(abc) save restore",
+		"save 1 2 add exch restore",
+		"userdict begin save restore",
+		"/x [1 2 3] def save x 1 1 getinterval exch restore",
+		"/d 1 dict def d /k [1 2] put save d /k get d /k 0 put exch restore",
+		"/x [[1]] def save x 0 get x 0 0 put exch restore",
+		"{ save (x) exch restore pop } exec",
+		"1 1 1 { pop save { } exch restore pop } for",
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(code)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", code, err)
+		}
+	}
+}
+
+func TestRestoreTypecheck(t *testing.T) {
+	intp := NewInterpreter()
+	err := intp.ExecuteString("1 restore")
+	var psErr *postScriptError
+	if !errors.As(err, &psErr) || psErr.tp != eTypecheck {
+		t.Errorf("expected typecheck, got %v", err)
+	}
+}
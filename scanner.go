@@ -73,6 +73,12 @@ type scanner struct {
 	err error
 }
 
+// immediateName is an immediately evaluated name, written as `//name`.
+// The interpreter replaces immediately evaluated names by their values
+// when they are scanned, see section 3.12.2 of the PostScript Language
+// Reference Manual.
+type immediateName Name
+
 type Comment struct {
 	Key   string
 	Value string
@@ -108,6 +114,9 @@ func (s *scanner) ScanToken() (Object, error) {
 	if err != nil {
 		return nil, err
 	}
+	if isBinaryToken(b) {
+		return s.readBinaryToken()
+	}
 	switch b {
 	case '(':
 		return s.ReadString()
@@ -140,7 +149,11 @@ func (s *scanner) ScanToken() (Object, error) {
 	case '/':
 		var name []byte
 		s.SkipByte()
-		// TODO(voss): implement "immediate names"
+		immediate := false
+		if b, err := s.Peek(); err == nil && b == '/' {
+			s.SkipByte()
+			immediate = true
+		}
 		for {
 			b, err := s.Peek()
 			if err == io.EOF {
@@ -148,7 +161,7 @@ func (s *scanner) ScanToken() (Object, error) {
 			} else if err != nil {
 				return nil, err
 			}
-			if class[b] != regular {
+			if class[b] != regular || isBinaryToken(b) {
 				break
 			}
 			if len(name) >= s.maxNameBytes {
@@ -157,6 +170,10 @@ func (s *scanner) ScanToken() (Object, error) {
 			s.SkipByte()
 			name = append(name, b)
 		}
+		s.skipTerminator()
+		if immediate {
+			return immediateName(name), nil
+		}
 		return Name(name), nil
 	default:
 		s.SkipByte()
@@ -169,7 +186,7 @@ func (s *scanner) ScanToken() (Object, error) {
 				} else if err != nil {
 					return nil, err
 				}
-				if class[b] != regular {
+				if class[b] != regular || isBinaryToken(b) {
 					break
 				}
 				if len(opBytes) >= s.maxNameBytes {
@@ -178,6 +195,7 @@ func (s *scanner) ScanToken() (Object, error) {
 				s.SkipByte()
 				opBytes = append(opBytes, b)
 			}
+			s.skipTerminator()
 		}
 
 		x, err := parseNumber(opBytes)
@@ -189,6 +207,20 @@ func (s *scanner) ScanToken() (Object, error) {
 	}
 }
 
+// skipTerminator consumes the white-space character which terminates a
+// name or number token.  This way, operators like `readstring` which read
+// from the current file start reading immediately after this character.
+func (s *scanner) skipTerminator() {
+	b, err := s.Peek()
+	if err != nil || class[b] != space {
+		return
+	}
+	s.SkipByte()
+	if b == '\r' {
+		s.SkipOptionalByte('\n')
+	}
+}
+
 func (s *scanner) ReadString() (String, error) {
 	err := s.SkipRequiredByte('(')
 	if err != nil {
@@ -170,6 +170,7 @@ func TestScanToken(t *testing.T) {
 	23A
 	23E1
 	23#1
+	//ABC
 	`
 	exp := []Object{
 		Integer(123),
@@ -184,6 +185,7 @@ func TestScanToken(t *testing.T) {
 		Operator("23A"),
 		Real(23e1),
 		Integer(1),
+		immediateName("ABC"),
 	}
 	s := newScanner(strings.NewReader(in))
 	var oo []Object
@@ -433,3 +435,106 @@ func TestDSC3(t *testing.T) {
 		t.Errorf("expected A, got %q", token)
 	}
 }
+
+func TestScanBinaryTokens(t *testing.T) {
+	in := "\x84\x00\x00\x01\x00" + // 32-bit integer, high-order byte first
+		"\x85\x00\x01\x00\x00" + // 32-bit integer, low-order byte first
+		"\x86\xff\xfe" + // 16-bit integer
+		"\x88\xfb" + // 8-bit integer
+		"\x89\x01\x00\x00\x00\x03" + // fixed point, scale 1
+		"\x89\x20\x00\x07" + // 16-bit fixed point, scale 0
+		"\x8a\x3f\xc0\x00\x00" + // IEEE real
+		"\x8b\x00\x00\x20\xc0" + // IEEE real, low-order byte first
+		"\x8d\x01" + // boolean
+		"\x8e\x03abc" + // string
+		"\x90\x02\x00xy" + // string, low-order byte first
+		"\x91\x01" + // literal system name
+		"/foo\x92\x63" + // executable system name, terminating a name
+		"\x95\x20\x00\x02\x00\x01\x00\x02" // homogeneous number array
+	exp := []Object{
+		Integer(256),
+		Integer(256),
+		Integer(-2),
+		Integer(-5),
+		Real(1.5),
+		Integer(7),
+		Real(1.5),
+		Real(-2.5),
+		Boolean(true),
+		String("abc"),
+		String("xy"),
+		Name("add"),
+		Name("foo"),
+		Operator("le"),
+		Array{Integer(1), Integer(2)},
+	}
+	s := newScanner(strings.NewReader(in))
+	var oo []Object
+	for {
+		token, err := s.ScanToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		oo = append(oo, token)
+	}
+	if d := cmp.Diff(exp, oo); d != "" {
+		t.Errorf("unexpected objects: %s", d)
+	}
+}
+
+func TestScanBinaryObjectSequence(t *testing.T) {
+	// The sequence `[1 (ab) /add] {true}`, with the objects stored
+	// low-order byte first.
+	seq := "\x81\x02\x36\x00" + // header: 2 top-level objects, 54 bytes
+		"\x09\x00\x03\x00\x10\x00\x00\x00" + // array of length 3 at offset 16
+		"\x89\x00\x01\x00\x28\x00\x00\x00" + // procedure of length 1 at offset 40
+		"\x01\x00\x00\x00\x01\x00\x00\x00" + // 1
+		"\x05\x00\x02\x00\x30\x00\x00\x00" + // string of length 2 at offset 48
+		"\x03\x00\x00\x00\x01\x00\x00\x00" + // system name 1
+		"\x04\x00\x00\x00\x01\x00\x00\x00" + // true
+		"ab"
+	s := newScanner(strings.NewReader(seq))
+	obj, err := s.ScanToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := binarySequence{
+		Array{Integer(1), String("ab"), Name("add")},
+		Procedure{Boolean(true)},
+	}
+	if d := cmp.Diff(exp, obj); d != "" {
+		t.Errorf("unexpected sequence: %s", d)
+	}
+
+	// The interpreter executes binary object sequences immediately.
+	intp := NewInterpreter()
+	err = intp.Execute(strings.NewReader("3 4 \x81\x01\x0c\x00\x83\x00\x00\x00\x01\x00\x00\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]Object{Integer(7)}, intp.Stack); d != "" {
+		t.Errorf("unexpected stack: %s", d)
+	}
+}
+
+func TestScanBinaryErrors(t *testing.T) {
+	for _, in := range []string{
+		"\x84\x00\x01",     // truncated integer
+		"\x96",             // unassigned token type
+		"\x91\xff",         // undefined system name
+		"\x89\x30\x00\x00", // fixed point with real representation
+		"\x80\x01\x00\x04", // sequence too short for its objects
+		"\x81\x01\x0c\x00" + // array which contains itself
+			"\x09\x00\x01\x00\x00\x00\x00\x00",
+		"\x81\x01\x0c\x00" + // string outside of the sequence
+			"\x05\x00\x10\x00\x00\x00\x00\x00",
+	} {
+		s := newScanner(strings.NewReader(in))
+		_, err := s.ScanToken()
+		if _, ok := err.(*postScriptError); !ok {
+			t.Errorf("%q: expected PostScript error, got %v", in, err)
+		}
+	}
+}
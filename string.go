@@ -0,0 +1,318 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func bSearch(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "search: not enough arguments")
+	}
+	str, seek, err := intp.searchArgs("search")
+	if err != nil {
+		return err
+	}
+	idx := bytes.Index(str, seek)
+	if idx < 0 {
+		intp.Stack[len(intp.Stack)-1] = Boolean(false)
+		return nil
+	}
+
+	post := str[idx+len(seek):]
+	match := str[idx : idx+len(seek)]
+	pre := str[:idx]
+	for _, s := range []String{post, match, pre} {
+		intp.inheritAccess(s, str)
+	}
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], post, match, pre, Boolean(true))
+	return nil
+}
+
+func bAnchorsearch(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "anchorsearch: not enough arguments")
+	}
+	str, seek, err := intp.searchArgs("anchorsearch")
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(str, seek) {
+		intp.Stack[len(intp.Stack)-1] = Boolean(false)
+		return nil
+	}
+
+	post := str[len(seek):]
+	match := str[:len(seek)]
+	intp.inheritAccess(post, str)
+	intp.inheritAccess(match, str)
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], post, match, Boolean(true))
+	return nil
+}
+
+// searchArgs checks the operands of `search` and `anchorsearch`.
+func (intp *Interpreter) searchArgs(op string) (str, seek String, err error) {
+	str, ok1 := intp.Stack[len(intp.Stack)-2].(String)
+	seek, ok2 := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok1 || !ok2 {
+		return nil, nil, intp.e(eTypecheck, "%s: needs two strings", op)
+	}
+	if err := intp.checkRead(op, str); err != nil {
+		return nil, nil, err
+	}
+	if err := intp.checkRead(op, seek); err != nil {
+		return nil, nil, err
+	}
+	return str, seek, nil
+}
+
+func bToken(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "token: not enough arguments")
+	}
+	src := intp.Stack[len(intp.Stack)-1]
+
+	str, isString := src.(String)
+	if !isString {
+		s, err := intp.fileScanner("token", src)
+		if err != nil {
+			return err
+		}
+		obj, err := intp.scanObject(s)
+		if err == io.EOF {
+			intp.Stack[len(intp.Stack)-1] = Boolean(false)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		intp.Stack = append(intp.Stack[:len(intp.Stack)-1], obj, Boolean(true))
+		return nil
+	}
+
+	if err := intp.checkRead("token", str); err != nil {
+		return err
+	}
+	r := bytes.NewReader(str)
+	s := newScanner(r)
+	obj, err := intp.scanObject(s)
+	if err == io.EOF {
+		intp.Stack[len(intp.Stack)-1] = Boolean(false)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// Bytes read from r, but not yet used by the scanner, are still
+	// part of the remaining string.
+	used := int(r.Size()) - r.Len() - (s.used - s.pos) - len(s.peek)
+	post := str[used:]
+	intp.inheritAccess(post, str)
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-1], post, obj, Boolean(true))
+	return nil
+}
+
+// scanObject reads the next object from s, as done by the `token`
+// operator.  In contrast to the main interpreter loop, procedures are
+// returned as a single object, and binary object sequences are returned
+// as procedures instead of being executed.
+func (intp *Interpreter) scanObject(s *scanner) (Object, error) {
+	var open [][]Object
+	for {
+		obj, err := s.ScanToken()
+		if err == io.EOF && len(open) > 0 {
+			return nil, intp.e(eSyntaxerror, "token: unterminated procedure")
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch obj {
+		case Operator("{"):
+			open = append(open, []Object{})
+			continue
+		case Operator("}"):
+			if len(open) == 0 {
+				return nil, intp.e(eSyntaxerror, "token: unmatched '}'")
+			}
+			body := open[len(open)-1]
+			open = open[:len(open)-1]
+			if err := intp.charge(len(body) * objectSize); err != nil {
+				return nil, err
+			}
+			obj = Procedure(body)
+		}
+
+		switch obj.(type) {
+		case immediateName, binarySequence:
+			var ok bool
+			obj, ok, err = intp.resolveImmediate(obj)
+			if err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+		}
+		if seq, ok := obj.(binarySequence); ok {
+			obj = Procedure(seq)
+		}
+
+		if len(open) > 0 {
+			open[len(open)-1] = append(open[len(open)-1], obj)
+			continue
+		}
+		return obj, nil
+	}
+}
+
+func bCvs(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "cvs: not enough arguments")
+	}
+	obj := intp.Stack[len(intp.Stack)-2]
+	if err := intp.checkRead("cvs", obj); err != nil {
+		return err
+	}
+	return intp.storeString("cvs", cvsText(obj))
+}
+
+func bCvrs(intp *Interpreter) error {
+	if len(intp.Stack) < 3 {
+		return intp.e(eStackunderflow, "cvrs: not enough arguments")
+	}
+	radix, ok := intp.Stack[len(intp.Stack)-2].(Integer)
+	if !ok {
+		return intp.e(eTypecheck, "cvrs: invalid radix")
+	} else if radix < 2 || radix > 36 {
+		return intp.e(eRangecheck, "cvrs: invalid radix %d", radix)
+	}
+
+	var text string
+	switch x := intp.Stack[len(intp.Stack)-3].(type) {
+	case Integer:
+		if radix == 10 {
+			text = strconv.Itoa(int(x))
+		} else {
+			text = formatRadix(int64(x), int(radix))
+		}
+	case Real:
+		if radix == 10 {
+			text = formatReal(float64(x))
+			break
+		}
+		y := math.Trunc(float64(x))
+		if y < math.MinInt32 || y > math.MaxUint32 {
+			return intp.e(eRangecheck, "cvrs: number too large")
+		}
+		text = formatRadix(int64(y), int(radix))
+	default:
+		return intp.e(eTypecheck, "cvrs: invalid argument type %T", x)
+	}
+
+	// move the string operand into the place of the radix
+	intp.Stack[len(intp.Stack)-2] = intp.Stack[len(intp.Stack)-1]
+	intp.Stack = intp.Stack[:len(intp.Stack)-1]
+	return intp.storeString("cvrs", text)
+}
+
+// formatRadix formats x in the given radix.  Negative numbers are
+// represented by their 32-bit two's complement.
+func formatRadix(x int64, radix int) string {
+	if x < 0 {
+		x = int64(uint32(x))
+	}
+	return strings.ToUpper(strconv.FormatInt(x, radix))
+}
+
+// storeString implements the common part of `cvs` and `cvrs`.  The
+// operands `any string` are replaced by the substring of string which
+// holds text.
+func (intp *Interpreter) storeString(op string, text string) error {
+	buf, ok := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "%s: needs a string, not %T", op, intp.Stack[len(intp.Stack)-1])
+	}
+	if err := intp.checkWrite(op, buf); err != nil {
+		return err
+	}
+	if len(text) > len(buf) {
+		return intp.e(eRangecheck, "%s: string too short", op)
+	}
+	n := copy(buf, text)
+	intp.Stack = append(intp.Stack[:len(intp.Stack)-2], buf[:n])
+	return nil
+}
+
+// cvsText returns the text representation of obj used by `cvs`.
+func cvsText(obj Object) string {
+	switch obj := obj.(type) {
+	case Integer:
+		return strconv.Itoa(int(obj))
+	case Real:
+		return formatReal(float64(obj))
+	case Boolean:
+		return strconv.FormatBool(bool(obj))
+	case String:
+		return string(obj)
+	case Name:
+		return string(obj)
+	case Operator:
+		return string(obj)
+	case builtin, *GoOperator:
+		return commandName(obj)
+	default:
+		return "--nostringval--"
+	}
+}
+
+// formatReal formats a real number like Adobe PostScript interpreters do:
+// with six significant digits, and always with a decimal point or an
+// exponent.
+func formatReal(x float64) string {
+	s := strconv.FormatFloat(x, 'g', 6, 64)
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return s
+	}
+	mant, exp, hasExp := strings.Cut(s, "e")
+	if !strings.Contains(mant, ".") {
+		mant += ".0"
+	}
+	if hasExp {
+		return mant + "e" + exp
+	}
+	return mant
+}
+
+func bCvn(intp *Interpreter) error {
+	if len(intp.Stack) < 1 {
+		return intp.e(eStackunderflow, "cvn: not enough arguments")
+	}
+	str, ok := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "cvn: needs a string, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	if err := intp.checkRead("cvn", str); err != nil {
+		return err
+	}
+	if len(str) > defaultMaxNameBytes {
+		return intp.e(eLimitcheck, "cvn: name too long")
+	}
+	intp.Stack[len(intp.Stack)-1] = Name(str)
+	return nil
+}
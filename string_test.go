@@ -0,0 +1,120 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStringOperators(t *testing.T) {
+	for _, test := range []struct {
+		code     string
+		expected []Object
+	}{
+		{"(abbc) (b) search", []Object{String("bc"), String("b"), String("a"), Boolean(true)}},
+		{"(abc) (x) search", []Object{String("abc"), Boolean(false)}},
+		{"(abc) (c) search", []Object{String(""), String("c"), String("ab"), Boolean(true)}},
+		{"(abc) (ab) anchorsearch", []Object{String("c"), String("ab"), Boolean(true)}},
+		{"(abc) (bc) anchorsearch", []Object{String("abc"), Boolean(false)}},
+		{"(abc) readonly (a) search pop pop pop wcheck", []Object{Boolean(false)}},
+
+		{"( 15 (x) ) token", []Object{String("(x) "), Integer(15), Boolean(true)}},
+		{"(/a{1 {2}}[) token", []Object{String("{1 {2}}["), Name("a"), Boolean(true)}},
+		{"({1 {2}}[) token", []Object{String("["), Procedure{Integer(1), Procedure{Integer(2)}}, Boolean(true)}},
+		{"(add) token", []Object{String(""), Operator("add"), Boolean(true)}},
+		{"(  % comment\n) token", []Object{Boolean(false)}},
+		{"/x 7 def (//x) token", []Object{String(""), Integer(7), Boolean(true)}},
+		{"(1.5 2) token pop exch token", []Object{Real(1.5), String(""), Integer(2), Boolean(true)}},
+		{"/f (332034>) /ASCIIHexDecode filter def f token f token f token",
+			[]Object{Integer(3), Boolean(true), Integer(4), Boolean(true), Boolean(false)}},
+
+		{"/abc 10 string cvs", []Object{String("abc")}},
+		{"/add load 10 string cvs", []Object{String("add")}},
+		{"123 10 string cvs", []Object{String("123")}},
+		{"-7 10 string cvs", []Object{String("-7")}},
+		{"true 10 string cvs", []Object{String("true")}},
+		{"(xy) 10 string cvs", []Object{String("xy")}},
+		{"[1] 20 string cvs", []Object{String("--nostringval--")}},
+		{"1.0 10 string cvs", []Object{String("1.0")}},
+		{"0.5 10 string cvs", []Object{String("0.5")}},
+		{"1 3 div 10 string cvs", []Object{String("0.333333")}},
+		{"1.0e10 10 string cvs", []Object{String("1.0e+10")}},
+		{"123456789.0 20 string cvs", []Object{String("1.23457e+08")}},
+		{"1.0e-5 10 string cvs", []Object{String("1.0e-05")}},
+		{"-100.0 10 string cvs", []Object{String("-100.0")}},
+
+		{"(abc) cvn", []Object{Name("abc")}},
+		{"() cvn", []Object{Name("")}},
+
+		{"255 16 10 string cvrs", []Object{String("FF")}},
+		{"5 2 10 string cvrs", []Object{String("101")}},
+		{"-1 16 10 string cvrs", []Object{String("FFFFFFFF")}},
+		{"123.7 8 10 string cvrs", []Object{String("173")}},
+		{"123.5 10 10 string cvrs", []Object{String("123.5")}},
+		{"35 36 10 string cvrs", []Object{String("Z")}},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		if err != nil {
+			t.Errorf("%q: %v", test.code, err)
+			continue
+		}
+		if d := cmp.Diff(test.expected, intp.Stack); d != "" {
+			t.Errorf("%q: %s", test.code, d)
+		}
+	}
+}
+
+func TestCvsSharesStorage(t *testing.T) {
+	intp, err := run("/s 5 string def 42 s cvs pop s", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(String("42\000\000\000"), intp.Stack[0]); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestStringOperatorErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"(abc) 1 search", eTypecheck},
+		{"(abc) noaccess (a) search", eInvalidaccess},
+		{"12345 3 string cvs", eRangecheck},
+		{"1 (abc) readonly cvs", eInvalidaccess},
+		{"1 1 10 string cvrs", eRangecheck},
+		{"1 37 10 string cvrs", eRangecheck},
+		{"(x) 16 10 string cvrs", eTypecheck},
+		{"1 cvn", eTypecheck},
+		{"({1 2) token", eSyntaxerror},
+		{"(}) token", eSyntaxerror},
+		{"(//nope) token", eUndefined},
+		{"1 token", eTypecheck},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// glyphMode describes what the text operators do with a glyph.
+type glyphMode int
+
+const (
+	glyphPaint    glyphMode = iota // paint the glyph using the Device
+	glyphCharpath                  // append the glyph outline to the current path
+	glyphMetrics                   // only compute the advance width
+)
+
+// textOp describes the variations between the members of the `show`
+// operator family.
+type textOp struct {
+	name string
+	mode glyphMode
+
+	// a is added to the advance width of every glyph (ashow).
+	a vec.Vec2
+
+	// w is added to the advance width of glyphs with character code wChar
+	// (widthshow).  If wChar is negative, no adjustment is made.
+	wChar int
+	w     vec.Vec2
+
+	// If hasXY is true, the glyph widths are replaced by the displacements
+	// from xy (xshow, yshow, xyshow).  xyStep is the number of values per
+	// glyph, and xyMask selects which coordinates are taken from the array.
+	hasXY  bool
+	xy     []float64
+	xyStep int
+	xyMask [2]bool
+
+	// proc, if not nil, is executed between glyphs, with the character
+	// codes of both glyphs on the operand stack (kshow).
+	proc Object
+}
+
+func bShow(intp *Interpreter) error {
+	s, err := intp.popString("show", 0)
+	if err != nil {
+		return err
+	}
+	return intp.show(&textOp{name: "show", wChar: -1}, s)
+}
+
+func bAshow(intp *Interpreter) error {
+	s, err := intp.popString("ashow", 2)
+	if err != nil {
+		return err
+	}
+	a, err := intp.popNumbers("ashow", 2)
+	if err != nil {
+		intp.Stack = append(intp.Stack, s)
+		return err
+	}
+	op := &textOp{
+		name:  "ashow",
+		a:     vec.Vec2{X: a[0], Y: a[1]},
+		wChar: -1,
+	}
+	return intp.show(op, s)
+}
+
+func bWidthshow(intp *Interpreter) error {
+	return intp.widthshow("widthshow", false)
+}
+
+func bAwidthshow(intp *Interpreter) error {
+	return intp.widthshow("awidthshow", true)
+}
+
+// widthshow implements the `widthshow` and `awidthshow` operators.
+func (intp *Interpreter) widthshow(name string, hasA bool) error {
+	numArgs := 4
+	if hasA {
+		numArgs = 6
+	}
+	if len(intp.Stack) < numArgs {
+		return intp.e(eStackunderflow, "%s: not enough arguments", name)
+	}
+	base := len(intp.Stack) - numArgs
+	args := intp.Stack[base:]
+	s, ok := args[numArgs-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "%s: needs a string, not %T", name, args[numArgs-1])
+	}
+	char, ok := args[2].(Integer)
+	if !ok {
+		return intp.e(eTypecheck, "%s: invalid character code %T", name, args[2])
+	}
+	var x [4]float64
+	for i, idx := range []int{0, 1, 3, 4}[:numArgs-2] {
+		v, ok := getNumber(args[idx])
+		if !ok {
+			return intp.e(eTypecheck, "%s: needs a number, not %T", name, args[idx])
+		}
+		x[i] = v
+	}
+	intp.Stack = intp.Stack[:base]
+
+	op := &textOp{
+		name:  name,
+		wChar: int(char) & 0xFF,
+		w:     vec.Vec2{X: x[0], Y: x[1]},
+		a:     vec.Vec2{X: x[2], Y: x[3]},
+	}
+	return intp.show(op, s)
+}
+
+func bKshow(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "kshow: not enough arguments")
+	}
+	proc, ok := intp.Stack[len(intp.Stack)-2].(Procedure)
+	if !ok {
+		return intp.e(eTypecheck, "kshow: needs a procedure, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	s, ok := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "kshow: needs a string, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+	return intp.show(&textOp{name: "kshow", wChar: -1, proc: proc}, s)
+}
+
+func bXshow(intp *Interpreter) error {
+	return intp.xyshow("xshow", 1, [2]bool{true, false})
+}
+
+func bYshow(intp *Interpreter) error {
+	return intp.xyshow("yshow", 1, [2]bool{false, true})
+}
+
+func bXyshow(intp *Interpreter) error {
+	return intp.xyshow("xyshow", 2, [2]bool{true, true})
+}
+
+// xyshow implements the `xshow`, `yshow` and `xyshow` operators.
+func (intp *Interpreter) xyshow(name string, step int, mask [2]bool) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "%s: not enough arguments", name)
+	}
+	s, ok := intp.Stack[len(intp.Stack)-2].(String)
+	if !ok {
+		return intp.e(eTypecheck, "%s: needs a string, not %T", name, intp.Stack[len(intp.Stack)-2])
+	}
+	a, ok := intp.Stack[len(intp.Stack)-1].(Array)
+	if !ok {
+		return intp.e(eTypecheck, "%s: needs an array, not %T", name, intp.Stack[len(intp.Stack)-1])
+	}
+	if len(a) < step*len(s) {
+		return intp.e(eRangecheck, "%s: not enough displacements", name)
+	}
+	xy := make([]float64, len(a))
+	for i, obj := range a {
+		x, ok := getNumber(obj)
+		if !ok {
+			return intp.e(eTypecheck, "%s: invalid displacement %T", name, obj)
+		}
+		xy[i] = x
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+
+	op := &textOp{
+		name:   name,
+		wChar:  -1,
+		hasXY:  true,
+		xy:     xy,
+		xyStep: step,
+		xyMask: mask,
+	}
+	return intp.show(op, s)
+}
+
+func bCharpath(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "charpath: not enough arguments")
+	}
+	s, ok := intp.Stack[len(intp.Stack)-2].(String)
+	if !ok {
+		return intp.e(eTypecheck, "charpath: needs a string, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	// The boolean operand only makes a difference for stroked fonts, where
+	// it requests the outline of the stroke instead of the centre line.
+	// Since `strokepath` is not implemented, we always use the centre line.
+	if _, ok := intp.Stack[len(intp.Stack)-1].(Boolean); !ok {
+		return intp.e(eTypecheck, "charpath: needs a boolean, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+	return intp.show(&textOp{name: "charpath", mode: glyphCharpath, wChar: -1}, s)
+}
+
+func bStringwidth(intp *Interpreter) error {
+	s, err := intp.popString("stringwidth", 0)
+	if err != nil {
+		return err
+	}
+	font, fm, err := intp.currentFont("stringwidth")
+	if err != nil {
+		intp.Stack = append(intp.Stack, s)
+		return err
+	}
+	var w vec.Vec2
+	for _, c := range s {
+		wc, err := intp.glyph("stringwidth", font, fm, c, glyphMetrics)
+		if err != nil {
+			return err
+		}
+		w = w.Add(linear(fm).Apply(wc))
+	}
+	intp.Stack = append(intp.Stack, Real(w.X), Real(w.Y))
+	return nil
+}
+
+func bCshow(intp *Interpreter) error {
+	if len(intp.Stack) < 2 {
+		return intp.e(eStackunderflow, "cshow: not enough arguments")
+	}
+	proc, ok := intp.Stack[len(intp.Stack)-2].(Procedure)
+	if !ok {
+		return intp.e(eTypecheck, "cshow: needs a procedure, not %T", intp.Stack[len(intp.Stack)-2])
+	}
+	s, ok := intp.Stack[len(intp.Stack)-1].(String)
+	if !ok {
+		return intp.e(eTypecheck, "cshow: needs a string, not %T", intp.Stack[len(intp.Stack)-1])
+	}
+	intp.Stack = intp.Stack[:len(intp.Stack)-2]
+
+	for _, c := range s {
+		font, fm, err := intp.currentFont("cshow")
+		if err != nil {
+			return err
+		}
+		wc, err := intp.glyph("cshow", font, fm, c, glyphMetrics)
+		if err != nil {
+			return err
+		}
+		w := linear(fm).Apply(wc)
+		intp.Stack = append(intp.Stack, Integer(c), Real(w.X), Real(w.Y))
+		err = intp.execute(proc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// show implements the operators of the `show` family.  For every character
+// in s, the glyph is processed as specified by op.mode and the current
+// point is advanced.
+func (intp *Interpreter) show(op *textOp, s String) error {
+	if !intp.graphicsState().hasCurrent {
+		return intp.e(eNocurrentpoint, "%s: no current point", op.name)
+	}
+
+	for i, c := range s {
+		// The procedure of `kshow` may change the graphics state, so the
+		// font and the graphics state are re-read for every character.
+		font, fm, err := intp.currentFont(op.name)
+		if err != nil {
+			return err
+		}
+		wc, err := intp.glyph(op.name, font, fm, c, op.mode)
+		if err != nil {
+			return err
+		}
+
+		w := linear(fm).Apply(wc)
+		width := w
+		if op.hasXY {
+			k := i * op.xyStep
+			for j := range 2 {
+				v := 0.0
+				if op.xyMask[j] {
+					v = op.xy[k]
+					k++
+				}
+				if j == 0 {
+					w.X = v
+				} else {
+					w.Y = v
+				}
+			}
+		}
+		w = w.Add(op.a)
+		if int(c) == op.wChar {
+			w = w.Add(op.w)
+		}
+
+		gs := intp.graphicsState()
+		if !gs.hasCurrent {
+			return intp.e(eNocurrentpoint, "%s: no current point", op.name)
+		}
+		advance := linear(gs.CTM).Apply(w)
+		// Inside the glyph procedure of a Type 3 font, glyphWidth is set.
+		if dev, ok := intp.Device.(TextDevice); ok && op.mode == glyphPaint && intp.glyphWidth == nil {
+			g := &Glyph{
+				Code:    c,
+				Name:    glyphName(font, c),
+				Origin:  gs.current,
+				Width:   linear(gs.CTM).Apply(width),
+				Advance: advance,
+			}
+			err = dev.ShowGlyph(gs, g)
+			if err != nil {
+				return err
+			}
+		}
+		err = intp.moveTo(gs.current.Add(advance))
+		if err != nil {
+			return err
+		}
+
+		if op.proc != nil && i+1 < len(s) {
+			intp.Stack = append(intp.Stack, Integer(c), Integer(s[i+1]))
+			err := intp.execute(op.proc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// glyph processes the glyph for character code c of the given font, with
+// the glyph origin at the current point.  The function returns the advance
+// width of the glyph, in character space.
+func (intp *Interpreter) glyph(op string, font Dict, fm matrix.Matrix, c byte, mode glyphMode) (vec.Vec2, error) {
+	gs := intp.graphicsState()
+
+	// M maps character space to device space
+	M := fm.Mul(gs.CTM)
+	M[4] += gs.current.X - gs.CTM[4]
+	M[5] += gs.current.Y - gs.CTM[5]
+
+	if fontType, _ := font["FontType"].(Integer); fontType == 3 {
+		return intp.type3Glyph(op, font, M, c, mode)
+	}
+
+	dec, err := intp.glyphDecoder(op, font)
+	if err != nil {
+		return vec.Vec2{}, err
+	}
+	outline, width, ok := dec.DecodeGlyph(glyphName(font, c))
+	if !ok {
+		outline, width, _ = dec.DecodeGlyph(".notdef")
+	}
+	if outline == nil || mode == glyphMetrics {
+		return width, nil
+	}
+
+	switch mode {
+	case glyphPaint:
+		if intp.Device == nil || len(outline.Cmds) == 0 {
+			break
+		}
+		tmp := *gs
+		tmp.Path = transformPath(outline, M)
+		if paintType, _ := font["PaintType"].(Integer); paintType == 2 {
+			strokeWidth, _ := getNumber(font["StrokeWidth"])
+			tmp.CTM = M
+			tmp.LineWidth = strokeWidth
+			tmp.Dash = nil
+			err = intp.Device.Stroke(&tmp)
+		} else {
+			err = intp.Device.Fill(&tmp, false)
+		}
+	case glyphCharpath:
+		err = intp.appendPath(outline, M)
+	}
+	return width, err
+}
+
+// type3Glyph executes the BuildGlyph or BuildChar procedure of a Type 3
+// font.  M is the transformation from character space to device space.
+func (intp *Interpreter) type3Glyph(op string, font Dict, M matrix.Matrix, c byte, mode glyphMode) (vec.Vec2, error) {
+	var proc, arg Object
+	if p, ok := font["BuildGlyph"].(Procedure); ok {
+		proc, arg = p, glyphName(font, c)
+	} else if p, ok := font["BuildChar"].(Procedure); ok {
+		proc, arg = p, Integer(c)
+	} else {
+		return vec.Vec2{}, intp.e(eInvalidfont, "%s: Type 3 font without BuildGlyph", op)
+	}
+
+	// The glyph procedure runs in a separate graphics state, which cannot
+	// be affected by `grestore`.  Painting is suppressed, unless the glyph
+	// is shown.
+	savedGS, savedStack := intp.graphicsState(), intp.gstack
+	savedWidth, savedDevice := intp.glyphWidth, intp.Device
+	gs := savedGS.clone()
+	gs.CTM = M
+	gs.newPath()
+	intp.gstate, intp.gstack = gs, nil
+	width := &vec.Vec2{}
+	intp.glyphWidth = width
+	var collector *pathCollector
+	switch mode {
+	case glyphCharpath:
+		collector = &pathCollector{ctm: savedGS.CTM}
+		intp.Device = collector
+	case glyphMetrics:
+		intp.Device = nil
+	}
+
+	intp.Stack = append(intp.Stack, font, arg)
+	err := intp.execute(proc)
+
+	intp.gstate, intp.gstack = savedGS, savedStack
+	intp.glyphWidth, intp.Device = savedWidth, savedDevice
+	if err != nil {
+		return vec.Vec2{}, err
+	}
+
+	if collector != nil {
+		for _, p := range collector.paths {
+			err = intp.appendPath(p, matrix.Identity)
+			if err != nil {
+				return vec.Vec2{}, err
+			}
+		}
+	}
+	return *width, nil
+}
+
+// pathCollector is the Device used while a Type 3 glyph is executed for
+// `charpath`.  Instead of painting, the paths are recorded.
+type pathCollector struct {
+	ctm   matrix.Matrix
+	paths []*path.Data
+}
+
+func (c *pathCollector) DefaultMatrix() matrix.Matrix {
+	return c.ctm
+}
+
+func (c *pathCollector) Fill(gs *GraphicsState, evenOdd bool) error {
+	c.paths = append(c.paths, gs.Path)
+	return nil
+}
+
+func (c *pathCollector) Stroke(gs *GraphicsState) error {
+	c.paths = append(c.paths, gs.Path)
+	return nil
+}
+
+func (c *pathCollector) ShowPage() error {
+	return nil
+}
+
+func bSetcharwidth(intp *Interpreter) error {
+	return intp.setGlyphWidth("setcharwidth", 2)
+}
+
+func bSetcachedevice(intp *Interpreter) error {
+	return intp.setGlyphWidth("setcachedevice", 6)
+}
+
+func bSetcachedevice2(intp *Interpreter) error {
+	return intp.setGlyphWidth("setcachedevice2", 10)
+}
+
+// setGlyphWidth implements the operators which set the width of a Type 3
+// glyph.  The width is given by the first two of the n operands; the
+// bounding box and the vertical metrics are ignored.
+func (intp *Interpreter) setGlyphWidth(op string, n int) error {
+	if intp.glyphWidth == nil {
+		return intp.e(eUndefined, "%s: not inside a BuildGlyph procedure", op)
+	}
+	x, err := intp.popNumbers(op, n)
+	if err != nil {
+		return err
+	}
+	*intp.glyphWidth = vec.Vec2{X: x[0], Y: x[1]}
+	return nil
+}
+
+// appendPath appends p, transformed by M, to the current path.
+func (intp *Interpreter) appendPath(p *path.Data, M matrix.Matrix) error {
+	gs := intp.graphicsState()
+	for cmd, pts := range p.Iter() {
+		var err error
+		switch cmd {
+		case path.CmdMoveTo:
+			err = intp.moveTo(M.Apply(pts[0]))
+		case path.CmdLineTo:
+			if gs.hasCurrent {
+				err = intp.lineTo(M.Apply(pts[0]))
+			}
+		case path.CmdCubeTo:
+			if gs.hasCurrent {
+				err = intp.curveTo(M.Apply(pts[0]),
+					M.Apply(pts[1]), M.Apply(pts[2]))
+			}
+		case path.CmdClose:
+			if gs.hasCurrent {
+				gs.Path.Close()
+				gs.current = gs.subpathStart
+			}
+		default:
+			if gs.hasCurrent && len(pts) > 0 {
+				err = intp.lineTo(M.Apply(pts[len(pts)-1]))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformPath returns a copy of p, transformed by M.
+func transformPath(p *path.Data, M matrix.Matrix) *path.Data {
+	res := &path.Data{
+		Cmds:   p.Cmds,
+		Coords: make([]vec.Vec2, len(p.Coords)),
+	}
+	for i, c := range p.Coords {
+		res.Coords[i] = M.Apply(c)
+	}
+	return res
+}
+
+// popString removes the string on top of the operand stack.  The string
+// must be preceded by skip further operands, which are left on the stack
+// for the caller.
+func (intp *Interpreter) popString(op string, skip int) (String, error) {
+	if len(intp.Stack) < 1+skip {
+		return nil, intp.e(eStackunderflow, "%s: not enough arguments", op)
+	}
+	idx := len(intp.Stack) - 1
+	s, ok := intp.Stack[idx].(String)
+	if !ok {
+		return nil, intp.e(eTypecheck, "%s: needs a string, not %T", op, intp.Stack[idx])
+	}
+	intp.Stack = intp.Stack[:idx]
+	return s, nil
+}
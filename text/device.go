@@ -0,0 +1,174 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package text
+
+import (
+	"math"
+	"strings"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/vec"
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/type1/names"
+)
+
+// Device is a PostScript output device which extracts the text of the
+// pages.  Device space coincides with default user space.
+type Device struct {
+	// Pages holds the completed pages, in the order in which they were
+	// shown.  Pages are only collected if OnPage is nil.
+	Pages []*Page
+
+	// OnPage, if not nil, is called by the `showpage` operator with the
+	// completed page.
+	OnPage func(p *Page) error
+
+	page *Page
+
+	// span is the span which receives the next glyph, if this continues
+	// the text, and end is the position where the next glyph of the span
+	// is expected.
+	span *Span
+	end  vec.Vec2
+}
+
+// NewDevice returns a new text extraction device.
+func NewDevice() *Device {
+	return &Device{}
+}
+
+// Page returns the current, incomplete page.
+func (d *Device) Page() *Page {
+	if d.page == nil {
+		d.page = &Page{Number: len(d.Pages) + 1}
+	}
+	return d.page
+}
+
+// DefaultMatrix implements the [postscript.Device] interface.
+func (d *Device) DefaultMatrix() matrix.Matrix {
+	return matrix.Identity
+}
+
+// Fill implements the [postscript.Device] interface.
+// Filled areas are ignored.
+func (d *Device) Fill(gs *postscript.GraphicsState, evenOdd bool) error {
+	return nil
+}
+
+// Stroke implements the [postscript.Device] interface.
+// Stroked lines are ignored.
+func (d *Device) Stroke(gs *postscript.GraphicsState) error {
+	return nil
+}
+
+// ShowPage implements the [postscript.Device] interface.
+func (d *Device) ShowPage() error {
+	page := d.Page()
+	d.page = nil
+	d.span = nil
+	if d.OnPage != nil {
+		return d.OnPage(page)
+	}
+	d.Pages = append(d.Pages, page)
+	return nil
+}
+
+// ShowGlyph implements the [postscript.TextDevice] interface.
+func (d *Device) ShowGlyph(gs *postscript.GraphicsState, g *postscript.Glyph) error {
+	fontName, _ := gs.Font["FontName"].(postscript.Name)
+	size := fontSize(gs)
+	text := glyphText(g, string(fontName))
+
+	span := d.span
+	if span != nil && (span.Font != string(fontName) || math.Abs(span.Size-size) > 0.01*size) {
+		span = nil
+	}
+	if span != nil {
+		// Small deviations from the expected position are caused by
+		// kerning and justification.  Larger gaps on the same baseline
+		// separate words.
+		delta := g.Origin.Sub(d.end)
+		switch {
+		case math.Abs(delta.Y) > 0.1*size || delta.X < -0.3*size || delta.X > 3*size:
+			span = nil
+		case delta.X > wordSpace*size && !strings.HasSuffix(span.Text, " "):
+			span.Text += " "
+		}
+	}
+	if span == nil {
+		span = &Span{
+			X:    g.Origin.X,
+			Y:    g.Origin.Y,
+			Font: string(fontName),
+			Size: size,
+		}
+		page := d.Page()
+		page.Spans = append(page.Spans, span)
+		d.span = span
+	}
+
+	span.Text += text
+	d.end = g.Origin.Add(g.Width)
+	span.Width = d.end.X - span.X
+	return nil
+}
+
+// wordSpace is the minimal gap between words, as a fraction of the font
+// size.
+const wordSpace = 0.15
+
+// glyphText returns the Unicode text for a glyph.  If the glyph name cannot
+// be mapped, printable ASCII character codes are used as they are.
+func glyphText(g *postscript.Glyph, fontName string) string {
+	text := names.ToUnicode(string(g.Name), fontName)
+	if text == "" && g.Code > ' ' && g.Code < 127 {
+		text = string(rune(g.Code))
+	}
+	return text
+}
+
+// fontSize returns the size of the current font in default user space.
+// This is the length of a vertical unit vector in the coordinate system
+// of the unscaled font, as given by the ScaleMatrix of the font.
+func fontSize(gs *postscript.GraphicsState) float64 {
+	M := gs.CTM
+	if sm, ok := getMatrix(gs.Font["ScaleMatrix"]); ok {
+		M = sm.Mul(M)
+	}
+	return math.Hypot(M[2], M[3])
+}
+
+// getMatrix converts a PostScript array to a matrix.
+func getMatrix(obj postscript.Object) (matrix.Matrix, bool) {
+	a, ok := obj.(postscript.Array)
+	if !ok || len(a) != 6 {
+		return matrix.Matrix{}, false
+	}
+	var M matrix.Matrix
+	for i, x := range a {
+		switch x := x.(type) {
+		case postscript.Integer:
+			M[i] = float64(x)
+		case postscript.Real:
+			M[i] = float64(x)
+		default:
+			return matrix.Matrix{}, false
+		}
+	}
+	return M, true
+}
@@ -0,0 +1,37 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package text implements a PostScript output device which extracts the
+// text of the pages.
+//
+// The [Device] type implements the [postscript.TextDevice] interface.
+// Every glyph shown by the text operators is mapped to Unicode, using the
+// glyph name from the font's Encoding, and consecutive glyphs are
+// collected into [Span] objects which record position, font and size.
+// The spans of a page can be converted to plain text in reading order
+// using [Page.Text], or stored in JSON format.
+//
+// Example:
+//
+//	dev := text.NewDevice()
+//	intp := postscript.NewInterpreter()
+//	intp.Device = dev
+//	err := intp.Execute(r)
+//	...
+//	for _, page := range dev.Pages {
+//		fmt.Println(page.Text())
+//	}
+package text
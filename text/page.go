@@ -0,0 +1,120 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package text
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Page holds the text of a page.
+type Page struct {
+	// Number is the page number, starting at 1.
+	Number int `json:"page"`
+
+	// Spans lists the text on the page, in the order in which it was
+	// shown.
+	Spans []*Span `json:"spans"`
+}
+
+// Span is a run of text shown on a single baseline, using one font.
+type Span struct {
+	// Text is the text of the span.
+	Text string `json:"text"`
+
+	// X and Y give the origin of the first glyph, and Width is the
+	// horizontal distance to the end of the last glyph, in default user
+	// space.
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Width float64 `json:"width"`
+
+	// Font is the name of the font, and Size is the font size in default
+	// user space.
+	Font string  `json:"font"`
+	Size float64 `json:"size"`
+}
+
+// Text returns the text of the page in reading order.
+//
+// Spans are collected into lines, which are ordered from top to bottom.
+// Within each line, spans are ordered from left to right, and spaces are
+// inserted between spans which are separated by a gap.  Lines with a large
+// vertical distance are separated by an empty line.  The layout analysis
+// assumes horizontal text in a single column.
+func (p *Page) Text() string {
+	lines := p.lines()
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if prev.y-line.y > paragraphSkip*max(prev.size, line.size) {
+				b.WriteByte('\n')
+			}
+		}
+		var end float64
+		for j, span := range line.spans {
+			if j > 0 && span.X-end > wordSpace*span.Size &&
+				!strings.HasSuffix(b.String(), " ") && !strings.HasPrefix(span.Text, " ") {
+				b.WriteByte(' ')
+			}
+			b.WriteString(span.Text)
+			end = span.X + span.Width
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// paragraphSkip is the minimal distance between the baselines of lines
+// which are separated by an empty line, as a fraction of the font size.
+const paragraphSkip = 1.8
+
+// line is a list of spans which share a baseline.
+type line struct {
+	y     float64
+	size  float64
+	spans []*Span
+}
+
+// lines groups the spans of the page into lines, from top to bottom.
+func (p *Page) lines() []*line {
+	spans := slices.Clone(p.Spans)
+	slices.SortStableFunc(spans, func(a, b *Span) int {
+		return cmp.Compare(b.Y, a.Y)
+	})
+
+	var lines []*line
+	var cur *line
+	for _, span := range spans {
+		if cur == nil || math.Abs(cur.y-span.Y) > 0.5*max(cur.size, span.Size) {
+			cur = &line{y: span.Y, size: span.Size}
+			lines = append(lines, cur)
+		}
+		cur.spans = append(cur.spans, span)
+		cur.size = max(cur.size, span.Size)
+	}
+	for _, l := range lines {
+		slices.SortStableFunc(l.spans, func(a, b *Span) int {
+			return cmp.Compare(a.X, b.X)
+		})
+	}
+	return lines
+}
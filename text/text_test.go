@@ -0,0 +1,156 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package text
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"seehuhn.de/go/postscript"
+)
+
+// testFont defines a Type 3 font /Test, where every glyph is 500 units wide.
+// The glyph names are taken from StandardEncoding, except for code 65,
+// which has the name /g65.
+const testFont = `
+/Test <<
+	/FontType 3
+	/FontName /Test
+	/FontMatrix [0.001 0 0 0.001 0 0]
+	/FontBBox [0 0 500 700]
+	/Encoding StandardEncoding 256 array copy dup 65 /g65 put
+	/BuildChar { pop pop 500 0 setcharwidth }
+>> definefont pop
+`
+
+// run executes the PostScript code and returns the pages.
+func run(t *testing.T, code string) []*Page {
+	t.Helper()
+	dev := NewDevice()
+	intp := postscript.NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(testFont + code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dev.Pages
+}
+
+func TestSpans(t *testing.T) {
+	pages := run(t, `
+		/Test 10 selectfont
+		72 700 moveto (Hello) show
+		( ) show (world) show
+		1 0 0 setrgbcolor
+		gsave 2 2 scale (!) show grestore
+		showpage`)
+	if len(pages) != 1 {
+		t.Fatalf("got %d pages", len(pages))
+	}
+
+	expected := []*Span{
+		{Text: "Hello world", X: 72, Y: 700, Width: 55, Font: "Test", Size: 10},
+		{Text: "!", X: 127, Y: 700, Width: 10, Font: "Test", Size: 20},
+	}
+	approx := cmpopts.EquateApprox(0, 1e-9)
+	if d := cmp.Diff(expected, pages[0].Spans, approx); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestText(t *testing.T) {
+	pages := run(t, `
+		/Test 10 selectfont
+		% shown out of order
+		72 688 moveto (second) show
+		72 700 moveto (first) show
+		112 700 moveto (line) show
+		/Test 20 selectfont
+		72 600 moveto (new paragraph) show
+		/Test 10 selectfont
+		210 600 moveto (same line) show
+		72 570 moveto (A) show
+		showpage
+		72 700 moveto (page two) show
+		showpage`)
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages", len(pages))
+	}
+
+	expected := "first line\nsecond\n\nnew paragraph same line\nA\n"
+	if got := pages[0].Text(); got != expected {
+		t.Errorf("wrong text %q", got)
+	}
+	if got := pages[1].Text(); got != "page two\n" || pages[1].Number != 2 {
+		t.Errorf("wrong second page %d %q", pages[1].Number, got)
+	}
+}
+
+func TestTextWordGaps(t *testing.T) {
+	// Words positioned individually are separated by spaces, glyphs
+	// with small kerning adjustments are not.
+	pages := run(t, `
+		/Test 10 selectfont
+		72 700 moveto (ab) [4.5 0 7 0] xyshow
+		(cd) show
+		showpage`)
+	if got := pages[0].Text(); got != "ab cd\n" {
+		t.Errorf("wrong text %q", got)
+	}
+	if len(pages[0].Spans) != 1 {
+		t.Errorf("got %d spans, expected 1", len(pages[0].Spans))
+	}
+}
+
+func TestJSON(t *testing.T) {
+	pages := run(t, `/Test 10 selectfont 72 700 moveto (Hi) show showpage`)
+	data, err := json.Marshal(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"page":1,"spans":[{"text":"Hi","x":72,"y":700,"width":10,"font":"Test","size":10}]}]`
+	if string(data) != expected {
+		t.Errorf("wrong JSON %s", data)
+	}
+}
+
+func TestOnPage(t *testing.T) {
+	var texts []string
+	dev := NewDevice()
+	dev.OnPage = func(p *Page) error {
+		texts = append(texts, p.Text())
+		return nil
+	}
+	intp := postscript.NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(testFont + `
+		/Test 10 selectfont
+		0 0 moveto (a) show showpage
+		showpage`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"a\n", ""}, texts); d != "" {
+		t.Error(d)
+	}
+	if len(dev.Pages) != 0 {
+		t.Error("pages collected despite OnPage")
+	}
+}
@@ -0,0 +1,310 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package postscript
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+)
+
+// type3Font defines a Type 3 font /T3 with a 1000 unit em square.  The glyph
+// "a" is a 500x500 square with advance width 600, and the glyph "b" is a
+// triangle with advance width 400.  All other codes map to .notdef, which has
+// no outline and advance width 250.
+const type3Font = `
+/T3 <<
+	/FontType 3
+	/FontMatrix [0.001 0 0 0.001 0 0]
+	/FontBBox [0 0 500 500]
+	/Encoding 256 array dup 0 1 255 { /.notdef put dup } for pop
+		dup 97 /a put dup 98 /b put
+	/Glyphs <<
+		/a { 600 0 setcharwidth 0 0 moveto 500 0 lineto 500 500 lineto 0 500 lineto closepath fill }
+		/b { 400 0 0 0 400 400 setcachedevice 0 0 moveto 400 0 lineto 200 400 lineto closepath fill }
+		/.notdef { 250 0 setcharwidth }
+	>>
+	/BuildGlyph { exch /Glyphs get exch get exec }
+	/BuildChar { 1 index /Encoding get exch get 1 index /BuildGlyph get exec }
+>> definefont pop
+`
+
+// newTextInterpreter returns an interpreter with the Type 3 font /T3 as the
+// current font, scaled to a size of 10 units.
+func newTextInterpreter(t *testing.T, dev Device) *Interpreter {
+	t.Helper()
+	intp := NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(type3Font + "/T3 findfont setfont 10 10 scale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intp
+}
+
+func checkNumbers(t *testing.T, stack []Object, want ...float64) {
+	t.Helper()
+	if len(stack) != len(want) {
+		t.Fatalf("got %d stack entries, expected %d", len(stack), len(want))
+	}
+	for i, obj := range stack {
+		x, ok := getNumber(obj)
+		if !ok || math.Abs(x-want[i]) > 1e-9 {
+			t.Errorf("stack[%d] = %v, expected %g", i, obj, want[i])
+		}
+	}
+}
+
+func TestShowType3(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := newTextInterpreter(t, dev)
+	err := intp.ExecuteString("1 2 moveto (ab) show currentpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 2, 2)
+
+	if len(dev.fills) != 2 {
+		t.Fatalf("got %d fills, expected 2", len(dev.fills))
+	}
+	expected := &path.Data{}
+	expected.MoveTo(vec.Vec2{X: 10, Y: 20})
+	expected.LineTo(vec.Vec2{X: 15, Y: 20})
+	expected.LineTo(vec.Vec2{X: 15, Y: 25})
+	expected.LineTo(vec.Vec2{X: 10, Y: 25})
+	expected.Close()
+	approx := cmpopts.EquateApprox(0, 1e-9)
+	if d := cmp.Diff(dev.fills[0].path, expected, approx); d != "" {
+		t.Error(d)
+	}
+	if got := dev.fills[1].path.Coords[0]; math.Abs(got.X-16) > 1e-9 || math.Abs(got.Y-20) > 1e-9 {
+		t.Errorf("second glyph starts at %v, expected (16, 20)", got)
+	}
+
+	// The glyph procedures must not affect the graphics state.
+	if p := intp.graphicsState().Path; len(p.Cmds) != 1 || p.Cmds[0] != path.CmdMoveTo {
+		t.Errorf("unexpected current path %v", p.Cmds)
+	}
+}
+
+// glyphDevice is a TextDevice which records the glyphs shown.
+type glyphDevice struct {
+	recordingDevice
+	glyphs []Glyph
+}
+
+func (d *glyphDevice) ShowGlyph(gs *GraphicsState, g *Glyph) error {
+	d.glyphs = append(d.glyphs, *g)
+	return nil
+}
+
+func TestShowGlyph(t *testing.T) {
+	dev := &glyphDevice{}
+	intp := newTextInterpreter(t, dev)
+	err := intp.ExecuteString(`
+		/Outer <<
+			/FontType 3
+			/FontMatrix [0.001 0 0 0.001 0 0]
+			/FontBBox [0 0 500 500]
+			/Encoding StandardEncoding
+			/BuildChar {
+				1000 0 setcharwidth
+				/T3 findfont 1000 scalefont setfont
+				0 0 moveto (ab) show
+				pop pop
+			}
+		>> definefont pop
+		1 2 moveto (ab) show
+		0 0 moveto (a) true charpath (b) stringwidth pop pop
+		/Outer findfont setfont (c) show
+		/T3 findfont setfont 1 0 (b) ashow`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Glyph{
+		{Code: 'a', Name: "a", Origin: vec.Vec2{X: 10, Y: 20}, Width: vec.Vec2{X: 6}, Advance: vec.Vec2{X: 6}},
+		{Code: 'b', Name: "b", Origin: vec.Vec2{X: 16, Y: 20}, Width: vec.Vec2{X: 4}, Advance: vec.Vec2{X: 4}},
+		{Code: 'c', Name: "c", Origin: vec.Vec2{X: 6}, Width: vec.Vec2{X: 10}, Advance: vec.Vec2{X: 10}},
+		{Code: 'b', Name: "b", Origin: vec.Vec2{X: 16}, Width: vec.Vec2{X: 4}, Advance: vec.Vec2{X: 14}},
+	}
+	approx := cmpopts.EquateApprox(0, 1e-9)
+	if d := cmp.Diff(expected, dev.glyphs, approx); d != "" {
+		t.Error(d)
+	}
+}
+
+func TestScaleMatrix(t *testing.T) {
+	intp := newTextInterpreter(t, nil)
+	err := intp.ExecuteString(`
+		/T3 findfont dup /ScaleMatrix known
+		exch 12 scalefont [1 0 0.5 1 0 0] makefont /ScaleMatrix get {} forall`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intp.Stack[0] != Boolean(false) {
+		t.Error("unscaled font has a ScaleMatrix")
+	}
+	checkNumbers(t, intp.Stack[1:], 12, 0, 6, 12, 0, 0)
+}
+
+func TestShowVariants(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		x, y float64
+	}{
+		{"(abc) show", 1.25, 0},
+		{"1 2 (abc) ashow", 4.25, 6},
+		{"1 2 98 (abb) widthshow", 3.4, 4},
+		{"3 4 97 1 2 (aba) awidthshow", 10.6, 14},
+		{"(ab) [1 2 3 4] xyshow", 4, 6},
+		{"(ab) [1 2] xshow", 3, 0},
+		{"(ab) [1 2] yshow", 0, 3},
+		{"{ pop pop 1 0 rmoveto } (aba) kshow", 3.6, 0},
+	} {
+		intp := newTextInterpreter(t, nil)
+		err := intp.ExecuteString("0 0 moveto " + test.code + " currentpoint")
+		if err != nil {
+			t.Errorf("%s: %v", test.code, err)
+			continue
+		}
+		checkNumbers(t, intp.Stack, test.x, test.y)
+	}
+}
+
+func TestStringwidth(t *testing.T) {
+	intp := newTextInterpreter(t, nil)
+	err := intp.ExecuteString("(aab) stringwidth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 1.6, 0)
+}
+
+func TestCharpath(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := newTextInterpreter(t, dev)
+	err := intp.ExecuteString("newpath 0 0 moveto (a) false charpath pathbbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The path ends with a moveto to the end of the glyph, at x=0.6.
+	checkNumbers(t, intp.Stack, 0, 0, 0.6, 0.5)
+	if len(dev.fills) != 0 {
+		t.Error("charpath painted the glyph")
+	}
+}
+
+func TestKshowCodes(t *testing.T) {
+	intp := newTextInterpreter(t, nil)
+	err := intp.ExecuteString("0 0 moveto {} (ab?) kshow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 97, 98, 98, 63)
+}
+
+func TestCshow(t *testing.T) {
+	dev := &recordingDevice{}
+	intp := newTextInterpreter(t, dev)
+	err := intp.ExecuteString("{} (ab) cshow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 97, 0.6, 0, 98, 0.4, 0)
+	if len(dev.fills) != 0 {
+		t.Error("cshow painted a glyph")
+	}
+}
+
+func TestTextErrors(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		tp   Name
+	}{
+		{"0 0 moveto (a) show", eInvalidfont},
+		{"/T3 findfont setfont newpath (a) show", eNocurrentpoint},
+		{"/T3 findfont setfont newpath (a) true charpath", eNocurrentpoint},
+		{"/T3 findfont setfont 0 0 moveto 1 show", eTypecheck},
+		{"/T3 findfont setfont 0 0 moveto (ab) [1 2] xyshow", eRangecheck},
+		{"1 0 setcharwidth", eUndefined},
+		{"<< /FontType 99 /FontMatrix [1 0 0 1 0 0] >> setfont 0 0 moveto (a) show", eInvalidfont},
+	} {
+		intp := NewInterpreter()
+		err := intp.ExecuteString(type3Font + test.code)
+		var psErr *postScriptError
+		if !errors.As(err, &psErr) || psErr.tp != test.tp {
+			t.Errorf("%q: expected %s, got %v", test.code, test.tp, err)
+		}
+	}
+}
+
+// testDecoder is a GlyphDecoder where every glyph is a unit square with
+// advance width 2.
+type testDecoder struct{}
+
+func (testDecoder) DecodeGlyph(name Name) (*path.Data, vec.Vec2, bool) {
+	if name != "square" {
+		return nil, vec.Vec2{}, false
+	}
+	p := &path.Data{}
+	p.MoveTo(vec.Vec2{X: 0, Y: 0})
+	p.LineTo(vec.Vec2{X: 1, Y: 0})
+	p.LineTo(vec.Vec2{X: 1, Y: 1})
+	p.Close()
+	return p, vec.Vec2{X: 2}, true
+}
+
+func TestRegisteredFontType(t *testing.T) {
+	const testFontType = 1000
+	numDecoded := 0
+	RegisterFontType(testFontType, func(font Dict) (GlyphDecoder, error) {
+		numDecoded++
+		return testDecoder{}, nil
+	})
+
+	dev := &recordingDevice{}
+	intp := NewInterpreter()
+	intp.Device = dev
+	err := intp.ExecuteString(`
+		/F <<
+			/FontType 1000
+			/FontMatrix [1 0 0 1 0 0]
+			/Encoding 256 array dup 0 1 255 { /.notdef put dup } for pop dup 65 /square put
+			/PaintType 0
+		>> definefont setfont
+		1 1 moveto (AA) show (A?) stringwidth currentpoint`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNumbers(t, intp.Stack, 2, 0, 5, 1)
+	if numDecoded != 1 {
+		t.Errorf("font decoded %d times, expected once", numDecoded)
+	}
+	if len(dev.fills) != 2 {
+		t.Fatalf("got %d fills, expected 2", len(dev.fills))
+	}
+	if got := dev.fills[1].path.Coords[0]; got != (vec.Vec2{X: 3, Y: 1}) {
+		t.Errorf("second glyph starts at %v, expected (3, 1)", got)
+	}
+}
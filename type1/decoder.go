@@ -0,0 +1,72 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package type1
+
+import (
+	"errors"
+	"slices"
+
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/postscript"
+	"seehuhn.de/go/postscript/psenc"
+)
+
+// Importing this package makes Type 1 fonts available to the text
+// operators of the PostScript interpreter.
+func init() {
+	postscript.RegisterFontType(1, newGlyphDecoder)
+}
+
+// glyphDecoder implements the [postscript.GlyphDecoder] interface for the
+// glyphs of a Type 1 font.
+type glyphDecoder map[string]*Glyph
+
+// newGlyphDecoder decodes all charstrings of the Type 1 font dictionary fd.
+func newGlyphDecoder(fd postscript.Dict) (postscript.GlyphDecoder, error) {
+	pd, ok := fd["Private"].(postscript.Dict)
+	if !ok {
+		return nil, errors.New("missing/invalid Private dictionary")
+	}
+	charstrings, subrs, codeBytes, err := readCharstrings(fd, pd)
+	if err != nil {
+		return nil, err
+	}
+
+	fontInfo, _ := fd["FontInfo"].(postscript.Dict)
+	var weightVector []float64
+	if mm := readMMInfo(fd, fontInfo); mm != nil {
+		weightVector = mm.WeightVector
+	}
+
+	// The character codes in the seac operator always refer to the standard
+	// encoding, independent of the encoding the font is used with.  The
+	// slice is copied, since decodeGlyphs modifies the encoding.
+	encoding := slices.Clone(psenc.StandardEncoding[:])
+	glyphs := decodeGlyphs(charstrings, subrs, weightVector, encoding, codeBytes)
+	return glyphDecoder(glyphs), nil
+}
+
+// DecodeGlyph implements the [postscript.GlyphDecoder] interface.
+func (d glyphDecoder) DecodeGlyph(name postscript.Name) (*path.Data, vec.Vec2, bool) {
+	g, ok := d[string(name)]
+	if !ok {
+		return nil, vec.Vec2{}, false
+	}
+	return g.Outline, vec.Vec2{X: g.WidthX, Y: g.WidthY}, true
+}
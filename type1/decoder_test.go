@@ -0,0 +1,113 @@
+// seehuhn.de/go/postscript - a rudimentary PostScript interpreter
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package type1
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/postscript"
+)
+
+// fillDevice records the paths filled by the PostScript interpreter.
+type fillDevice struct {
+	fills []*path.Data
+}
+
+func (d *fillDevice) DefaultMatrix() matrix.Matrix { return matrix.Identity }
+
+func (d *fillDevice) Fill(gs *postscript.GraphicsState, evenOdd bool) error {
+	d.fills = append(d.fills, gs.Path)
+	return nil
+}
+
+func (d *fillDevice) Stroke(gs *postscript.GraphicsState) error { return nil }
+
+func (d *fillDevice) ShowPage() error { return nil }
+
+// TestShow checks that the text operators of the PostScript interpreter can
+// use Type 1 fonts.
+func TestShow(t *testing.T) {
+	encoding := makeEmptyEncoding()
+	encoding['A'] = "A"
+	F := &Font{
+		FontInfo: &FontInfo{
+			FontName:   "Test",
+			FontMatrix: matrix.Matrix{0.001, 0, 0, 0.001, 0, 0},
+		},
+		Outlines: &Outlines{
+			Private:  &PrivateDict{BlueScale: 0.039625, BlueShift: 7, BlueFuzz: 1},
+			Glyphs:   map[string]*Glyph{},
+			Encoding: encoding,
+		},
+	}
+	F.NewGlyph(".notdef", 100)
+	g := F.NewGlyph("A", 600)
+	g.MoveTo(0, 0)
+	g.LineTo(500, 0)
+	g.LineTo(250, 700)
+	g.ClosePath()
+	g = F.NewGlyph("acute", 300)
+	g.MoveTo(0, 0)
+	g.LineTo(100, 0)
+	g.LineTo(100, 100)
+	g.ClosePath()
+
+	buf := &bytes.Buffer{}
+	err := F.Write(buf, &WriterOptions{Format: FormatPFA})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev := &fillDevice{}
+	intp := postscript.NewInterpreter()
+	intp.Device = dev
+	err = intp.Execute(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = intp.ExecuteString(`
+		/Test findfont setfont 10 10 scale
+		1 2 moveto (AA) show currentpoint
+		(AA) stringwidth`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{2.2, 2, 1.2, 0}
+	if len(intp.Stack) != len(want) {
+		t.Fatalf("got %d stack entries, expected %d", len(intp.Stack), len(want))
+	}
+	for i, obj := range intp.Stack {
+		x, _ := getReal(obj)
+		if math.Abs(x-want[i]) > 1e-9 {
+			t.Errorf("stack[%d] = %v, expected %g", i, obj, want[i])
+		}
+	}
+
+	if len(dev.fills) != 2 {
+		t.Fatalf("got %d fills, expected 2", len(dev.fills))
+	}
+	// The apex of the second glyph is at (1.6 + 0.25, 2 + 0.7) in user space.
+	top := dev.fills[1].Coords[2]
+	if math.Abs(top.X-18.5) > 1e-9 || math.Abs(top.Y-27) > 1e-9 {
+		t.Errorf("wrong glyph position %v, expected (18.5, 27)", top)
+	}
+}
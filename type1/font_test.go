@@ -27,6 +27,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/postscript"
 	"seehuhn.de/go/postscript/funit"
 	"seehuhn.de/go/postscript/internal/debug"
 )
@@ -90,6 +91,58 @@ func TestReadMissingFontInfo(t *testing.T) {
 	}
 }
 
+func TestFromDict(t *testing.T) {
+	encoding := makeEmptyEncoding()
+	encoding[65] = "A"
+	F := &Font{
+		FontInfo: &FontInfo{
+			FontName:   "FromDict",
+			FontMatrix: matrix.Matrix{0.001, 0, 0, 0.001, 0, 0},
+		},
+		Outlines: &Outlines{
+			Private:  &PrivateDict{BlueScale: 0.039625, BlueShift: 7, BlueFuzz: 1},
+			Glyphs:   map[string]*Glyph{},
+			Encoding: encoding,
+		},
+	}
+	g := F.NewGlyph(".notdef", 100)
+	g.ClosePath()
+	g = F.NewGlyph("A", 200)
+	g.MoveTo(0, 10)
+	g.LineTo(200, 10)
+	g.LineTo(100, 110)
+	g.ClosePath()
+
+	buf := &bytes.Buffer{}
+	err := F.Write(buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intp := postscript.NewInterpreter()
+	err = intp.Execute(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, ok := intp.FontDirectory["FromDict"].(postscript.Dict)
+	if !ok {
+		t.Fatal("font not defined")
+	}
+
+	G, err := FromDict(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(F, G); d != "" {
+		t.Errorf("F and G differ: %s", d)
+	}
+
+	_, err = FromDict(postscript.Dict{"FontType": postscript.Integer(3)})
+	if err == nil {
+		t.Error("Type 3 font accepted")
+	}
+}
+
 func FuzzFont(f *testing.F) {
 	encoding := makeEmptyEncoding()
 	encoding[65] = "A"
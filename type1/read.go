@@ -109,6 +109,18 @@ creationDateLoop:
 	if !ok {
 		return nil, errors.New("invalid font")
 	}
+	return fromDict(fd, key, creationDate)
+}
+
+// FromDict converts a Type 1 font dictionary, as passed to the `definefont`
+// PostScript operator, into a Font.
+func FromDict(fd postscript.Dict) (*Font, error) {
+	return fromDict(fd, "", time.Time{})
+}
+
+// fromDict converts a font dictionary into a Font.  The key is used as the
+// font name if the dictionary has no FontName entry.
+func fromDict(fd postscript.Dict, key postscript.Name, creationDate time.Time) (*Font, error) {
 	fontType, ok := fd["FontType"].(postscript.Integer)
 	if !ok || fontType != 1 {
 		return nil, errors.New("wrong FontType")
@@ -278,22 +290,52 @@ creationDateLoop:
 
 	// =============================================================
 
+	charstrings, subrs, codeBytes, err := readCharstrings(fd, pd)
+	if err != nil {
+		return nil, err
+	}
+
+	mm := readMMInfo(fd, fontInfo)
+	var weightVector []float64
+	if mm != nil {
+		mm.charstrings = charstrings
+		mm.subrs = subrs
+		mm.codeBytes = codeBytes
+		weightVector = mm.WeightVector
+	}
+
+	glyphs := decodeGlyphs(charstrings, subrs, weightVector, encoding, codeBytes)
+
+	res := &Font{
+		CreationDate: creationDate,
+		FontInfo:     fi,
+		MM:           mm,
+		Outlines: &Outlines{
+			Private:  private,
+			Glyphs:   glyphs,
+			Encoding: encoding,
+		},
+	}
+	return res, nil
+}
+
+// readCharstrings extracts the deobfuscated charstrings and subroutines
+// from a font dictionary fd with private dictionary pd.
+//
+// The total charstring code (every subr plus every glyph body) is returned
+// as codeBytes, and is used to size a single font-wide decode budget shared
+// across all glyphs.  This bounds total decode work in proportion to the
+// font's charstring data; one runaway glyph can exhaust the budget and blank
+// the glyphs decoded after it, but the budget is generous enough that no
+// well-formed font trips it.  Subr lengths are counted after deobfuscation,
+// charstring lengths before (including entries later dropped as too short),
+// matching the amount of interpreter work each contributes.
+func readCharstrings(fd, pd postscript.Dict) (charstrings map[string][]byte, subrs [][]byte, codeBytes int, err error) {
 	lenIV, ok := pd["lenIV"].(postscript.Integer)
 	if !ok {
 		lenIV = 4
 	}
 
-	// total charstring code (every subr plus every glyph body), used to size
-	// a single font-wide decode budget shared across all glyphs.  This bounds
-	// total decode work in proportion to the font's charstring data; one
-	// runaway glyph can exhaust the budget and blank the glyphs decoded after
-	// it, but the budget is generous enough that no well-formed font trips it.
-	// Subr lengths are counted after deobfuscation, charstring lengths before
-	// (including entries later dropped as too short), matching the amount of
-	// interpreter work each contributes.
-	codeBytes := 0
-
-	var subrs [][]byte
 	if subrsArray, ok := pd["Subrs"].(postscript.Array); ok {
 		for _, cipherObj := range subrsArray {
 			cipher, ok := cipherObj.(postscript.String)
@@ -309,14 +351,14 @@ creationDateLoop:
 
 	cs, ok := fd["CharStrings"].(postscript.Dict)
 	if !ok {
-		return nil, errors.New("missing/invalid CharStrings dictionary")
+		return nil, nil, 0, errors.New("missing/invalid CharStrings dictionary")
 	}
 	for _, obfuscatedObj := range cs {
 		if s, ok := obfuscatedObj.(postscript.String); ok {
 			codeBytes += len(s)
 		}
 	}
-	charstrings := make(map[string][]byte)
+	charstrings = make(map[string][]byte)
 	for name, obfuscatedObj := range cs {
 		obfuscated, ok := obfuscatedObj.(postscript.String)
 		if !ok || len(obfuscated) < 4 {
@@ -324,37 +366,15 @@ creationDateLoop:
 		}
 		charstrings[string(name)] = deobfuscateCharstring(obfuscated, int(lenIV))
 	}
-
-	mm := readMMInfo(fd, fontInfo)
-	var weightVector []float64
-	if mm != nil {
-		mm.charstrings = charstrings
-		mm.subrs = subrs
-		mm.codeBytes = codeBytes
-		weightVector = mm.WeightVector
-	}
-
-	glyphs := decodeGlyphs(charstrings, subrs, weightVector, encoding, codeBytes)
-
-	res := &Font{
-		CreationDate: creationDate,
-		FontInfo:     fi,
-		MM:           mm,
-		Outlines: &Outlines{
-			Private:  private,
-			Glyphs:   glyphs,
-			Encoding: encoding,
-		},
-	}
-	return res, nil
+	return charstrings, subrs, codeBytes, nil
 }
 
 // decodeGlyphs decodes the deobfuscated charstrings into glyphs, resolves
 // seac composites and synthesises a fallback ".notdef".  Encoding entries
 // that reference a missing glyph are rewritten to ".notdef".  weightVector
 // is the font's blend weights (nil for non-MM fonts).  codeBytes sizes the
-// font-wide charstring decode budget; see the comment in Read for how it is
-// computed.
+// font-wide charstring decode budget; see the comment in readCharstrings for
+// how it is computed.
 func decodeGlyphs(charstrings map[string][]byte, subrs [][]byte, weightVector []float64, encoding []string, codeBytes int) map[string]*Glyph {
 	ctx := &decodeInfo{
 		subrs:        subrs,